@@ -1,25 +1,42 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
+	"time"
 
 	pb "muzz-explore/internal/api"
+	"muzz-explore/internal/pagetoken"
 	database "muzz-explore/internal/store/database"
+	"muzz-explore/internal/store/memory"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/postgres"
+	"muzz-explore/internal/store/sqlite"
 	server "muzz-explore/server"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 )
 
 const configFilePath = "/etc/explore-svc/config.json"
 
+// Database drivers the decisions can be stored with.
+const (
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite"
+	driverMemory   = "memory"
+)
+
 // Configuration for the service.
 type Configuration struct {
 	DBUser string `json:"dbUser"`
@@ -27,6 +44,44 @@ type Configuration struct {
 	DBHost string `json:"dbHost"`
 	DBPort string `json:"dbPort"`
 	DBName string `json:"dbName"`
+	// DBDriver is the database the decisions are stored in: "mysql" (the default), "postgres",
+	// "sqlite" or "memory". SQLite only uses DBName, as the path of the database file. The in-memory
+	// store runs the service without a database: decisions are lost on shutdown, and not shared
+	// between replicas.
+	DBDriver string `json:"dbDriver"`
+
+	// PageTokenKey is the secret pagination tokens are signed with. It must be at least 32 bytes
+	// long, and shared by all the replicas of the service.
+	PageTokenKey string `json:"pageTokenKey"`
+	// EncryptPageTokens makes pagination tokens opaque, on top of signed.
+	EncryptPageTokens bool `json:"encryptPageTokens"`
+
+	// UserIDMaxLength is the maximum length of the user IDs accepted, in characters. Zero keeps the
+	// default, which matches the size of the user ID columns.
+	UserIDMaxLength int `json:"userIDMaxLength"`
+	// UserIDPattern, if set, is a regular expression user IDs must match as a whole.
+	UserIDPattern string `json:"userIDPattern"`
+
+	// WatchBufferSize is the number of like events buffered per WatchLikes stream, before failing the
+	// streams that don't keep up. WatchHistorySize is the number of events kept to resume streams.
+	// Zero keeps the defaults.
+	WatchBufferSize  int `json:"watchBufferSize"`
+	WatchHistorySize int `json:"watchHistorySize"`
+
+	// UndoWindowSeconds is how long after a decision changes it can be undone. Zero keeps the default.
+	UndoWindowSeconds int `json:"undoWindowSeconds"`
+
+	// MaxPageSize is the largest page of likes clients can ask for. Zero keeps the default.
+	MaxPageSize int `json:"maxPageSize"`
+
+	// RequireSchemaUpToDate makes the service refuse to start when there are migrations pending.
+	RequireSchemaUpToDate bool `json:"requireSchemaUpToDate"`
+}
+
+// sqlStore is a store kept in a database, with a versioned schema.
+type sqlStore interface {
+	server.DecisionStore
+	Migrator() (*migrations.Migrator, error)
 }
 
 func main() {
@@ -35,11 +90,45 @@ func main() {
 		log.Fatal().Msgf("Error reading config file: %v", err)
 	}
 
-	db, dbClose, err := database.NewClient(cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	var ds server.DecisionStore
+	var dsClose func() error
+	if cfg.DBDriver == driverMemory {
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			log.Fatal().Msg("the in-memory store has no schema to migrate")
+		}
+		log.Warn().Msg("using the in-memory store: decisions are lost on shutdown, and not shared between replicas")
+		ds, dsClose = memory.New(), func() error { return nil }
+	} else {
+		ds, dsClose = openDatabase(cfg)
+	}
+	pageTokens, err := pagetoken.NewCodec([]byte(cfg.PageTokenKey), cfg.EncryptPageTokens)
 	if err != nil {
-		log.Fatal().Msgf("failed to create database client: %v", err)
+		log.Fatal().Msgf("failed to create page token codec: %v", err)
+	}
+	userIDRules, err := cfg.userIDRules()
+	if err != nil {
+		log.Fatal().Msgf("invalid user ID rules: %v", err)
+	}
+	watchBufferSize, watchHistorySize, err := cfg.watchSizes()
+	if err != nil {
+		log.Fatal().Msgf("invalid watch settings: %v", err)
+	}
+	undoWindow, err := cfg.undoWindow()
+	if err != nil {
+		log.Fatal().Msgf("invalid undo settings: %v", err)
+	}
+	maxPageSize, err := cfg.maxPageSize()
+	if err != nil {
+		log.Fatal().Msgf("invalid page settings: %v", err)
 	}
-	explorerService := server.NewServiceServer(db)
+	explorerService := server.NewServiceServer(
+		ds,
+		server.WithPageTokenCodec(pageTokens),
+		server.WithUserIDRules(userIDRules),
+		server.WithLikesHub(server.NewMemoryHub(watchBufferSize, watchHistorySize)),
+		server.WithUndoWindow(undoWindow),
+		server.WithMaxPageSize(maxPageSize),
+	)
 
 	tcpListener, err := net.Listen("tcp", ":8080")
 	if err != nil {
@@ -65,9 +154,115 @@ func main() {
 	// relaying DB closing to the ServiceServer as that's the module using it.
 
 	// Close DB.
-	if err := dbClose(); err != nil {
-		log.Warn().Err(err).Msg("failed to mark decisions as seen")
+	if err := dsClose(); err != nil {
+		log.Warn().Err(err).Msg("failed to close store")
+	}
+}
+
+// openDatabase connects to the database of the configured driver, checking its schema is up to date
+// if required. When the migrate subcommand is given, it manages the schema and exits instead.
+func openDatabase(cfg *Configuration) (server.DecisionStore, func() error) {
+	var db sqlStore
+	var dbClose func() error
+	var err error
+	switch cfg.DBDriver {
+	case "", driverMySQL:
+		db, dbClose, err = database.NewClient(cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	case driverPostgres:
+		db, dbClose, err = postgres.NewClient(cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	case driverSQLite:
+		db, dbClose, err = sqlite.NewClient(cfg.DBName)
+	default:
+		log.Fatal().Msgf("unknown database driver %q", cfg.DBDriver)
+	}
+	if err != nil {
+		log.Fatal().Msgf("failed to create database client: %v", err)
+	}
+	migrator, err := db.Migrator()
+	if err != nil {
+		log.Fatal().Msgf("failed to create migrator: %v", err)
+	}
+
+	// The migrate subcommand manages the schema instead of serving.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(context.Background(), migrator, os.Args[2:])
+		if closeErr := dbClose(); closeErr != nil {
+			log.Warn().Err(closeErr).Msg("failed to close database")
+		}
+		if err != nil {
+			log.Fatal().Msgf("failed to migrate: %v", err)
+		}
+		os.Exit(0)
+	}
+	if cfg.RequireSchemaUpToDate {
+		if err := migrator.Check(context.Background()); err != nil {
+			log.Fatal().Msgf("refusing to start: %v", err)
+		}
+	}
+	return db, dbClose
+}
+
+// userIDRules builds the rules user IDs are validated with out of the configuration. A negative
+// maximum length is rejected, as the validation would take it for no limit at all.
+func (cfg *Configuration) userIDRules() (server.UserIDRules, error) {
+	if cfg.UserIDMaxLength < 0 {
+		return server.UserIDRules{}, fmt.Errorf("userIDMaxLength must not be negative, got %d", cfg.UserIDMaxLength)
+	}
+	rules := server.DefaultUserIDRules
+	if cfg.UserIDMaxLength != 0 {
+		rules.MaxLength = cfg.UserIDMaxLength
+	}
+	if cfg.UserIDPattern != "" {
+		pattern, err := regexp.Compile("^(?:" + cfg.UserIDPattern + ")$")
+		if err != nil {
+			return server.UserIDRules{}, fmt.Errorf("failed to compile user ID pattern: %w", err)
+		}
+		rules.Pattern = pattern
+	}
+	return rules, nil
+}
+
+// watchSizes returns the number of like events buffered per WatchLikes stream, and the number of them
+// kept to resume streams, out of the configuration. The hub can't work without room for events, so
+// negative sizes are rejected.
+func (cfg *Configuration) watchSizes() (int, int, error) {
+	if cfg.WatchBufferSize < 0 {
+		return 0, 0, fmt.Errorf("watchBufferSize must not be negative, got %d", cfg.WatchBufferSize)
+	}
+	if cfg.WatchHistorySize < 0 {
+		return 0, 0, fmt.Errorf("watchHistorySize must not be negative, got %d", cfg.WatchHistorySize)
+	}
+	return orDefault(cfg.WatchBufferSize, server.DefaultWatchBufferSize),
+		orDefault(cfg.WatchHistorySize, server.DefaultWatchHistorySize), nil
+}
+
+// undoWindow returns how long decisions can be undone, out of the configuration. A negative window
+// is rejected, as no decision could ever be undone with it.
+func (cfg *Configuration) undoWindow() (time.Duration, error) {
+	if cfg.UndoWindowSeconds < 0 {
+		return 0, fmt.Errorf("undoWindowSeconds must not be negative, got %d", cfg.UndoWindowSeconds)
+	}
+	if cfg.UndoWindowSeconds == 0 {
+		return server.DefaultUndoWindow, nil
+	}
+	return time.Duration(cfg.UndoWindowSeconds) * time.Second, nil
+}
+
+// maxPageSize returns the largest page of likes clients can ask for, out of the configuration. A
+// negative size is rejected, as the page sizes capped to it would be ignored.
+func (cfg *Configuration) maxPageSize() (int, error) {
+	if cfg.MaxPageSize < 0 {
+		return 0, fmt.Errorf("maxPageSize must not be negative, got %d", cfg.MaxPageSize)
+	}
+	return orDefault(cfg.MaxPageSize, server.DefaultMaxPageSize), nil
+}
+
+// orDefault returns value, or def if it's not set.
+func orDefault(value, def int) int {
+	if value == 0 {
+		return def
 	}
+	return value
 }
 
 func WaitForShutdown(closers ...io.Closer) {
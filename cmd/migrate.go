@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"muzz-explore/internal/store/migrations"
+
+	"github.com/rs/zerolog/log"
+)
+
+const migrateUsage = "usage: explore-server migrate up | down [steps] | status"
+
+// runMigrate runs the migrate subcommand, which applies, reverts or lists the schema migrations.
+func runMigrate(ctx context.Context, migrator *migrations.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to apply migrations (%d applied): %w", applied, err)
+		}
+		log.Info().Msgf("%d migrations applied, schema at version %d", applied, migrator.Latest())
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q: %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return fmt.Errorf("failed to revert migrations (%d reverted): %w", reverted, err)
+		}
+		log.Info().Msgf("%d migrations reverted", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get migrations status: %w", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = fmt.Sprintf("applied at %d", status.AppliedAt)
+			}
+			fmt.Printf("%04d %s: %s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q: %s", args[0], migrateUsage)
+	}
+	return nil
+}
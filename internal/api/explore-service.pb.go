@@ -20,13 +20,231 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// How the likes returned by a listing are marked as seen by the recipient.
+type AcknowledgementMode int32
+
+const (
+	AcknowledgementMode_ACKNOWLEDGEMENT_MODE_UNSPECIFIED AcknowledgementMode = 0 // Same as implicit, kept as default for backwards compatibility
+	AcknowledgementMode_ACKNOWLEDGEMENT_MODE_IMPLICIT    AcknowledgementMode = 1 // Likes are marked as seen as soon as they are listed
+	AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT    AcknowledgementMode = 2 // Likes are only marked as seen through AcknowledgeLikes
+)
+
+// Enum value maps for AcknowledgementMode.
+var (
+	AcknowledgementMode_name = map[int32]string{
+		0: "ACKNOWLEDGEMENT_MODE_UNSPECIFIED",
+		1: "ACKNOWLEDGEMENT_MODE_IMPLICIT",
+		2: "ACKNOWLEDGEMENT_MODE_EXPLICIT",
+	}
+	AcknowledgementMode_value = map[string]int32{
+		"ACKNOWLEDGEMENT_MODE_UNSPECIFIED": 0,
+		"ACKNOWLEDGEMENT_MODE_IMPLICIT":    1,
+		"ACKNOWLEDGEMENT_MODE_EXPLICIT":    2,
+	}
+)
+
+func (x AcknowledgementMode) Enum() *AcknowledgementMode {
+	p := new(AcknowledgementMode)
+	*p = x
+	return p
+}
+
+func (x AcknowledgementMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AcknowledgementMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_api_explore_service_proto_enumTypes[0].Descriptor()
+}
+
+func (AcknowledgementMode) Type() protoreflect.EnumType {
+	return &file_internal_api_explore_service_proto_enumTypes[0]
+}
+
+func (x AcknowledgementMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AcknowledgementMode.Descriptor instead.
+func (AcknowledgementMode) EnumDescriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{0}
+}
+
+// Which likes are considered new by ListNewLikedYou.
+type NewLikesFilter int32
+
+const (
+	NewLikesFilter_NEW_LIKES_FILTER_UNSPECIFIED               NewLikesFilter = 0 // Same as unseen, kept as default for backwards compatibility
+	NewLikesFilter_NEW_LIKES_FILTER_UNSEEN                    NewLikesFilter = 1 // Likes not seen by the recipient yet
+	NewLikesFilter_NEW_LIKES_FILTER_UNRECIPROCATED            NewLikesFilter = 2 // Likes the recipient hasn't liked in return
+	NewLikesFilter_NEW_LIKES_FILTER_UNSEEN_AND_UNRECIPROCATED NewLikesFilter = 3 // Likes both unseen and not liked in return
+)
+
+// Enum value maps for NewLikesFilter.
+var (
+	NewLikesFilter_name = map[int32]string{
+		0: "NEW_LIKES_FILTER_UNSPECIFIED",
+		1: "NEW_LIKES_FILTER_UNSEEN",
+		2: "NEW_LIKES_FILTER_UNRECIPROCATED",
+		3: "NEW_LIKES_FILTER_UNSEEN_AND_UNRECIPROCATED",
+	}
+	NewLikesFilter_value = map[string]int32{
+		"NEW_LIKES_FILTER_UNSPECIFIED":               0,
+		"NEW_LIKES_FILTER_UNSEEN":                    1,
+		"NEW_LIKES_FILTER_UNRECIPROCATED":            2,
+		"NEW_LIKES_FILTER_UNSEEN_AND_UNRECIPROCATED": 3,
+	}
+)
+
+func (x NewLikesFilter) Enum() *NewLikesFilter {
+	p := new(NewLikesFilter)
+	*p = x
+	return p
+}
+
+func (x NewLikesFilter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NewLikesFilter) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_api_explore_service_proto_enumTypes[1].Descriptor()
+}
+
+func (NewLikesFilter) Type() protoreflect.EnumType {
+	return &file_internal_api_explore_service_proto_enumTypes[1]
+}
+
+func (x NewLikesFilter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NewLikesFilter.Descriptor instead.
+func (NewLikesFilter) EnumDescriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{1}
+}
+
+// Order of the likes listed by ListLikedYou and ListNewLikedYou.
+type SortOrder int32
+
+const (
+	SortOrder_SORT_ORDER_UNSPECIFIED SortOrder = 0 // Same as actor ID, kept as default for backwards compatibility
+	SortOrder_SORT_ORDER_ACTOR_ID    SortOrder = 1 // Super-likes first, then by actor ID
+	SortOrder_SORT_ORDER_NEWEST      SortOrder = 2 // Last changed first
+	SortOrder_SORT_ORDER_OLDEST      SortOrder = 3 // Last changed last
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "SORT_ORDER_UNSPECIFIED",
+		1: "SORT_ORDER_ACTOR_ID",
+		2: "SORT_ORDER_NEWEST",
+		3: "SORT_ORDER_OLDEST",
+	}
+	SortOrder_value = map[string]int32{
+		"SORT_ORDER_UNSPECIFIED": 0,
+		"SORT_ORDER_ACTOR_ID":    1,
+		"SORT_ORDER_NEWEST":      2,
+		"SORT_ORDER_OLDEST":      3,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_api_explore_service_proto_enumTypes[2].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_internal_api_explore_service_proto_enumTypes[2]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{2}
+}
+
+type DecisionType int32
+
+const (
+	DecisionType_DECISION_TYPE_UNSPECIFIED DecisionType = 0 // Taken from liked_recipient, for backwards compatibility
+	DecisionType_DECISION_TYPE_PASS        DecisionType = 1
+	DecisionType_DECISION_TYPE_LIKE        DecisionType = 2
+	DecisionType_DECISION_TYPE_SUPER_LIKE  DecisionType = 3 // Listed first in the likes of the recipient
+	DecisionType_DECISION_TYPE_BLOCK       DecisionType = 4 // Hides both users from each other
+)
+
+// Enum value maps for DecisionType.
+var (
+	DecisionType_name = map[int32]string{
+		0: "DECISION_TYPE_UNSPECIFIED",
+		1: "DECISION_TYPE_PASS",
+		2: "DECISION_TYPE_LIKE",
+		3: "DECISION_TYPE_SUPER_LIKE",
+		4: "DECISION_TYPE_BLOCK",
+	}
+	DecisionType_value = map[string]int32{
+		"DECISION_TYPE_UNSPECIFIED": 0,
+		"DECISION_TYPE_PASS":        1,
+		"DECISION_TYPE_LIKE":        2,
+		"DECISION_TYPE_SUPER_LIKE":  3,
+		"DECISION_TYPE_BLOCK":       4,
+	}
+)
+
+func (x DecisionType) Enum() *DecisionType {
+	p := new(DecisionType)
+	*p = x
+	return p
+}
+
+func (x DecisionType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DecisionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_api_explore_service_proto_enumTypes[3].Descriptor()
+}
+
+func (DecisionType) Type() protoreflect.EnumType {
+	return &file_internal_api_explore_service_proto_enumTypes[3]
+}
+
+func (x DecisionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DecisionType.Descriptor instead.
+func (DecisionType) EnumDescriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{3}
+}
+
 type ListLikedYouRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RecipientUserId string  `protobuf:"bytes,1,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
-	PaginationToken *string `protobuf:"bytes,2,opt,name=pagination_token,json=paginationToken,proto3,oneof" json:"pagination_token,omitempty"`
+	RecipientUserId     string              `protobuf:"bytes,1,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+	PaginationToken     *string             `protobuf:"bytes,2,opt,name=pagination_token,json=paginationToken,proto3,oneof" json:"pagination_token,omitempty"`
+	AcknowledgementMode AcknowledgementMode `protobuf:"varint,3,opt,name=acknowledgement_mode,json=acknowledgementMode,proto3,enum=api.AcknowledgementMode" json:"acknowledgement_mode,omitempty"`
+	NewLikesFilter      NewLikesFilter      `protobuf:"varint,4,opt,name=new_likes_filter,json=newLikesFilter,proto3,enum=api.NewLikesFilter" json:"new_likes_filter,omitempty"` // Only used by ListNewLikedYou
+	SortOrder           SortOrder           `protobuf:"varint,5,opt,name=sort_order,json=sortOrder,proto3,enum=api.SortOrder" json:"sort_order,omitempty"`                       // Pagination tokens are only valid with the sort order they were returned for
+	SinceUnixTimestamp  uint64              `protobuf:"varint,6,opt,name=since_unix_timestamp,json=sinceUnixTimestamp,proto3" json:"since_unix_timestamp,omitempty"`             // Only list likes last changed at or after this time, if set
+	UntilUnixTimestamp  uint64              `protobuf:"varint,7,opt,name=until_unix_timestamp,json=untilUnixTimestamp,proto3" json:"until_unix_timestamp,omitempty"`             // Only list likes last changed before this time, if set
+	PageSize            *uint32             `protobuf:"varint,8,opt,name=page_size,json=pageSize,proto3,oneof" json:"page_size,omitempty"`                                       // Capped to the maximum of the service, 10 if not set
+	IncludeTotalCount   bool                `protobuf:"varint,9,opt,name=include_total_count,json=includeTotalCount,proto3" json:"include_total_count,omitempty"`                // Count all the likes listed across pages, in total_count
 }
 
 func (x *ListLikedYouRequest) Reset() {
@@ -73,6 +291,55 @@ func (x *ListLikedYouRequest) GetPaginationToken() string {
 	return ""
 }
 
+func (x *ListLikedYouRequest) GetAcknowledgementMode() AcknowledgementMode {
+	if x != nil {
+		return x.AcknowledgementMode
+	}
+	return AcknowledgementMode_ACKNOWLEDGEMENT_MODE_UNSPECIFIED
+}
+
+func (x *ListLikedYouRequest) GetNewLikesFilter() NewLikesFilter {
+	if x != nil {
+		return x.NewLikesFilter
+	}
+	return NewLikesFilter_NEW_LIKES_FILTER_UNSPECIFIED
+}
+
+func (x *ListLikedYouRequest) GetSortOrder() SortOrder {
+	if x != nil {
+		return x.SortOrder
+	}
+	return SortOrder_SORT_ORDER_UNSPECIFIED
+}
+
+func (x *ListLikedYouRequest) GetSinceUnixTimestamp() uint64 {
+	if x != nil {
+		return x.SinceUnixTimestamp
+	}
+	return 0
+}
+
+func (x *ListLikedYouRequest) GetUntilUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UntilUnixTimestamp
+	}
+	return 0
+}
+
+func (x *ListLikedYouRequest) GetPageSize() uint32 {
+	if x != nil && x.PageSize != nil {
+		return *x.PageSize
+	}
+	return 0
+}
+
+func (x *ListLikedYouRequest) GetIncludeTotalCount() bool {
+	if x != nil {
+		return x.IncludeTotalCount
+	}
+	return false
+}
+
 type ListLikedYouResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -80,6 +347,7 @@ type ListLikedYouResponse struct {
 
 	Likers              []*ListLikedYouResponse_Liker `protobuf:"bytes,1,rep,name=likers,proto3" json:"likers,omitempty"`
 	NextPaginationToken *string                       `protobuf:"bytes,2,opt,name=next_pagination_token,json=nextPaginationToken,proto3,oneof" json:"next_pagination_token,omitempty"`
+	TotalCount          *uint64                       `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3,oneof" json:"total_count,omitempty"` // Only set if include_total_count was requested
 }
 
 func (x *ListLikedYouResponse) Reset() {
@@ -126,6 +394,151 @@ func (x *ListLikedYouResponse) GetNextPaginationToken() string {
 	return ""
 }
 
+func (x *ListLikedYouResponse) GetTotalCount() uint64 {
+	if x != nil && x.TotalCount != nil {
+		return *x.TotalCount
+	}
+	return 0
+}
+
+type ListYouLikedRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorUserId        string         `protobuf:"bytes,1,opt,name=actor_user_id,json=actorUserId,proto3" json:"actor_user_id,omitempty"`
+	PaginationToken    *string        `protobuf:"bytes,2,opt,name=pagination_token,json=paginationToken,proto3,oneof" json:"pagination_token,omitempty"`
+	DecisionTypes      []DecisionType `protobuf:"varint,3,rep,packed,name=decision_types,json=decisionTypes,proto3,enum=api.DecisionType" json:"decision_types,omitempty"` // Only list decisions of these types, all of them if empty
+	PendingOnly        bool           `protobuf:"varint,4,opt,name=pending_only,json=pendingOnly,proto3" json:"pending_only,omitempty"`                                    // Only list likes not liked in return yet
+	SinceUnixTimestamp uint64         `protobuf:"varint,5,opt,name=since_unix_timestamp,json=sinceUnixTimestamp,proto3" json:"since_unix_timestamp,omitempty"`             // Only list decisions last changed at or after this time, if set
+	UntilUnixTimestamp uint64         `protobuf:"varint,6,opt,name=until_unix_timestamp,json=untilUnixTimestamp,proto3" json:"until_unix_timestamp,omitempty"`             // Only list decisions last changed before this time, if set
+}
+
+func (x *ListYouLikedRequest) Reset() {
+	*x = ListYouLikedRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListYouLikedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListYouLikedRequest) ProtoMessage() {}
+
+func (x *ListYouLikedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListYouLikedRequest.ProtoReflect.Descriptor instead.
+func (*ListYouLikedRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListYouLikedRequest) GetActorUserId() string {
+	if x != nil {
+		return x.ActorUserId
+	}
+	return ""
+}
+
+func (x *ListYouLikedRequest) GetPaginationToken() string {
+	if x != nil && x.PaginationToken != nil {
+		return *x.PaginationToken
+	}
+	return ""
+}
+
+func (x *ListYouLikedRequest) GetDecisionTypes() []DecisionType {
+	if x != nil {
+		return x.DecisionTypes
+	}
+	return nil
+}
+
+func (x *ListYouLikedRequest) GetPendingOnly() bool {
+	if x != nil {
+		return x.PendingOnly
+	}
+	return false
+}
+
+func (x *ListYouLikedRequest) GetSinceUnixTimestamp() uint64 {
+	if x != nil {
+		return x.SinceUnixTimestamp
+	}
+	return 0
+}
+
+func (x *ListYouLikedRequest) GetUntilUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UntilUnixTimestamp
+	}
+	return 0
+}
+
+type ListYouLikedResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Decisions           []*ListYouLikedResponse_Decision `protobuf:"bytes,1,rep,name=decisions,proto3" json:"decisions,omitempty"`
+	NextPaginationToken *string                          `protobuf:"bytes,2,opt,name=next_pagination_token,json=nextPaginationToken,proto3,oneof" json:"next_pagination_token,omitempty"`
+}
+
+func (x *ListYouLikedResponse) Reset() {
+	*x = ListYouLikedResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListYouLikedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListYouLikedResponse) ProtoMessage() {}
+
+func (x *ListYouLikedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListYouLikedResponse.ProtoReflect.Descriptor instead.
+func (*ListYouLikedResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListYouLikedResponse) GetDecisions() []*ListYouLikedResponse_Decision {
+	if x != nil {
+		return x.Decisions
+	}
+	return nil
+}
+
+func (x *ListYouLikedResponse) GetNextPaginationToken() string {
+	if x != nil && x.NextPaginationToken != nil {
+		return *x.NextPaginationToken
+	}
+	return ""
+}
+
 type CountLikedYouRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -136,7 +549,7 @@ type CountLikedYouRequest struct {
 
 func (x *CountLikedYouRequest) Reset() {
 	*x = CountLikedYouRequest{}
-	mi := &file_internal_api_explore_service_proto_msgTypes[2]
+	mi := &file_internal_api_explore_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -148,7 +561,7 @@ func (x *CountLikedYouRequest) String() string {
 func (*CountLikedYouRequest) ProtoMessage() {}
 
 func (x *CountLikedYouRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_explore_service_proto_msgTypes[2]
+	mi := &file_internal_api_explore_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -161,7 +574,7 @@ func (x *CountLikedYouRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountLikedYouRequest.ProtoReflect.Descriptor instead.
 func (*CountLikedYouRequest) Descriptor() ([]byte, []int) {
-	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{2}
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *CountLikedYouRequest) GetRecipientUserId() string {
@@ -181,7 +594,7 @@ type CountLikedYouResponse struct {
 
 func (x *CountLikedYouResponse) Reset() {
 	*x = CountLikedYouResponse{}
-	mi := &file_internal_api_explore_service_proto_msgTypes[3]
+	mi := &file_internal_api_explore_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -193,7 +606,7 @@ func (x *CountLikedYouResponse) String() string {
 func (*CountLikedYouResponse) ProtoMessage() {}
 
 func (x *CountLikedYouResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_explore_service_proto_msgTypes[3]
+	mi := &file_internal_api_explore_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -206,7 +619,7 @@ func (x *CountLikedYouResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountLikedYouResponse.ProtoReflect.Descriptor instead.
 func (*CountLikedYouResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{3}
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CountLikedYouResponse) GetCount() uint64 {
@@ -221,14 +634,15 @@ type PutDecisionRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ActorUserId     string `protobuf:"bytes,1,opt,name=actor_user_id,json=actorUserId,proto3" json:"actor_user_id,omitempty"`
-	RecipientUserId string `protobuf:"bytes,2,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
-	LikedRecipient  bool   `protobuf:"varint,3,opt,name=liked_recipient,json=likedRecipient,proto3" json:"liked_recipient,omitempty"`
+	ActorUserId     string       `protobuf:"bytes,1,opt,name=actor_user_id,json=actorUserId,proto3" json:"actor_user_id,omitempty"`
+	RecipientUserId string       `protobuf:"bytes,2,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+	LikedRecipient  bool         `protobuf:"varint,3,opt,name=liked_recipient,json=likedRecipient,proto3" json:"liked_recipient,omitempty"` // Deprecated: use decision_type, which takes precedence when set
+	DecisionType    DecisionType `protobuf:"varint,4,opt,name=decision_type,json=decisionType,proto3,enum=api.DecisionType" json:"decision_type,omitempty"`
 }
 
 func (x *PutDecisionRequest) Reset() {
 	*x = PutDecisionRequest{}
-	mi := &file_internal_api_explore_service_proto_msgTypes[4]
+	mi := &file_internal_api_explore_service_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -240,7 +654,7 @@ func (x *PutDecisionRequest) String() string {
 func (*PutDecisionRequest) ProtoMessage() {}
 
 func (x *PutDecisionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_explore_service_proto_msgTypes[4]
+	mi := &file_internal_api_explore_service_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -253,7 +667,7 @@ func (x *PutDecisionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutDecisionRequest.ProtoReflect.Descriptor instead.
 func (*PutDecisionRequest) Descriptor() ([]byte, []int) {
-	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{4}
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PutDecisionRequest) GetActorUserId() string {
@@ -277,6 +691,13 @@ func (x *PutDecisionRequest) GetLikedRecipient() bool {
 	return false
 }
 
+func (x *PutDecisionRequest) GetDecisionType() DecisionType {
+	if x != nil {
+		return x.DecisionType
+	}
+	return DecisionType_DECISION_TYPE_UNSPECIFIED
+}
+
 type PutDecisionResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -287,7 +708,7 @@ type PutDecisionResponse struct {
 
 func (x *PutDecisionResponse) Reset() {
 	*x = PutDecisionResponse{}
-	mi := &file_internal_api_explore_service_proto_msgTypes[5]
+	mi := &file_internal_api_explore_service_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -299,7 +720,7 @@ func (x *PutDecisionResponse) String() string {
 func (*PutDecisionResponse) ProtoMessage() {}
 
 func (x *PutDecisionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_explore_service_proto_msgTypes[5]
+	mi := &file_internal_api_explore_service_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -312,7 +733,7 @@ func (x *PutDecisionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutDecisionResponse.ProtoReflect.Descriptor instead.
 func (*PutDecisionResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{5}
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *PutDecisionResponse) GetMutualLikes() bool {
@@ -322,30 +743,1225 @@ func (x *PutDecisionResponse) GetMutualLikes() bool {
 	return false
 }
 
-type ListLikedYouResponse_Liker struct {
+type PutDecisionsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ActorId       string `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
-	UnixTimestamp uint64 `protobuf:"varint,2,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"`
+	Decisions []*PutDecisionRequest `protobuf:"bytes,1,rep,name=decisions,proto3" json:"decisions,omitempty"` // Applied in order, so the last decision on a recipient wins
 }
 
-func (x *ListLikedYouResponse_Liker) Reset() {
+func (x *PutDecisionsRequest) Reset() {
+	*x = PutDecisionsRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutDecisionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutDecisionsRequest) ProtoMessage() {}
+
+func (x *PutDecisionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutDecisionsRequest.ProtoReflect.Descriptor instead.
+func (*PutDecisionsRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PutDecisionsRequest) GetDecisions() []*PutDecisionRequest {
+	if x != nil {
+		return x.Decisions
+	}
+	return nil
+}
+
+type PutDecisionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*PutDecisionsResponse_Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // One per decision, in the same order as the request
+}
+
+func (x *PutDecisionsResponse) Reset() {
+	*x = PutDecisionsResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutDecisionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutDecisionsResponse) ProtoMessage() {}
+
+func (x *PutDecisionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutDecisionsResponse.ProtoReflect.Descriptor instead.
+func (*PutDecisionsResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PutDecisionsResponse) GetResults() []*PutDecisionsResponse_Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type SwipeSessionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string              `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // Chosen by the client, sent back on the events caused by this decision
+	Decision  *PutDecisionRequest `protobuf:"bytes,2,opt,name=decision,proto3" json:"decision,omitempty"`
+}
+
+func (x *SwipeSessionRequest) Reset() {
+	*x = SwipeSessionRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SwipeSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwipeSessionRequest) ProtoMessage() {}
+
+func (x *SwipeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwipeSessionRequest.ProtoReflect.Descriptor instead.
+func (*SwipeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SwipeSessionRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SwipeSessionRequest) GetDecision() *PutDecisionRequest {
+	if x != nil {
+		return x.Decision
+	}
+	return nil
+}
+
+type SwipeSessionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*SwipeSessionResponse_Ack_
+	//	*SwipeSessionResponse_Match_
+	Event isSwipeSessionResponse_Event `protobuf_oneof:"event"`
+}
+
+func (x *SwipeSessionResponse) Reset() {
+	*x = SwipeSessionResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SwipeSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwipeSessionResponse) ProtoMessage() {}
+
+func (x *SwipeSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwipeSessionResponse.ProtoReflect.Descriptor instead.
+func (*SwipeSessionResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (m *SwipeSessionResponse) GetEvent() isSwipeSessionResponse_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *SwipeSessionResponse) GetAck() *SwipeSessionResponse_Ack {
+	if x, ok := x.GetEvent().(*SwipeSessionResponse_Ack_); ok {
+		return x.Ack
+	}
+	return nil
+}
+
+func (x *SwipeSessionResponse) GetMatch() *SwipeSessionResponse_Match {
+	if x, ok := x.GetEvent().(*SwipeSessionResponse_Match_); ok {
+		return x.Match
+	}
+	return nil
+}
+
+type isSwipeSessionResponse_Event interface {
+	isSwipeSessionResponse_Event()
+}
+
+type SwipeSessionResponse_Ack_ struct {
+	Ack *SwipeSessionResponse_Ack `protobuf:"bytes,1,opt,name=ack,proto3,oneof"` // Sent for every decision, in order
+}
+
+type SwipeSessionResponse_Match_ struct {
+	Match *SwipeSessionResponse_Match `protobuf:"bytes,2,opt,name=match,proto3,oneof"` // Sent right after the ack of a decision completing a match
+}
+
+func (*SwipeSessionResponse_Ack_) isSwipeSessionResponse_Event() {}
+
+func (*SwipeSessionResponse_Match_) isSwipeSessionResponse_Event() {}
+
+type WatchLikesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RecipientUserId string  `protobuf:"bytes,1,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+	Cursor          *string `protobuf:"bytes,2,opt,name=cursor,proto3,oneof" json:"cursor,omitempty"` // Cursor of the last event received, to resume right after it
+}
+
+func (x *WatchLikesRequest) Reset() {
+	*x = WatchLikesRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLikesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLikesRequest) ProtoMessage() {}
+
+func (x *WatchLikesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLikesRequest.ProtoReflect.Descriptor instead.
+func (*WatchLikesRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchLikesRequest) GetRecipientUserId() string {
+	if x != nil {
+		return x.RecipientUserId
+	}
+	return ""
+}
+
+func (x *WatchLikesRequest) GetCursor() string {
+	if x != nil && x.Cursor != nil {
+		return *x.Cursor
+	}
+	return ""
+}
+
+type WatchLikesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"` // Cursor to resume right after this event
+	// Types that are assignable to Event:
+	//	*WatchLikesResponse_Like_
+	//	*WatchLikesResponse_Match_
+	//	*WatchLikesResponse_Unmatch_
+	Event isWatchLikesResponse_Event `protobuf_oneof:"event"`
+}
+
+func (x *WatchLikesResponse) Reset() {
+	*x = WatchLikesResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLikesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLikesResponse) ProtoMessage() {}
+
+func (x *WatchLikesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLikesResponse.ProtoReflect.Descriptor instead.
+func (*WatchLikesResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchLikesResponse) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (m *WatchLikesResponse) GetEvent() isWatchLikesResponse_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *WatchLikesResponse) GetLike() *WatchLikesResponse_Like {
+	if x, ok := x.GetEvent().(*WatchLikesResponse_Like_); ok {
+		return x.Like
+	}
+	return nil
+}
+
+func (x *WatchLikesResponse) GetMatch() *WatchLikesResponse_Match {
+	if x, ok := x.GetEvent().(*WatchLikesResponse_Match_); ok {
+		return x.Match
+	}
+	return nil
+}
+
+func (x *WatchLikesResponse) GetUnmatch() *WatchLikesResponse_Unmatch {
+	if x, ok := x.GetEvent().(*WatchLikesResponse_Unmatch_); ok {
+		return x.Unmatch
+	}
+	return nil
+}
+
+type isWatchLikesResponse_Event interface {
+	isWatchLikesResponse_Event()
+}
+
+type WatchLikesResponse_Like_ struct {
+	Like *WatchLikesResponse_Like `protobuf:"bytes,2,opt,name=like,proto3,oneof"`
+}
+
+type WatchLikesResponse_Match_ struct {
+	Match *WatchLikesResponse_Match `protobuf:"bytes,3,opt,name=match,proto3,oneof"` // Sent instead of a like when it completes a match, to both users
+}
+
+type WatchLikesResponse_Unmatch_ struct {
+	Unmatch *WatchLikesResponse_Unmatch `protobuf:"bytes,4,opt,name=unmatch,proto3,oneof"` // Sent to both users when their match is dissolved
+}
+
+func (*WatchLikesResponse_Like_) isWatchLikesResponse_Event() {}
+
+func (*WatchLikesResponse_Match_) isWatchLikesResponse_Event() {}
+
+func (*WatchLikesResponse_Unmatch_) isWatchLikesResponse_Event() {}
+
+type UndoDecisionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorUserId     string `protobuf:"bytes,1,opt,name=actor_user_id,json=actorUserId,proto3" json:"actor_user_id,omitempty"`
+	RecipientUserId string `protobuf:"bytes,2,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+}
+
+func (x *UndoDecisionRequest) Reset() {
+	*x = UndoDecisionRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndoDecisionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndoDecisionRequest) ProtoMessage() {}
+
+func (x *UndoDecisionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndoDecisionRequest.ProtoReflect.Descriptor instead.
+func (*UndoDecisionRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UndoDecisionRequest) GetActorUserId() string {
+	if x != nil {
+		return x.ActorUserId
+	}
+	return ""
+}
+
+func (x *UndoDecisionRequest) GetRecipientUserId() string {
+	if x != nil {
+		return x.RecipientUserId
+	}
+	return ""
+}
+
+type UndoDecisionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DecisionType DecisionType `protobuf:"varint,1,opt,name=decision_type,json=decisionType,proto3,enum=api.DecisionType" json:"decision_type,omitempty"` // The decision restored, or unspecified if there was none before
+}
+
+func (x *UndoDecisionResponse) Reset() {
+	*x = UndoDecisionResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndoDecisionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndoDecisionResponse) ProtoMessage() {}
+
+func (x *UndoDecisionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndoDecisionResponse.ProtoReflect.Descriptor instead.
+func (*UndoDecisionResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UndoDecisionResponse) GetDecisionType() DecisionType {
+	if x != nil {
+		return x.DecisionType
+	}
+	return DecisionType_DECISION_TYPE_UNSPECIFIED
+}
+
+type DeleteDecisionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorUserId     string `protobuf:"bytes,1,opt,name=actor_user_id,json=actorUserId,proto3" json:"actor_user_id,omitempty"`
+	RecipientUserId string `protobuf:"bytes,2,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+}
+
+func (x *DeleteDecisionRequest) Reset() {
+	*x = DeleteDecisionRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDecisionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDecisionRequest) ProtoMessage() {}
+
+func (x *DeleteDecisionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDecisionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDecisionRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteDecisionRequest) GetActorUserId() string {
+	if x != nil {
+		return x.ActorUserId
+	}
+	return ""
+}
+
+func (x *DeleteDecisionRequest) GetRecipientUserId() string {
+	if x != nil {
+		return x.RecipientUserId
+	}
+	return ""
+}
+
+type DeleteDecisionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteDecisionResponse) Reset() {
+	*x = DeleteDecisionResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDecisionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDecisionResponse) ProtoMessage() {}
+
+func (x *DeleteDecisionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDecisionResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDecisionResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{17}
+}
+
+type UnmatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId        string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PartnerUserId string `protobuf:"bytes,2,opt,name=partner_user_id,json=partnerUserId,proto3" json:"partner_user_id,omitempty"`
+}
+
+func (x *UnmatchRequest) Reset() {
+	*x = UnmatchRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnmatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnmatchRequest) ProtoMessage() {}
+
+func (x *UnmatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnmatchRequest.ProtoReflect.Descriptor instead.
+func (*UnmatchRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UnmatchRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UnmatchRequest) GetPartnerUserId() string {
+	if x != nil {
+		return x.PartnerUserId
+	}
+	return ""
+}
+
+type UnmatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UnmatchResponse) Reset() {
+	*x = UnmatchResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnmatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnmatchResponse) ProtoMessage() {}
+
+func (x *UnmatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnmatchResponse.ProtoReflect.Descriptor instead.
+func (*UnmatchResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{19}
+}
+
+type GetRelationshipRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ViewerUserId string   `protobuf:"bytes,1,opt,name=viewer_user_id,json=viewerUserId,proto3" json:"viewer_user_id,omitempty"`
+	OtherUserIds []string `protobuf:"bytes,2,rep,name=other_user_ids,json=otherUserIds,proto3" json:"other_user_ids,omitempty"` // Up to 500
+}
+
+func (x *GetRelationshipRequest) Reset() {
+	*x = GetRelationshipRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRelationshipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRelationshipRequest) ProtoMessage() {}
+
+func (x *GetRelationshipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRelationshipRequest.ProtoReflect.Descriptor instead.
+func (*GetRelationshipRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetRelationshipRequest) GetViewerUserId() string {
+	if x != nil {
+		return x.ViewerUserId
+	}
+	return ""
+}
+
+func (x *GetRelationshipRequest) GetOtherUserIds() []string {
+	if x != nil {
+		return x.OtherUserIds
+	}
+	return nil
+}
+
+type GetRelationshipResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Relationships []*GetRelationshipResponse_Relationship `protobuf:"bytes,1,rep,name=relationships,proto3" json:"relationships,omitempty"` // In the order of other_user_ids
+}
+
+func (x *GetRelationshipResponse) Reset() {
+	*x = GetRelationshipResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRelationshipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRelationshipResponse) ProtoMessage() {}
+
+func (x *GetRelationshipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRelationshipResponse.ProtoReflect.Descriptor instead.
+func (*GetRelationshipResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetRelationshipResponse) GetRelationships() []*GetRelationshipResponse_Relationship {
+	if x != nil {
+		return x.Relationships
+	}
+	return nil
+}
+
+type ListMutualMatchesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId          string  `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PaginationToken *string `protobuf:"bytes,2,opt,name=pagination_token,json=paginationToken,proto3,oneof" json:"pagination_token,omitempty"`
+}
+
+func (x *ListMutualMatchesRequest) Reset() {
+	*x = ListMutualMatchesRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMutualMatchesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMutualMatchesRequest) ProtoMessage() {}
+
+func (x *ListMutualMatchesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMutualMatchesRequest.ProtoReflect.Descriptor instead.
+func (*ListMutualMatchesRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListMutualMatchesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListMutualMatchesRequest) GetPaginationToken() string {
+	if x != nil && x.PaginationToken != nil {
+		return *x.PaginationToken
+	}
+	return ""
+}
+
+type ListMutualMatchesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Matches             []*ListMutualMatchesResponse_Match `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	NextPaginationToken *string                            `protobuf:"bytes,2,opt,name=next_pagination_token,json=nextPaginationToken,proto3,oneof" json:"next_pagination_token,omitempty"`
+}
+
+func (x *ListMutualMatchesResponse) Reset() {
+	*x = ListMutualMatchesResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMutualMatchesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMutualMatchesResponse) ProtoMessage() {}
+
+func (x *ListMutualMatchesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMutualMatchesResponse.ProtoReflect.Descriptor instead.
+func (*ListMutualMatchesResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListMutualMatchesResponse) GetMatches() []*ListMutualMatchesResponse_Match {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *ListMutualMatchesResponse) GetNextPaginationToken() string {
+	if x != nil && x.NextPaginationToken != nil {
+		return *x.NextPaginationToken
+	}
+	return ""
+}
+
+type AcknowledgeLikesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RecipientUserId string   `protobuf:"bytes,1,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+	ActorUserIds    []string `protobuf:"bytes,2,rep,name=actor_user_ids,json=actorUserIds,proto3" json:"actor_user_ids,omitempty"` // Actors whose likes were actually shown to the recipient
+}
+
+func (x *AcknowledgeLikesRequest) Reset() {
+	*x = AcknowledgeLikesRequest{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcknowledgeLikesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcknowledgeLikesRequest) ProtoMessage() {}
+
+func (x *AcknowledgeLikesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcknowledgeLikesRequest.ProtoReflect.Descriptor instead.
+func (*AcknowledgeLikesRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *AcknowledgeLikesRequest) GetRecipientUserId() string {
+	if x != nil {
+		return x.RecipientUserId
+	}
+	return ""
+}
+
+func (x *AcknowledgeLikesRequest) GetActorUserIds() []string {
+	if x != nil {
+		return x.ActorUserIds
+	}
+	return nil
+}
+
+type AcknowledgeLikesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *AcknowledgeLikesResponse) Reset() {
+	*x = AcknowledgeLikesResponse{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcknowledgeLikesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcknowledgeLikesResponse) ProtoMessage() {}
+
+func (x *AcknowledgeLikesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcknowledgeLikesResponse.ProtoReflect.Descriptor instead.
+func (*AcknowledgeLikesResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{25}
+}
+
+type ListLikedYouResponse_Liker struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorId              string       `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	UnixTimestamp        uint64       `protobuf:"varint,2,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"`                        // Time of the last change of the decision
+	CreatedUnixTimestamp uint64       `protobuf:"varint,3,opt,name=created_unix_timestamp,json=createdUnixTimestamp,proto3" json:"created_unix_timestamp,omitempty"` // Time of the first decision of the actor on the recipient
+	DecisionType         DecisionType `protobuf:"varint,4,opt,name=decision_type,json=decisionType,proto3,enum=api.DecisionType" json:"decision_type,omitempty"`     // Either a like or a super-like
+}
+
+func (x *ListLikedYouResponse_Liker) Reset() {
 	*x = ListLikedYouResponse_Liker{}
-	mi := &file_internal_api_explore_service_proto_msgTypes[6]
+	mi := &file_internal_api_explore_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLikedYouResponse_Liker) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLikedYouResponse_Liker) ProtoMessage() {}
+
+func (x *ListLikedYouResponse_Liker) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLikedYouResponse_Liker.ProtoReflect.Descriptor instead.
+func (*ListLikedYouResponse_Liker) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *ListLikedYouResponse_Liker) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *ListLikedYouResponse_Liker) GetUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UnixTimestamp
+	}
+	return 0
+}
+
+func (x *ListLikedYouResponse_Liker) GetCreatedUnixTimestamp() uint64 {
+	if x != nil {
+		return x.CreatedUnixTimestamp
+	}
+	return 0
+}
+
+func (x *ListLikedYouResponse_Liker) GetDecisionType() DecisionType {
+	if x != nil {
+		return x.DecisionType
+	}
+	return DecisionType_DECISION_TYPE_UNSPECIFIED
+}
+
+type ListYouLikedResponse_Decision struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RecipientId          string       `protobuf:"bytes,1,opt,name=recipient_id,json=recipientId,proto3" json:"recipient_id,omitempty"`
+	DecisionType         DecisionType `protobuf:"varint,2,opt,name=decision_type,json=decisionType,proto3,enum=api.DecisionType" json:"decision_type,omitempty"`
+	UnixTimestamp        uint64       `protobuf:"varint,3,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"`                        // Time of the last change of the decision
+	CreatedUnixTimestamp uint64       `protobuf:"varint,4,opt,name=created_unix_timestamp,json=createdUnixTimestamp,proto3" json:"created_unix_timestamp,omitempty"` // Time of the first decision of the actor on the recipient
+}
+
+func (x *ListYouLikedResponse_Decision) Reset() {
+	*x = ListYouLikedResponse_Decision{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListYouLikedResponse_Decision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListYouLikedResponse_Decision) ProtoMessage() {}
+
+func (x *ListYouLikedResponse_Decision) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListYouLikedResponse_Decision.ProtoReflect.Descriptor instead.
+func (*ListYouLikedResponse_Decision) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{3, 0}
+}
+
+func (x *ListYouLikedResponse_Decision) GetRecipientId() string {
+	if x != nil {
+		return x.RecipientId
+	}
+	return ""
+}
+
+func (x *ListYouLikedResponse_Decision) GetDecisionType() DecisionType {
+	if x != nil {
+		return x.DecisionType
+	}
+	return DecisionType_DECISION_TYPE_UNSPECIFIED
+}
+
+func (x *ListYouLikedResponse_Decision) GetUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UnixTimestamp
+	}
+	return 0
+}
+
+func (x *ListYouLikedResponse_Decision) GetCreatedUnixTimestamp() uint64 {
+	if x != nil {
+		return x.CreatedUnixTimestamp
+	}
+	return 0
+}
+
+type PutDecisionsResponse_Result struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MutualLikes      bool                               `protobuf:"varint,1,opt,name=mutual_likes,json=mutualLikes,proto3" json:"mutual_likes,omitempty"`                 // True if both users like each other
+	Error            *PutDecisionsResponse_Result_Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`                                                 // Set if the decision was not recorded
+	MutualCheckError *PutDecisionsResponse_Result_Error `protobuf:"bytes,3,opt,name=mutual_check_error,json=mutualCheckError,proto3" json:"mutual_check_error,omitempty"` // Set if the decision was recorded, but mutual_likes couldn't be checked
+}
+
+func (x *PutDecisionsResponse_Result) Reset() {
+	*x = PutDecisionsResponse_Result{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutDecisionsResponse_Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutDecisionsResponse_Result) ProtoMessage() {}
+
+func (x *PutDecisionsResponse_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutDecisionsResponse_Result.ProtoReflect.Descriptor instead.
+func (*PutDecisionsResponse_Result) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{9, 0}
+}
+
+func (x *PutDecisionsResponse_Result) GetMutualLikes() bool {
+	if x != nil {
+		return x.MutualLikes
+	}
+	return false
+}
+
+func (x *PutDecisionsResponse_Result) GetError() *PutDecisionsResponse_Result_Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *PutDecisionsResponse_Result) GetMutualCheckError() *PutDecisionsResponse_Result_Error {
+	if x != nil {
+		return x.MutualCheckError
+	}
+	return nil
+}
+
+type PutDecisionsResponse_Result_Error struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code    int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // gRPC status code
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *PutDecisionsResponse_Result_Error) Reset() {
+	*x = PutDecisionsResponse_Result_Error{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutDecisionsResponse_Result_Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutDecisionsResponse_Result_Error) ProtoMessage() {}
+
+func (x *PutDecisionsResponse_Result_Error) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutDecisionsResponse_Result_Error.ProtoReflect.Descriptor instead.
+func (*PutDecisionsResponse_Result_Error) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{9, 0, 0}
+}
+
+func (x *PutDecisionsResponse_Result_Error) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *PutDecisionsResponse_Result_Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SwipeSessionResponse_Ack struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string                             `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Error     *PutDecisionsResponse_Result_Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Set if the decision was not recorded, or its match not checked
+}
+
+func (x *SwipeSessionResponse_Ack) Reset() {
+	*x = SwipeSessionResponse_Ack{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListLikedYouResponse_Liker) String() string {
+func (x *SwipeSessionResponse_Ack) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListLikedYouResponse_Liker) ProtoMessage() {}
+func (*SwipeSessionResponse_Ack) ProtoMessage() {}
 
-func (x *ListLikedYouResponse_Liker) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_explore_service_proto_msgTypes[6]
+func (x *SwipeSessionResponse_Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -356,19 +1972,361 @@ func (x *ListLikedYouResponse_Liker) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListLikedYouResponse_Liker.ProtoReflect.Descriptor instead.
-func (*ListLikedYouResponse_Liker) Descriptor() ([]byte, []int) {
-	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{1, 0}
+// Deprecated: Use SwipeSessionResponse_Ack.ProtoReflect.Descriptor instead.
+func (*SwipeSessionResponse_Ack) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{11, 0}
 }
 
-func (x *ListLikedYouResponse_Liker) GetActorId() string {
+func (x *SwipeSessionResponse_Ack) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SwipeSessionResponse_Ack) GetError() *PutDecisionsResponse_Result_Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type SwipeSessionResponse_Match struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // Decision that completed the match
+	PartnerId string `protobuf:"bytes,2,opt,name=partner_id,json=partnerId,proto3" json:"partner_id,omitempty"`
+}
+
+func (x *SwipeSessionResponse_Match) Reset() {
+	*x = SwipeSessionResponse_Match{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SwipeSessionResponse_Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwipeSessionResponse_Match) ProtoMessage() {}
+
+func (x *SwipeSessionResponse_Match) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwipeSessionResponse_Match.ProtoReflect.Descriptor instead.
+func (*SwipeSessionResponse_Match) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{11, 1}
+}
+
+func (x *SwipeSessionResponse_Match) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SwipeSessionResponse_Match) GetPartnerId() string {
+	if x != nil {
+		return x.PartnerId
+	}
+	return ""
+}
+
+type WatchLikesResponse_Like struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorId       string `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	UnixTimestamp uint64 `protobuf:"varint,2,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"`
+	SuperLike     bool   `protobuf:"varint,3,opt,name=super_like,json=superLike,proto3" json:"super_like,omitempty"`
+}
+
+func (x *WatchLikesResponse_Like) Reset() {
+	*x = WatchLikesResponse_Like{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLikesResponse_Like) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLikesResponse_Like) ProtoMessage() {}
+
+func (x *WatchLikesResponse_Like) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLikesResponse_Like.ProtoReflect.Descriptor instead.
+func (*WatchLikesResponse_Like) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{13, 0}
+}
+
+func (x *WatchLikesResponse_Like) GetActorId() string {
 	if x != nil {
 		return x.ActorId
 	}
 	return ""
 }
 
-func (x *ListLikedYouResponse_Liker) GetUnixTimestamp() uint64 {
+func (x *WatchLikesResponse_Like) GetUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UnixTimestamp
+	}
+	return 0
+}
+
+func (x *WatchLikesResponse_Like) GetSuperLike() bool {
+	if x != nil {
+		return x.SuperLike
+	}
+	return false
+}
+
+type WatchLikesResponse_Match struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PartnerId     string `protobuf:"bytes,1,opt,name=partner_id,json=partnerId,proto3" json:"partner_id,omitempty"`
+	UnixTimestamp uint64 `protobuf:"varint,2,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"`
+}
+
+func (x *WatchLikesResponse_Match) Reset() {
+	*x = WatchLikesResponse_Match{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLikesResponse_Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLikesResponse_Match) ProtoMessage() {}
+
+func (x *WatchLikesResponse_Match) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLikesResponse_Match.ProtoReflect.Descriptor instead.
+func (*WatchLikesResponse_Match) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{13, 1}
+}
+
+func (x *WatchLikesResponse_Match) GetPartnerId() string {
+	if x != nil {
+		return x.PartnerId
+	}
+	return ""
+}
+
+func (x *WatchLikesResponse_Match) GetUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UnixTimestamp
+	}
+	return 0
+}
+
+type WatchLikesResponse_Unmatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PartnerId     string `protobuf:"bytes,1,opt,name=partner_id,json=partnerId,proto3" json:"partner_id,omitempty"`
+	UnixTimestamp uint64 `protobuf:"varint,2,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"`
+}
+
+func (x *WatchLikesResponse_Unmatch) Reset() {
+	*x = WatchLikesResponse_Unmatch{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLikesResponse_Unmatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLikesResponse_Unmatch) ProtoMessage() {}
+
+func (x *WatchLikesResponse_Unmatch) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLikesResponse_Unmatch.ProtoReflect.Descriptor instead.
+func (*WatchLikesResponse_Unmatch) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{13, 2}
+}
+
+func (x *WatchLikesResponse_Unmatch) GetPartnerId() string {
+	if x != nil {
+		return x.PartnerId
+	}
+	return ""
+}
+
+func (x *WatchLikesResponse_Unmatch) GetUnixTimestamp() uint64 {
+	if x != nil {
+		return x.UnixTimestamp
+	}
+	return 0
+}
+
+type GetRelationshipResponse_Relationship struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OtherUserId        string       `protobuf:"bytes,1,opt,name=other_user_id,json=otherUserId,proto3" json:"other_user_id,omitempty"`
+	ViewerDecisionType DecisionType `protobuf:"varint,2,opt,name=viewer_decision_type,json=viewerDecisionType,proto3,enum=api.DecisionType" json:"viewer_decision_type,omitempty"` // Decision of the viewer on the other user, unspecified if none
+	OtherDecisionType  DecisionType `protobuf:"varint,3,opt,name=other_decision_type,json=otherDecisionType,proto3,enum=api.DecisionType" json:"other_decision_type,omitempty"`    // Decision of the other user on the viewer, unspecified if none or a block
+	Matched            bool         `protobuf:"varint,4,opt,name=matched,proto3" json:"matched,omitempty"`
+}
+
+func (x *GetRelationshipResponse_Relationship) Reset() {
+	*x = GetRelationshipResponse_Relationship{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRelationshipResponse_Relationship) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRelationshipResponse_Relationship) ProtoMessage() {}
+
+func (x *GetRelationshipResponse_Relationship) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRelationshipResponse_Relationship.ProtoReflect.Descriptor instead.
+func (*GetRelationshipResponse_Relationship) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{21, 0}
+}
+
+func (x *GetRelationshipResponse_Relationship) GetOtherUserId() string {
+	if x != nil {
+		return x.OtherUserId
+	}
+	return ""
+}
+
+func (x *GetRelationshipResponse_Relationship) GetViewerDecisionType() DecisionType {
+	if x != nil {
+		return x.ViewerDecisionType
+	}
+	return DecisionType_DECISION_TYPE_UNSPECIFIED
+}
+
+func (x *GetRelationshipResponse_Relationship) GetOtherDecisionType() DecisionType {
+	if x != nil {
+		return x.OtherDecisionType
+	}
+	return DecisionType_DECISION_TYPE_UNSPECIFIED
+}
+
+func (x *GetRelationshipResponse_Relationship) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+type ListMutualMatchesResponse_Match struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PartnerId     string `protobuf:"bytes,1,opt,name=partner_id,json=partnerId,proto3" json:"partner_id,omitempty"`
+	UnixTimestamp uint64 `protobuf:"varint,2,opt,name=unix_timestamp,json=unixTimestamp,proto3" json:"unix_timestamp,omitempty"` // Time of the like that completed the match
+}
+
+func (x *ListMutualMatchesResponse_Match) Reset() {
+	*x = ListMutualMatchesResponse_Match{}
+	mi := &file_internal_api_explore_service_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMutualMatchesResponse_Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMutualMatchesResponse_Match) ProtoMessage() {}
+
+func (x *ListMutualMatchesResponse_Match) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_explore_service_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMutualMatchesResponse_Match.ProtoReflect.Descriptor instead.
+func (*ListMutualMatchesResponse_Match) Descriptor() ([]byte, []int) {
+	return file_internal_api_explore_service_proto_rawDescGZIP(), []int{23, 0}
+}
+
+func (x *ListMutualMatchesResponse_Match) GetPartnerId() string {
+	if x != nil {
+		return x.PartnerId
+	}
+	return ""
+}
+
+func (x *ListMutualMatchesResponse_Match) GetUnixTimestamp() uint64 {
 	if x != nil {
 		return x.UnixTimestamp
 	}
@@ -380,70 +2338,405 @@ var File_internal_api_explore_service_proto protoreflect.FileDescriptor
 var file_internal_api_explore_service_proto_rawDesc = []byte{
 	0x0a, 0x22, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x65,
 	0x78, 0x70, 0x6c, 0x6f, 0x72, 0x65, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x61, 0x70, 0x69, 0x22, 0x86, 0x01, 0x0a, 0x13, 0x4c, 0x69,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x61, 0x70, 0x69, 0x22, 0x85, 0x04, 0x0a, 0x13, 0x4c, 0x69,
 	0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
 	0x74, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75,
 	0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65,
 	0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2e, 0x0a,
 	0x10, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65,
 	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0f, 0x70, 0x61, 0x67, 0x69, 0x6e,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x13, 0x0a,
-	0x11, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b,
-	0x65, 0x6e, 0x22, 0xed, 0x01, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64,
-	0x59, 0x6f, 0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x37, 0x0a, 0x06, 0x6c,
-	0x69, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x6b, 0x65, 0x72, 0x52, 0x06, 0x6c, 0x69,
-	0x6b, 0x65, 0x72, 0x73, 0x12, 0x37, 0x0a, 0x15, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67,
-	0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x13, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x69, 0x6e,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x1a, 0x49, 0x0a,
-	0x05, 0x4c, 0x69, 0x6b, 0x65, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f,
-	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x49,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x4b, 0x0a,
+	0x14, 0x61, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x41, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x13, 0x61, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64,
+	0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x3d, 0x0a, 0x10, 0x6e, 0x65,
+	0x77, 0x5f, 0x6c, 0x69, 0x6b, 0x65, 0x73, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x65, 0x77, 0x4c, 0x69,
+	0x6b, 0x65, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x0e, 0x6e, 0x65, 0x77, 0x4c, 0x69,
+	0x6b, 0x65, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x2d, 0x0a, 0x0a, 0x73, 0x6f, 0x72,
+	0x74, 0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x53, 0x6f, 0x72, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x09, 0x73,
+	0x6f, 0x72, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x30, 0x0a, 0x14, 0x73, 0x69, 0x6e, 0x63,
+	0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x55, 0x6e, 0x69,
+	0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x30, 0x0a, 0x14, 0x75, 0x6e,
+	0x74, 0x69, 0x6c, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x55,
+	0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x20, 0x0a, 0x09,
+	0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x48,
+	0x01, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2e,
+	0x0a, 0x13, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69, 0x6e, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x13,
+	0x0a, 0x11, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f,
+	0x6b, 0x65, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a,
+	0x65, 0x22, 0x92, 0x03, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59,
+	0x6f, 0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x37, 0x0a, 0x06, 0x6c, 0x69,
+	0x6b, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x6b, 0x65, 0x72, 0x52, 0x06, 0x6c, 0x69, 0x6b,
+	0x65, 0x72, 0x73, 0x12, 0x37, 0x0a, 0x15, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x13, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x69, 0x6e, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x24, 0x0a, 0x0b,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x01, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x88,
+	0x01, 0x01, 0x1a, 0xb7, 0x01, 0x0a, 0x05, 0x4c, 0x69, 0x6b, 0x65, 0x72, 0x12, 0x19, 0x0a, 0x08,
+	0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x61, 0x63, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0d, 0x75, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x34,
+	0x0a, 0x16, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x14,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x55, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x12, 0x36, 0x0a, 0x0d, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c,
+	0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x42, 0x18, 0x0a, 0x16,
+	0x5f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xbf, 0x02, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x59,
+	0x6f, 0x75, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22,
+	0x0a, 0x0d, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72,
+	0x49, 0x64, 0x12, 0x2e, 0x0a, 0x10, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0f,
+	0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88,
+	0x01, 0x01, 0x12, 0x38, 0x0a, 0x0e, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0d, 0x64,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0b, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4f, 0x6e, 0x6c, 0x79, 0x12,
+	0x30, 0x0a, 0x14, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x55, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x12, 0x30, 0x0a, 0x14, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x12, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x55, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xf0, 0x02, 0x0a, 0x14, 0x4c, 0x69, 0x73,
+	0x74, 0x59, 0x6f, 0x75, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x40, 0x0a, 0x09, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x59,
+	0x6f, 0x75, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
+	0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x37, 0x0a, 0x15, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x13, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x69, 0x6e, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x1a, 0xc2, 0x01, 0x0a,
+	0x08, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x63,
+	0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x36, 0x0a, 0x0d,
+	0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x75, 0x6e,
+	0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x34, 0x0a, 0x16, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x14, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x55, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x42, 0x18, 0x0a, 0x16, 0x5f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x42, 0x0a, 0x14, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74,
+	0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22,
+	0x2d, 0x0a, 0x15, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xc5,
+	0x01, 0x0a, 0x12, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0d, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x75,
+	0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63,
+	0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63,
+	0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55,
+	0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x69, 0x6b, 0x65, 0x64, 0x5f, 0x72,
+	0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e,
+	0x6c, 0x69, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x36,
+	0x0a, 0x0d, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x22, 0x38, 0x0a, 0x13, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a,
+	0x0c, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6c, 0x69, 0x6b, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0b, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4c, 0x69, 0x6b, 0x65, 0x73,
+	0x22, 0x4c, 0x0a, 0x13, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x35, 0x0a, 0x09, 0x64, 0x65, 0x63, 0x69, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x52, 0x09, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xcb,
+	0x02, 0x0a, 0x14, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3a, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50,
+	0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x1a, 0xf6, 0x01, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x21,
+	0x0a, 0x0c, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6c, 0x69, 0x6b, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4c, 0x69, 0x6b, 0x65,
+	0x73, 0x12, 0x3c, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x26, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x54, 0x0a, 0x12, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x5f,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x2e, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x52, 0x10, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x45, 0x72, 0x72, 0x6f, 0x72, 0x1a, 0x35, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x69, 0x0a, 0x13,
+	0x53, 0x77, 0x69, 0x70, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x49, 0x64, 0x12, 0x33, 0x0a, 0x08, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65,
+	0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x64,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xb6, 0x02, 0x0a, 0x14, 0x53, 0x77, 0x69, 0x70,
+	0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x31, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x53, 0x77, 0x69, 0x70, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x41, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x03,
+	0x61, 0x63, 0x6b, 0x12, 0x37, 0x0a, 0x05, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x77, 0x69, 0x70, 0x65, 0x53, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x48, 0x00, 0x52, 0x05, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x1a, 0x62, 0x0a, 0x03,
+	0x41, 0x63, 0x6b, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x49, 0x64, 0x12, 0x3c, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x26, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x1a, 0x45, 0x0a, 0x05, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x74,
+	0x6e, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61,
+	0x72, 0x74, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x22, 0x67, 0x0a, 0x11, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65,
+	0x6e, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49,
+	0x64, 0x12, 0x1b, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x88, 0x01, 0x01, 0x42, 0x09,
+	0x0a, 0x07, 0x5f, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x22, 0xe6, 0x03, 0x0a, 0x12, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x32, 0x0a, 0x04, 0x6c, 0x69, 0x6b, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
+	0x4c, 0x69, 0x6b, 0x65, 0x48, 0x00, 0x52, 0x04, 0x6c, 0x69, 0x6b, 0x65, 0x12, 0x35, 0x0a, 0x05,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x48, 0x00, 0x52, 0x05, 0x6d, 0x61,
+	0x74, 0x63, 0x68, 0x12, 0x3b, 0x0a, 0x07, 0x75, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68,
+	0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x55, 0x6e,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x48, 0x00, 0x52, 0x07, 0x75, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68,
+	0x1a, 0x67, 0x0a, 0x04, 0x4c, 0x69, 0x6b, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x63, 0x74, 0x6f,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x63, 0x74, 0x6f,
+	0x72, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x75, 0x6e, 0x69,
+	0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x75,
+	0x70, 0x65, 0x72, 0x5f, 0x6c, 0x69, 0x6b, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09,
+	0x73, 0x75, 0x70, 0x65, 0x72, 0x4c, 0x69, 0x6b, 0x65, 0x1a, 0x4d, 0x0a, 0x05, 0x4d, 0x61, 0x74,
+	0x63, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x74, 0x6e, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x72, 0x74, 0x6e, 0x65, 0x72, 0x49,
 	0x64, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
 	0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x75, 0x6e, 0x69, 0x78, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x42, 0x18, 0x0a, 0x16, 0x5f, 0x6e, 0x65, 0x78,
-	0x74, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b,
-	0x65, 0x6e, 0x22, 0x42, 0x0a, 0x14, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64,
-	0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65,
-	0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74,
-	0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x2d, 0x0a, 0x15, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c,
-	0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
-	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x8d, 0x01, 0x0a, 0x12, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63,
-	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0d,
-	0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64,
-	0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75, 0x73,
-	0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x63,
-	0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x27, 0x0a, 0x0f,
-	0x6c, 0x69, 0x6b, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x6c, 0x69, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x63, 0x69,
-	0x70, 0x69, 0x65, 0x6e, 0x74, 0x22, 0x38, 0x0a, 0x13, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69,
-	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x0c,
-	0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6c, 0x69, 0x6b, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x0b, 0x6d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x32,
-	0xa7, 0x02, 0x0a, 0x0e, 0x45, 0x78, 0x70, 0x6c, 0x6f, 0x72, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x43, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59,
-	0x6f, 0x75, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b,
-	0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x61,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x1a, 0x4f, 0x0a, 0x07, 0x55, 0x6e, 0x6d, 0x61,
+	0x74, 0x63, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x74, 0x6e, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x72, 0x74, 0x6e, 0x65, 0x72,
+	0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x75, 0x6e, 0x69, 0x78,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x22, 0x65, 0x0a, 0x13, 0x55, 0x6e, 0x64, 0x6f, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0d, 0x61, 0x63, 0x74,
+	0x6f, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2a, 0x0a,
+	0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69,
+	0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x4e, 0x0a, 0x14, 0x55, 0x6e, 0x64,
+	0x6f, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x36, 0x0a, 0x0d, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x64, 0x65, 0x63,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x22, 0x67, 0x0a, 0x15, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x22, 0x0a, 0x0d, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72,
+	0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69,
+	0x65, 0x6e, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72,
+	0x49, 0x64, 0x22, 0x18, 0x0a, 0x16, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x51, 0x0a, 0x0e,
+	0x55, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17,
+	0x0a, 0x07, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x0f, 0x70, 0x61, 0x72, 0x74, 0x6e,
+	0x65, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x70, 0x61, 0x72, 0x74, 0x6e, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22,
+	0x11, 0x0a, 0x0f, 0x55, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x64, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x68, 0x69, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x24, 0x0a, 0x0e,
+	0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72,
+	0x49, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x6f, 0x74, 0x68, 0x65,
+	0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x73, 0x22, 0xc1, 0x02, 0x0a, 0x17, 0x47, 0x65, 0x74,
+	0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0d, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x68, 0x69, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69,
+	0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x52, 0x0d, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x68, 0x69, 0x70, 0x73, 0x1a, 0xd4, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x12, 0x22, 0x0a, 0x0d, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x5f,
+	0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6f,
+	0x74, 0x68, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x43, 0x0a, 0x14, 0x76, 0x69,
+	0x65, 0x77, 0x65, 0x72, 0x5f, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x12, 0x76, 0x69, 0x65,
+	0x77, 0x65, 0x72, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x41, 0x0a, 0x13, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x5f, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52,
+	0x11, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x22, 0x78, 0x0a, 0x18,
+	0x4c, 0x69, 0x73, 0x74, 0x4d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49,
+	0x64, 0x12, 0x2e, 0x0a, 0x10, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0f, 0x70,
+	0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01,
+	0x01, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xfd, 0x01, 0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x4d,
+	0x75, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x4d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74,
+	0x63, 0x68, 0x65, 0x73, 0x12, 0x37, 0x0a, 0x15, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67,
+	0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x13, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x69, 0x6e,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x1a, 0x4d, 0x0a,
+	0x05, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x74, 0x6e, 0x65,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x72, 0x74,
+	0x6e, 0x65, 0x72, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x75,
+	0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x42, 0x18, 0x0a, 0x16,
+	0x5f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x6b, 0x0a, 0x17, 0x41, 0x63, 0x6b, 0x6e, 0x6f, 0x77,
+	0x6c, 0x65, 0x64, 0x67, 0x65, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75,
+	0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65,
+	0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x24, 0x0a,
+	0x0e, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72,
+	0x49, 0x64, 0x73, 0x22, 0x1a, 0x0a, 0x18, 0x41, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64,
+	0x67, 0x65, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a,
+	0x81, 0x01, 0x0a, 0x13, 0x41, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x24, 0x0a, 0x20, 0x41, 0x43, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4c, 0x45, 0x44, 0x47, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x21, 0x0a,
+	0x1d, 0x41, 0x43, 0x4b, 0x4e, 0x4f, 0x57, 0x4c, 0x45, 0x44, 0x47, 0x45, 0x4d, 0x45, 0x4e, 0x54,
+	0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x43, 0x49, 0x54, 0x10, 0x01,
+	0x12, 0x21, 0x0a, 0x1d, 0x41, 0x43, 0x4b, 0x4e, 0x4f, 0x57, 0x4c, 0x45, 0x44, 0x47, 0x45, 0x4d,
+	0x45, 0x4e, 0x54, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x45, 0x58, 0x50, 0x4c, 0x49, 0x43, 0x49,
+	0x54, 0x10, 0x02, 0x2a, 0xa4, 0x01, 0x0a, 0x0e, 0x4e, 0x65, 0x77, 0x4c, 0x69, 0x6b, 0x65, 0x73,
+	0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x20, 0x0a, 0x1c, 0x4e, 0x45, 0x57, 0x5f, 0x4c, 0x49,
+	0x4b, 0x45, 0x53, 0x5f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x4e, 0x45, 0x57, 0x5f,
+	0x4c, 0x49, 0x4b, 0x45, 0x53, 0x5f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x53,
+	0x45, 0x45, 0x4e, 0x10, 0x01, 0x12, 0x23, 0x0a, 0x1f, 0x4e, 0x45, 0x57, 0x5f, 0x4c, 0x49, 0x4b,
+	0x45, 0x53, 0x5f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x52, 0x45, 0x43, 0x49,
+	0x50, 0x52, 0x4f, 0x43, 0x41, 0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x2e, 0x0a, 0x2a, 0x4e, 0x45,
+	0x57, 0x5f, 0x4c, 0x49, 0x4b, 0x45, 0x53, 0x5f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x55,
+	0x4e, 0x53, 0x45, 0x45, 0x4e, 0x5f, 0x41, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x52, 0x45, 0x43, 0x49,
+	0x50, 0x52, 0x4f, 0x43, 0x41, 0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x6e, 0x0a, 0x09, 0x53, 0x6f,
+	0x72, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x4f, 0x52, 0x54, 0x5f,
+	0x4f, 0x52, 0x44, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x4f, 0x52, 0x44, 0x45,
+	0x52, 0x5f, 0x41, 0x43, 0x54, 0x4f, 0x52, 0x5f, 0x49, 0x44, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11,
+	0x53, 0x4f, 0x52, 0x54, 0x5f, 0x4f, 0x52, 0x44, 0x45, 0x52, 0x5f, 0x4e, 0x45, 0x57, 0x45, 0x53,
+	0x54, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x4f, 0x52, 0x44, 0x45,
+	0x52, 0x5f, 0x4f, 0x4c, 0x44, 0x45, 0x53, 0x54, 0x10, 0x03, 0x2a, 0x94, 0x01, 0x0a, 0x0c, 0x44,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x19, 0x44,
+	0x45, 0x43, 0x49, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x44, 0x45,
+	0x43, 0x49, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x41, 0x53, 0x53,
+	0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x44, 0x45, 0x43, 0x49, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x4c, 0x49, 0x4b, 0x45, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x44, 0x45,
+	0x43, 0x49, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x55, 0x50, 0x45,
+	0x52, 0x5f, 0x4c, 0x49, 0x4b, 0x45, 0x10, 0x03, 0x12, 0x17, 0x0a, 0x13, 0x44, 0x45, 0x43, 0x49,
+	0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x10,
+	0x04, 0x32, 0xf4, 0x07, 0x0a, 0x0e, 0x45, 0x78, 0x70, 0x6c, 0x6f, 0x72, 0x65, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65,
+	0x64, 0x59, 0x6f, 0x75, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c,
+	0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f,
+	0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x0f, 0x4c, 0x69, 0x73,
+	0x74, 0x4e, 0x65, 0x77, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x12, 0x18, 0x2e, 0x61,
 	0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x4e,
-	0x65, 0x77, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69,
-	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4c,
-	0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x46, 0x0a, 0x0d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75,
-	0x12, 0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65,
-	0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x0b, 0x50, 0x75, 0x74, 0x44, 0x65,
-	0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74,
-	0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
-	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x2f, 0x2e,
-	0x2e, 0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x43, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x59, 0x6f, 0x75, 0x4c, 0x69, 0x6b, 0x65,
+	0x64, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x59, 0x6f, 0x75, 0x4c,
+	0x69, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x59, 0x6f, 0x75, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x0d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c,
+	0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x12, 0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x4c, 0x69, 0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x69,
+	0x6b, 0x65, 0x64, 0x59, 0x6f, 0x75, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40,
+	0x0a, 0x0b, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74,
+	0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x52, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x1d, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x4d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4d,
+	0x75, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x10, 0x41, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65,
+	0x64, 0x67, 0x65, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41,
+	0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x63, 0x6b,
+	0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0c, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x75, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x0c, 0x53, 0x77,
+	0x69, 0x70, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x53, 0x77, 0x69, 0x70, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x77, 0x69, 0x70, 0x65,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28,
+	0x01, 0x30, 0x01, 0x12, 0x3f, 0x0a, 0x0a, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x69, 0x6b, 0x65,
+	0x73, 0x12, 0x16, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x69, 0x6b,
+	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x69, 0x6b, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x30, 0x01, 0x12, 0x43, 0x0a, 0x0c, 0x55, 0x6e, 0x64, 0x6f, 0x44, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x55, 0x6e, 0x64, 0x6f, 0x44,
+	0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x55, 0x6e, 0x64, 0x6f, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x0e, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x07, 0x55, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x12,
+	0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x55, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x55, 0x6e, 0x6d, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0f, 0x47, 0x65,
+	0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x12, 0x1b, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x68, 0x69, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x2f, 0x2e, 0x2e,
+	0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -458,31 +2751,110 @@ func file_internal_api_explore_service_proto_rawDescGZIP() []byte {
 	return file_internal_api_explore_service_proto_rawDescData
 }
 
-var file_internal_api_explore_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_internal_api_explore_service_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_internal_api_explore_service_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_internal_api_explore_service_proto_goTypes = []any{
-	(*ListLikedYouRequest)(nil),        // 0: api.ListLikedYouRequest
-	(*ListLikedYouResponse)(nil),       // 1: api.ListLikedYouResponse
-	(*CountLikedYouRequest)(nil),       // 2: api.CountLikedYouRequest
-	(*CountLikedYouResponse)(nil),      // 3: api.CountLikedYouResponse
-	(*PutDecisionRequest)(nil),         // 4: api.PutDecisionRequest
-	(*PutDecisionResponse)(nil),        // 5: api.PutDecisionResponse
-	(*ListLikedYouResponse_Liker)(nil), // 6: api.ListLikedYouResponse.Liker
+	(AcknowledgementMode)(0),                     // 0: api.AcknowledgementMode
+	(NewLikesFilter)(0),                          // 1: api.NewLikesFilter
+	(SortOrder)(0),                               // 2: api.SortOrder
+	(DecisionType)(0),                            // 3: api.DecisionType
+	(*ListLikedYouRequest)(nil),                  // 4: api.ListLikedYouRequest
+	(*ListLikedYouResponse)(nil),                 // 5: api.ListLikedYouResponse
+	(*ListYouLikedRequest)(nil),                  // 6: api.ListYouLikedRequest
+	(*ListYouLikedResponse)(nil),                 // 7: api.ListYouLikedResponse
+	(*CountLikedYouRequest)(nil),                 // 8: api.CountLikedYouRequest
+	(*CountLikedYouResponse)(nil),                // 9: api.CountLikedYouResponse
+	(*PutDecisionRequest)(nil),                   // 10: api.PutDecisionRequest
+	(*PutDecisionResponse)(nil),                  // 11: api.PutDecisionResponse
+	(*PutDecisionsRequest)(nil),                  // 12: api.PutDecisionsRequest
+	(*PutDecisionsResponse)(nil),                 // 13: api.PutDecisionsResponse
+	(*SwipeSessionRequest)(nil),                  // 14: api.SwipeSessionRequest
+	(*SwipeSessionResponse)(nil),                 // 15: api.SwipeSessionResponse
+	(*WatchLikesRequest)(nil),                    // 16: api.WatchLikesRequest
+	(*WatchLikesResponse)(nil),                   // 17: api.WatchLikesResponse
+	(*UndoDecisionRequest)(nil),                  // 18: api.UndoDecisionRequest
+	(*UndoDecisionResponse)(nil),                 // 19: api.UndoDecisionResponse
+	(*DeleteDecisionRequest)(nil),                // 20: api.DeleteDecisionRequest
+	(*DeleteDecisionResponse)(nil),               // 21: api.DeleteDecisionResponse
+	(*UnmatchRequest)(nil),                       // 22: api.UnmatchRequest
+	(*UnmatchResponse)(nil),                      // 23: api.UnmatchResponse
+	(*GetRelationshipRequest)(nil),               // 24: api.GetRelationshipRequest
+	(*GetRelationshipResponse)(nil),              // 25: api.GetRelationshipResponse
+	(*ListMutualMatchesRequest)(nil),             // 26: api.ListMutualMatchesRequest
+	(*ListMutualMatchesResponse)(nil),            // 27: api.ListMutualMatchesResponse
+	(*AcknowledgeLikesRequest)(nil),              // 28: api.AcknowledgeLikesRequest
+	(*AcknowledgeLikesResponse)(nil),             // 29: api.AcknowledgeLikesResponse
+	(*ListLikedYouResponse_Liker)(nil),           // 30: api.ListLikedYouResponse.Liker
+	(*ListYouLikedResponse_Decision)(nil),        // 31: api.ListYouLikedResponse.Decision
+	(*PutDecisionsResponse_Result)(nil),          // 32: api.PutDecisionsResponse.Result
+	(*PutDecisionsResponse_Result_Error)(nil),    // 33: api.PutDecisionsResponse.Result.Error
+	(*SwipeSessionResponse_Ack)(nil),             // 34: api.SwipeSessionResponse.Ack
+	(*SwipeSessionResponse_Match)(nil),           // 35: api.SwipeSessionResponse.Match
+	(*WatchLikesResponse_Like)(nil),              // 36: api.WatchLikesResponse.Like
+	(*WatchLikesResponse_Match)(nil),             // 37: api.WatchLikesResponse.Match
+	(*WatchLikesResponse_Unmatch)(nil),           // 38: api.WatchLikesResponse.Unmatch
+	(*GetRelationshipResponse_Relationship)(nil), // 39: api.GetRelationshipResponse.Relationship
+	(*ListMutualMatchesResponse_Match)(nil),      // 40: api.ListMutualMatchesResponse.Match
 }
 var file_internal_api_explore_service_proto_depIdxs = []int32{
-	6, // 0: api.ListLikedYouResponse.likers:type_name -> api.ListLikedYouResponse.Liker
-	0, // 1: api.ExploreService.ListLikedYou:input_type -> api.ListLikedYouRequest
-	0, // 2: api.ExploreService.ListNewLikedYou:input_type -> api.ListLikedYouRequest
-	2, // 3: api.ExploreService.CountLikedYou:input_type -> api.CountLikedYouRequest
-	4, // 4: api.ExploreService.PutDecision:input_type -> api.PutDecisionRequest
-	1, // 5: api.ExploreService.ListLikedYou:output_type -> api.ListLikedYouResponse
-	1, // 6: api.ExploreService.ListNewLikedYou:output_type -> api.ListLikedYouResponse
-	3, // 7: api.ExploreService.CountLikedYou:output_type -> api.CountLikedYouResponse
-	5, // 8: api.ExploreService.PutDecision:output_type -> api.PutDecisionResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: api.ListLikedYouRequest.acknowledgement_mode:type_name -> api.AcknowledgementMode
+	1,  // 1: api.ListLikedYouRequest.new_likes_filter:type_name -> api.NewLikesFilter
+	2,  // 2: api.ListLikedYouRequest.sort_order:type_name -> api.SortOrder
+	30, // 3: api.ListLikedYouResponse.likers:type_name -> api.ListLikedYouResponse.Liker
+	3,  // 4: api.ListYouLikedRequest.decision_types:type_name -> api.DecisionType
+	31, // 5: api.ListYouLikedResponse.decisions:type_name -> api.ListYouLikedResponse.Decision
+	3,  // 6: api.PutDecisionRequest.decision_type:type_name -> api.DecisionType
+	10, // 7: api.PutDecisionsRequest.decisions:type_name -> api.PutDecisionRequest
+	32, // 8: api.PutDecisionsResponse.results:type_name -> api.PutDecisionsResponse.Result
+	10, // 9: api.SwipeSessionRequest.decision:type_name -> api.PutDecisionRequest
+	34, // 10: api.SwipeSessionResponse.ack:type_name -> api.SwipeSessionResponse.Ack
+	35, // 11: api.SwipeSessionResponse.match:type_name -> api.SwipeSessionResponse.Match
+	36, // 12: api.WatchLikesResponse.like:type_name -> api.WatchLikesResponse.Like
+	37, // 13: api.WatchLikesResponse.match:type_name -> api.WatchLikesResponse.Match
+	38, // 14: api.WatchLikesResponse.unmatch:type_name -> api.WatchLikesResponse.Unmatch
+	3,  // 15: api.UndoDecisionResponse.decision_type:type_name -> api.DecisionType
+	39, // 16: api.GetRelationshipResponse.relationships:type_name -> api.GetRelationshipResponse.Relationship
+	40, // 17: api.ListMutualMatchesResponse.matches:type_name -> api.ListMutualMatchesResponse.Match
+	3,  // 18: api.ListLikedYouResponse.Liker.decision_type:type_name -> api.DecisionType
+	3,  // 19: api.ListYouLikedResponse.Decision.decision_type:type_name -> api.DecisionType
+	33, // 20: api.PutDecisionsResponse.Result.error:type_name -> api.PutDecisionsResponse.Result.Error
+	33, // 21: api.PutDecisionsResponse.Result.mutual_check_error:type_name -> api.PutDecisionsResponse.Result.Error
+	33, // 22: api.SwipeSessionResponse.Ack.error:type_name -> api.PutDecisionsResponse.Result.Error
+	3,  // 23: api.GetRelationshipResponse.Relationship.viewer_decision_type:type_name -> api.DecisionType
+	3,  // 24: api.GetRelationshipResponse.Relationship.other_decision_type:type_name -> api.DecisionType
+	4,  // 25: api.ExploreService.ListLikedYou:input_type -> api.ListLikedYouRequest
+	4,  // 26: api.ExploreService.ListNewLikedYou:input_type -> api.ListLikedYouRequest
+	6,  // 27: api.ExploreService.ListYouLiked:input_type -> api.ListYouLikedRequest
+	8,  // 28: api.ExploreService.CountLikedYou:input_type -> api.CountLikedYouRequest
+	10, // 29: api.ExploreService.PutDecision:input_type -> api.PutDecisionRequest
+	26, // 30: api.ExploreService.ListMutualMatches:input_type -> api.ListMutualMatchesRequest
+	28, // 31: api.ExploreService.AcknowledgeLikes:input_type -> api.AcknowledgeLikesRequest
+	12, // 32: api.ExploreService.PutDecisions:input_type -> api.PutDecisionsRequest
+	14, // 33: api.ExploreService.SwipeSession:input_type -> api.SwipeSessionRequest
+	16, // 34: api.ExploreService.WatchLikes:input_type -> api.WatchLikesRequest
+	18, // 35: api.ExploreService.UndoDecision:input_type -> api.UndoDecisionRequest
+	20, // 36: api.ExploreService.DeleteDecision:input_type -> api.DeleteDecisionRequest
+	22, // 37: api.ExploreService.Unmatch:input_type -> api.UnmatchRequest
+	24, // 38: api.ExploreService.GetRelationship:input_type -> api.GetRelationshipRequest
+	5,  // 39: api.ExploreService.ListLikedYou:output_type -> api.ListLikedYouResponse
+	5,  // 40: api.ExploreService.ListNewLikedYou:output_type -> api.ListLikedYouResponse
+	7,  // 41: api.ExploreService.ListYouLiked:output_type -> api.ListYouLikedResponse
+	9,  // 42: api.ExploreService.CountLikedYou:output_type -> api.CountLikedYouResponse
+	11, // 43: api.ExploreService.PutDecision:output_type -> api.PutDecisionResponse
+	27, // 44: api.ExploreService.ListMutualMatches:output_type -> api.ListMutualMatchesResponse
+	29, // 45: api.ExploreService.AcknowledgeLikes:output_type -> api.AcknowledgeLikesResponse
+	13, // 46: api.ExploreService.PutDecisions:output_type -> api.PutDecisionsResponse
+	15, // 47: api.ExploreService.SwipeSession:output_type -> api.SwipeSessionResponse
+	17, // 48: api.ExploreService.WatchLikes:output_type -> api.WatchLikesResponse
+	19, // 49: api.ExploreService.UndoDecision:output_type -> api.UndoDecisionResponse
+	21, // 50: api.ExploreService.DeleteDecision:output_type -> api.DeleteDecisionResponse
+	23, // 51: api.ExploreService.Unmatch:output_type -> api.UnmatchResponse
+	25, // 52: api.ExploreService.GetRelationship:output_type -> api.GetRelationshipResponse
+	39, // [39:53] is the sub-list for method output_type
+	25, // [25:39] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_internal_api_explore_service_proto_init() }
@@ -492,18 +2864,33 @@ func file_internal_api_explore_service_proto_init() {
 	}
 	file_internal_api_explore_service_proto_msgTypes[0].OneofWrappers = []any{}
 	file_internal_api_explore_service_proto_msgTypes[1].OneofWrappers = []any{}
+	file_internal_api_explore_service_proto_msgTypes[2].OneofWrappers = []any{}
+	file_internal_api_explore_service_proto_msgTypes[3].OneofWrappers = []any{}
+	file_internal_api_explore_service_proto_msgTypes[11].OneofWrappers = []any{
+		(*SwipeSessionResponse_Ack_)(nil),
+		(*SwipeSessionResponse_Match_)(nil),
+	}
+	file_internal_api_explore_service_proto_msgTypes[12].OneofWrappers = []any{}
+	file_internal_api_explore_service_proto_msgTypes[13].OneofWrappers = []any{
+		(*WatchLikesResponse_Like_)(nil),
+		(*WatchLikesResponse_Match_)(nil),
+		(*WatchLikesResponse_Unmatch_)(nil),
+	}
+	file_internal_api_explore_service_proto_msgTypes[22].OneofWrappers = []any{}
+	file_internal_api_explore_service_proto_msgTypes[23].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_internal_api_explore_service_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      4,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_internal_api_explore_service_proto_goTypes,
 		DependencyIndexes: file_internal_api_explore_service_proto_depIdxs,
+		EnumInfos:         file_internal_api_explore_service_proto_enumTypes,
 		MessageInfos:      file_internal_api_explore_service_proto_msgTypes,
 	}.Build()
 	File_internal_api_explore_service_proto = out.File
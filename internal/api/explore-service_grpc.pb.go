@@ -19,10 +19,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ExploreService_ListLikedYou_FullMethodName    = "/api.ExploreService/ListLikedYou"
-	ExploreService_ListNewLikedYou_FullMethodName = "/api.ExploreService/ListNewLikedYou"
-	ExploreService_CountLikedYou_FullMethodName   = "/api.ExploreService/CountLikedYou"
-	ExploreService_PutDecision_FullMethodName     = "/api.ExploreService/PutDecision"
+	ExploreService_ListLikedYou_FullMethodName      = "/api.ExploreService/ListLikedYou"
+	ExploreService_ListNewLikedYou_FullMethodName   = "/api.ExploreService/ListNewLikedYou"
+	ExploreService_ListYouLiked_FullMethodName      = "/api.ExploreService/ListYouLiked"
+	ExploreService_CountLikedYou_FullMethodName     = "/api.ExploreService/CountLikedYou"
+	ExploreService_PutDecision_FullMethodName       = "/api.ExploreService/PutDecision"
+	ExploreService_ListMutualMatches_FullMethodName = "/api.ExploreService/ListMutualMatches"
+	ExploreService_AcknowledgeLikes_FullMethodName  = "/api.ExploreService/AcknowledgeLikes"
+	ExploreService_PutDecisions_FullMethodName      = "/api.ExploreService/PutDecisions"
+	ExploreService_SwipeSession_FullMethodName      = "/api.ExploreService/SwipeSession"
+	ExploreService_WatchLikes_FullMethodName        = "/api.ExploreService/WatchLikes"
+	ExploreService_UndoDecision_FullMethodName      = "/api.ExploreService/UndoDecision"
+	ExploreService_DeleteDecision_FullMethodName    = "/api.ExploreService/DeleteDecision"
+	ExploreService_Unmatch_FullMethodName           = "/api.ExploreService/Unmatch"
+	ExploreService_GetRelationship_FullMethodName   = "/api.ExploreService/GetRelationship"
 )
 
 // ExploreServiceClient is the client API for ExploreService service.
@@ -31,8 +41,18 @@ const (
 type ExploreServiceClient interface {
 	ListLikedYou(ctx context.Context, in *ListLikedYouRequest, opts ...grpc.CallOption) (*ListLikedYouResponse, error)
 	ListNewLikedYou(ctx context.Context, in *ListLikedYouRequest, opts ...grpc.CallOption) (*ListLikedYouResponse, error)
+	ListYouLiked(ctx context.Context, in *ListYouLikedRequest, opts ...grpc.CallOption) (*ListYouLikedResponse, error)
 	CountLikedYou(ctx context.Context, in *CountLikedYouRequest, opts ...grpc.CallOption) (*CountLikedYouResponse, error)
 	PutDecision(ctx context.Context, in *PutDecisionRequest, opts ...grpc.CallOption) (*PutDecisionResponse, error)
+	ListMutualMatches(ctx context.Context, in *ListMutualMatchesRequest, opts ...grpc.CallOption) (*ListMutualMatchesResponse, error)
+	AcknowledgeLikes(ctx context.Context, in *AcknowledgeLikesRequest, opts ...grpc.CallOption) (*AcknowledgeLikesResponse, error)
+	PutDecisions(ctx context.Context, in *PutDecisionsRequest, opts ...grpc.CallOption) (*PutDecisionsResponse, error)
+	SwipeSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SwipeSessionRequest, SwipeSessionResponse], error)
+	WatchLikes(ctx context.Context, in *WatchLikesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchLikesResponse], error)
+	UndoDecision(ctx context.Context, in *UndoDecisionRequest, opts ...grpc.CallOption) (*UndoDecisionResponse, error)
+	DeleteDecision(ctx context.Context, in *DeleteDecisionRequest, opts ...grpc.CallOption) (*DeleteDecisionResponse, error)
+	Unmatch(ctx context.Context, in *UnmatchRequest, opts ...grpc.CallOption) (*UnmatchResponse, error)
+	GetRelationship(ctx context.Context, in *GetRelationshipRequest, opts ...grpc.CallOption) (*GetRelationshipResponse, error)
 }
 
 type exploreServiceClient struct {
@@ -63,6 +83,16 @@ func (c *exploreServiceClient) ListNewLikedYou(ctx context.Context, in *ListLike
 	return out, nil
 }
 
+func (c *exploreServiceClient) ListYouLiked(ctx context.Context, in *ListYouLikedRequest, opts ...grpc.CallOption) (*ListYouLikedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListYouLikedResponse)
+	err := c.cc.Invoke(ctx, ExploreService_ListYouLiked_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *exploreServiceClient) CountLikedYou(ctx context.Context, in *CountLikedYouRequest, opts ...grpc.CallOption) (*CountLikedYouResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CountLikedYouResponse)
@@ -83,14 +113,126 @@ func (c *exploreServiceClient) PutDecision(ctx context.Context, in *PutDecisionR
 	return out, nil
 }
 
+func (c *exploreServiceClient) ListMutualMatches(ctx context.Context, in *ListMutualMatchesRequest, opts ...grpc.CallOption) (*ListMutualMatchesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMutualMatchesResponse)
+	err := c.cc.Invoke(ctx, ExploreService_ListMutualMatches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exploreServiceClient) AcknowledgeLikes(ctx context.Context, in *AcknowledgeLikesRequest, opts ...grpc.CallOption) (*AcknowledgeLikesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AcknowledgeLikesResponse)
+	err := c.cc.Invoke(ctx, ExploreService_AcknowledgeLikes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exploreServiceClient) PutDecisions(ctx context.Context, in *PutDecisionsRequest, opts ...grpc.CallOption) (*PutDecisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutDecisionsResponse)
+	err := c.cc.Invoke(ctx, ExploreService_PutDecisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exploreServiceClient) SwipeSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SwipeSessionRequest, SwipeSessionResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExploreService_ServiceDesc.Streams[0], ExploreService_SwipeSession_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SwipeSessionRequest, SwipeSessionResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExploreService_SwipeSessionClient = grpc.BidiStreamingClient[SwipeSessionRequest, SwipeSessionResponse]
+
+func (c *exploreServiceClient) WatchLikes(ctx context.Context, in *WatchLikesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchLikesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExploreService_ServiceDesc.Streams[1], ExploreService_WatchLikes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchLikesRequest, WatchLikesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExploreService_WatchLikesClient = grpc.ServerStreamingClient[WatchLikesResponse]
+
+func (c *exploreServiceClient) UndoDecision(ctx context.Context, in *UndoDecisionRequest, opts ...grpc.CallOption) (*UndoDecisionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndoDecisionResponse)
+	err := c.cc.Invoke(ctx, ExploreService_UndoDecision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exploreServiceClient) DeleteDecision(ctx context.Context, in *DeleteDecisionRequest, opts ...grpc.CallOption) (*DeleteDecisionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteDecisionResponse)
+	err := c.cc.Invoke(ctx, ExploreService_DeleteDecision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exploreServiceClient) Unmatch(ctx context.Context, in *UnmatchRequest, opts ...grpc.CallOption) (*UnmatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnmatchResponse)
+	err := c.cc.Invoke(ctx, ExploreService_Unmatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exploreServiceClient) GetRelationship(ctx context.Context, in *GetRelationshipRequest, opts ...grpc.CallOption) (*GetRelationshipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRelationshipResponse)
+	err := c.cc.Invoke(ctx, ExploreService_GetRelationship_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ExploreServiceServer is the server API for ExploreService service.
 // All implementations must embed UnimplementedExploreServiceServer
 // for forward compatibility.
 type ExploreServiceServer interface {
 	ListLikedYou(context.Context, *ListLikedYouRequest) (*ListLikedYouResponse, error)
 	ListNewLikedYou(context.Context, *ListLikedYouRequest) (*ListLikedYouResponse, error)
+	ListYouLiked(context.Context, *ListYouLikedRequest) (*ListYouLikedResponse, error)
 	CountLikedYou(context.Context, *CountLikedYouRequest) (*CountLikedYouResponse, error)
 	PutDecision(context.Context, *PutDecisionRequest) (*PutDecisionResponse, error)
+	ListMutualMatches(context.Context, *ListMutualMatchesRequest) (*ListMutualMatchesResponse, error)
+	AcknowledgeLikes(context.Context, *AcknowledgeLikesRequest) (*AcknowledgeLikesResponse, error)
+	PutDecisions(context.Context, *PutDecisionsRequest) (*PutDecisionsResponse, error)
+	SwipeSession(grpc.BidiStreamingServer[SwipeSessionRequest, SwipeSessionResponse]) error
+	WatchLikes(*WatchLikesRequest, grpc.ServerStreamingServer[WatchLikesResponse]) error
+	UndoDecision(context.Context, *UndoDecisionRequest) (*UndoDecisionResponse, error)
+	DeleteDecision(context.Context, *DeleteDecisionRequest) (*DeleteDecisionResponse, error)
+	Unmatch(context.Context, *UnmatchRequest) (*UnmatchResponse, error)
+	GetRelationship(context.Context, *GetRelationshipRequest) (*GetRelationshipResponse, error)
 	mustEmbedUnimplementedExploreServiceServer()
 }
 
@@ -107,12 +249,42 @@ func (UnimplementedExploreServiceServer) ListLikedYou(context.Context, *ListLike
 func (UnimplementedExploreServiceServer) ListNewLikedYou(context.Context, *ListLikedYouRequest) (*ListLikedYouResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListNewLikedYou not implemented")
 }
+func (UnimplementedExploreServiceServer) ListYouLiked(context.Context, *ListYouLikedRequest) (*ListYouLikedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListYouLiked not implemented")
+}
 func (UnimplementedExploreServiceServer) CountLikedYou(context.Context, *CountLikedYouRequest) (*CountLikedYouResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CountLikedYou not implemented")
 }
 func (UnimplementedExploreServiceServer) PutDecision(context.Context, *PutDecisionRequest) (*PutDecisionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PutDecision not implemented")
 }
+func (UnimplementedExploreServiceServer) ListMutualMatches(context.Context, *ListMutualMatchesRequest) (*ListMutualMatchesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMutualMatches not implemented")
+}
+func (UnimplementedExploreServiceServer) AcknowledgeLikes(context.Context, *AcknowledgeLikesRequest) (*AcknowledgeLikesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcknowledgeLikes not implemented")
+}
+func (UnimplementedExploreServiceServer) PutDecisions(context.Context, *PutDecisionsRequest) (*PutDecisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutDecisions not implemented")
+}
+func (UnimplementedExploreServiceServer) SwipeSession(grpc.BidiStreamingServer[SwipeSessionRequest, SwipeSessionResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method SwipeSession not implemented")
+}
+func (UnimplementedExploreServiceServer) WatchLikes(*WatchLikesRequest, grpc.ServerStreamingServer[WatchLikesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchLikes not implemented")
+}
+func (UnimplementedExploreServiceServer) UndoDecision(context.Context, *UndoDecisionRequest) (*UndoDecisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndoDecision not implemented")
+}
+func (UnimplementedExploreServiceServer) DeleteDecision(context.Context, *DeleteDecisionRequest) (*DeleteDecisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDecision not implemented")
+}
+func (UnimplementedExploreServiceServer) Unmatch(context.Context, *UnmatchRequest) (*UnmatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unmatch not implemented")
+}
+func (UnimplementedExploreServiceServer) GetRelationship(context.Context, *GetRelationshipRequest) (*GetRelationshipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRelationship not implemented")
+}
 func (UnimplementedExploreServiceServer) mustEmbedUnimplementedExploreServiceServer() {}
 func (UnimplementedExploreServiceServer) testEmbeddedByValue()                        {}
 
@@ -170,6 +342,24 @@ func _ExploreService_ListNewLikedYou_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ExploreService_ListYouLiked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListYouLikedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).ListYouLiked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_ListYouLiked_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).ListYouLiked(ctx, req.(*ListYouLikedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ExploreService_CountLikedYou_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CountLikedYouRequest)
 	if err := dec(in); err != nil {
@@ -206,6 +396,150 @@ func _ExploreService_PutDecision_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ExploreService_ListMutualMatches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMutualMatchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).ListMutualMatches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_ListMutualMatches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).ListMutualMatches(ctx, req.(*ListMutualMatchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExploreService_AcknowledgeLikes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcknowledgeLikesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).AcknowledgeLikes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_AcknowledgeLikes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).AcknowledgeLikes(ctx, req.(*AcknowledgeLikesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExploreService_PutDecisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutDecisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).PutDecisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_PutDecisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).PutDecisions(ctx, req.(*PutDecisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExploreService_SwipeSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ExploreServiceServer).SwipeSession(&grpc.GenericServerStream[SwipeSessionRequest, SwipeSessionResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExploreService_SwipeSessionServer = grpc.BidiStreamingServer[SwipeSessionRequest, SwipeSessionResponse]
+
+func _ExploreService_WatchLikes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchLikesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExploreServiceServer).WatchLikes(m, &grpc.GenericServerStream[WatchLikesRequest, WatchLikesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExploreService_WatchLikesServer = grpc.ServerStreamingServer[WatchLikesResponse]
+
+func _ExploreService_UndoDecision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndoDecisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).UndoDecision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_UndoDecision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).UndoDecision(ctx, req.(*UndoDecisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExploreService_DeleteDecision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDecisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).DeleteDecision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_DeleteDecision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).DeleteDecision(ctx, req.(*DeleteDecisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExploreService_Unmatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnmatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).Unmatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_Unmatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).Unmatch(ctx, req.(*UnmatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExploreService_GetRelationship_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRelationshipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExploreServiceServer).GetRelationship(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExploreService_GetRelationship_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExploreServiceServer).GetRelationship(ctx, req.(*GetRelationshipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ExploreService_ServiceDesc is the grpc.ServiceDesc for ExploreService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -221,6 +555,10 @@ var ExploreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListNewLikedYou",
 			Handler:    _ExploreService_ListNewLikedYou_Handler,
 		},
+		{
+			MethodName: "ListYouLiked",
+			Handler:    _ExploreService_ListYouLiked_Handler,
+		},
 		{
 			MethodName: "CountLikedYou",
 			Handler:    _ExploreService_CountLikedYou_Handler,
@@ -229,7 +567,47 @@ var ExploreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PutDecision",
 			Handler:    _ExploreService_PutDecision_Handler,
 		},
+		{
+			MethodName: "ListMutualMatches",
+			Handler:    _ExploreService_ListMutualMatches_Handler,
+		},
+		{
+			MethodName: "AcknowledgeLikes",
+			Handler:    _ExploreService_AcknowledgeLikes_Handler,
+		},
+		{
+			MethodName: "PutDecisions",
+			Handler:    _ExploreService_PutDecisions_Handler,
+		},
+		{
+			MethodName: "UndoDecision",
+			Handler:    _ExploreService_UndoDecision_Handler,
+		},
+		{
+			MethodName: "DeleteDecision",
+			Handler:    _ExploreService_DeleteDecision_Handler,
+		},
+		{
+			MethodName: "Unmatch",
+			Handler:    _ExploreService_Unmatch_Handler,
+		},
+		{
+			MethodName: "GetRelationship",
+			Handler:    _ExploreService_GetRelationship_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SwipeSession",
+			Handler:       _ExploreService_SwipeSession_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchLikes",
+			Handler:       _ExploreService_WatchLikes_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/api/explore-service.proto",
 }
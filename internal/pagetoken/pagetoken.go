@@ -0,0 +1,139 @@
+// Package pagetoken turns the cursors used by the stores to paginate into opaque tokens that can be
+// handed to clients. Tokens are versioned and HMAC-signed, so clients can't forge or tamper with
+// them, and can optionally be encrypted so they don't leak the user IDs they are built from.
+package pagetoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const (
+	// version is the current format of the tokens, stored as their first byte.
+	version byte = 1
+
+	// MinKeyLength is the minimum length of the secret the keys of a Codec are derived from.
+	MinKeyLength = 32
+
+	flagEncrypted byte = 1 << 0
+	headerLength       = 2 // version + flags
+)
+
+// ErrInvalidToken is returned when a token is malformed, forged, or was created with another key.
+var ErrInvalidToken = errors.New("invalid page token")
+
+// Codec encodes and decodes page tokens. It is safe for concurrent use.
+type Codec struct {
+	macKey []byte
+	aead   cipher.AEAD // nil when tokens are only signed.
+}
+
+// NewCodec creates a Codec that signs tokens with a key derived from secret, and encrypts them
+// when encrypt is true.
+func NewCodec(secret []byte, encrypt bool) (*Codec, error) {
+	if len(secret) < MinKeyLength {
+		return nil, fmt.Errorf("page token key must be at least %d bytes long", MinKeyLength)
+	}
+	c := &Codec{macKey: deriveKey(secret, "explore page token signing")}
+	if encrypt {
+		block, err := aes.NewCipher(deriveKey(secret, "explore page token encryption"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("failed to create AEAD: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// NewRandomCodec creates a Codec with a random key, so its tokens are only valid for the lifetime
+// of the process. It panics if the system's random number generator fails.
+func NewRandomCodec() *Codec {
+	secret := make([]byte, MinKeyLength)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate page token key: %v", err))
+	}
+	c, err := NewCodec(secret, false)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Encode turns a store cursor into a token. An empty cursor, meaning there are no more pages, is
+// encoded as an empty token.
+func (c *Codec) Encode(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	token := []byte{version, 0}
+	if c.aead != nil {
+		token[1] |= flagEncrypted
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		token = append(token, nonce...)
+		token = c.aead.Seal(token, nonce, []byte(cursor), token[:headerLength])
+	} else {
+		token = append(token, cursor...)
+	}
+	token = append(token, c.sign(token)...)
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Decode verifies a token and returns the cursor it carries. An empty token is decoded as an empty
+// cursor, meaning the first page.
+func (c *Codec) Decode(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < headerLength+sha256.Size {
+		return "", ErrInvalidToken
+	}
+	body, mac := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+	if !hmac.Equal(mac, c.sign(body)) {
+		return "", ErrInvalidToken
+	}
+	if body[0] != version {
+		return "", ErrInvalidToken
+	}
+	encrypted := body[1]&flagEncrypted != 0
+	if encrypted != (c.aead != nil) {
+		return "", ErrInvalidToken
+	}
+	if !encrypted {
+		return string(body[headerLength:]), nil
+	}
+	if len(body) < headerLength+c.aead.NonceSize() {
+		return "", ErrInvalidToken
+	}
+	nonce := body[headerLength : headerLength+c.aead.NonceSize()]
+	cursor, err := c.aead.Open(nil, nonce, body[headerLength+c.aead.NonceSize():], body[:headerLength])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	return string(cursor), nil
+}
+
+func (c *Codec) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// deriveKey derives a key for a given purpose out of the configured secret, so the same secret is
+// never used directly for both signing and encrypting.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,92 @@
+package pagetoken
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKey  = []byte("0123456789abcdef0123456789abcdef")
+	otherKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestRoundTrip(t *testing.T) {
+	testMap := map[string]struct {
+		encrypt bool
+		cursor  string
+	}{
+		"signed":              {encrypt: false, cursor: "actor##recipient"},
+		"encrypted":           {encrypt: true, cursor: "actor##recipient"},
+		"empty cursor":        {encrypt: false, cursor: ""},
+		"empty cursor cipher": {encrypt: true, cursor: ""},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A codec.
+			c, err := NewCodec(testKey, tc.encrypt)
+			require.NoError(t, err)
+
+			// WHEN: A cursor is encoded and decoded back.
+			token, err := c.Encode(tc.cursor)
+			require.NoError(t, err)
+			got, err := c.Decode(token)
+
+			// THEN: The original cursor is returned, and it's not readable from the token if encrypted.
+			require.NoError(t, err)
+			assert.Equal(t, tc.cursor, got)
+			if tc.encrypt && tc.cursor != "" {
+				raw, err := base64.RawURLEncoding.DecodeString(token)
+				require.NoError(t, err)
+				assert.NotContains(t, string(raw), tc.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidTokens(t *testing.T) {
+	signed, err := NewCodec(testKey, false)
+	require.NoError(t, err)
+	encrypted, err := NewCodec(testKey, true)
+	require.NoError(t, err)
+	forger, err := NewCodec(otherKey, false)
+	require.NoError(t, err)
+
+	validToken, err := signed.Encode("actor##recipient")
+	require.NoError(t, err)
+	forgedToken, err := forger.Encode("actor##recipient")
+	require.NoError(t, err)
+	raw, err := base64.RawURLEncoding.DecodeString(validToken)
+	require.NoError(t, err)
+	raw[headerLength] ^= 0xff
+	tamperedToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	testMap := map[string]struct {
+		codec *Codec
+		token string
+	}{
+		"raw cursor":            {codec: signed, token: "actor##recipient"},
+		"not base64":            {codec: signed, token: "not a token!"},
+		"too short":             {codec: signed, token: base64.RawURLEncoding.EncodeToString([]byte{version, 0})},
+		"tampered":              {codec: signed, token: tamperedToken},
+		"signed with other key": {codec: signed, token: forgedToken},
+		"not encrypted":         {codec: encrypted, token: validToken},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// WHEN: An invalid token is decoded.
+			got, err := tc.codec.Decode(tc.token)
+
+			// THEN: ErrInvalidToken is returned.
+			assert.ErrorIs(t, err, ErrInvalidToken)
+			assert.Equal(t, "", got)
+		})
+	}
+}
+
+func TestNewCodecShortKey(t *testing.T) {
+	_, err := NewCodec([]byte("short"), false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"muzz-explore/internal/store/sqlstore"
+	"muzz-explore/internal/store/storetest"
+	"os"
+	"testing"
+)
+
+// TestConformance runs the store conformance suite against a real MySQL server, on a scratch database
+// per subtest. It's skipped when EXPLORE_TEST_MYSQL_DSN is not set, like the EXPLAIN based tests.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(explainDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", explainDSNEnv)
+	}
+	storetest.Run(t, func(t *testing.T) storetest.Store {
+		return sqlstore.New(newScratchDatabase(t, dsn), dialect)
+	})
+}
@@ -2,143 +2,56 @@
 package database
 
 import (
-	"context"
 	"database/sql"
 	"fmt"
 	"muzz-explore/internal/store"
-	"strings"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlstore"
 
 	sq "github.com/Masterminds/squirrel"
-	"github.com/rs/zerolog/log"
 )
 
-const PageLength = 10
+const (
+	// upsertDecisionSuffix keeps the first decision time on re-submissions, and only changes the
+	// modification time when the type of decision changes. The like becomes new again when it flips
+	// from not liked to liked, or is upgraded to a super-like (type 3), but its like time is kept
+	// while it stays a like. MySQL evaluates the assignments in order, so the columns compared against
+	// their previous value go last.
+	upsertDecisionSuffix = "AS new ON DUPLICATE KEY UPDATE " +
+		"seen_by_recipient=IF(NOT liked_recipient AND new.liked_recipient OR decision_type<>3 AND new.decision_type=3,FALSE,seen_by_recipient)," +
+		"last_modified=IF(decision_type=new.decision_type,last_modified,new.last_modified)," +
+		"liked_at=IF(liked_recipient AND new.liked_recipient,liked_at,new.liked_at)," +
+		"liked_recipient=new.liked_recipient," +
+		"decision_type=new.decision_type"
+)
 
-type database struct {
-	db *sql.DB
+// dialect is the MySQL dialect of the SQL store. Its transactions run at read committed, as at the
+// default repeatable read InnoDB locks the gap where a missing row would go when it's read for
+// update, which deadlocks concurrent first decisions landing in the same gap.
+var dialect = sqlstore.Dialect{
+	Builder:        sq.StatementBuilder,
+	UpsertSuffix:   upsertDecisionSuffix,
+	LockSuffix:     "FOR UPDATE",
+	TxIsolation:    sql.LevelReadCommitted,
+	Greatest:       "GREATEST",
+	PopHistory:     popHistoryQuery,
+	TranslateError: translateError,
+	NewMigrator:    migrations.NewMySQL,
 }
 
-func NewClient(user, pass, address, port, dbname string) (*database, func() error, error) {
+func NewClient(user, pass, address, port, dbname string) (*sqlstore.Store, func() error, error) {
 	// Create a new connection to the database.
 	connStr := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, pass, address, port, dbname)
 	db, err := sql.Open("mysql", connStr)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	return &database{db}, db.Close, nil
-}
-
-func (d *database) ListDecisions(
-	ctx context.Context,
-	filter store.DecisionFilter,
-	page string,
-) ([]store.Decision, string, error) {
-	sb := addFilters(sq.Select("*").From("decisions"), filter)
-	if page != "" {
-		pageIDs := strings.Split(page, "##")
-		sb = sb.Where("actor_user_id>?", pageIDs[0])
-		sb = sb.Where("recipient_user_id>?", pageIDs[1])
-	}
-	sb = sb.OrderBy("actor_user_id,recipient_user_id").Limit(PageLength)
-	results, err := sb.RunWith(d.db).QueryContext(ctx)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to list decisions: %w", err)
-	}
-	decisions := []store.Decision{}
-	for results.Next() {
-		var decision store.Decision
-		if err := results.Scan(
-			&decision.ActorUserID,
-			&decision.RecipientUserID,
-			&decision.LikedRecipient,
-			&decision.LastModified,
-			&decision.SeenByRecipient,
-		); err != nil {
-			// Log error and continue to next row, as we don't want to loose the whole query.
-			log.Err(err).Msg("failed to scan decision")
-			continue
-		}
-		decisions = append(decisions, store.Decision(decision))
-	}
-	numDecisions := len(decisions)
-	if numDecisions == 0 {
-		return decisions, "", nil
-	}
-	lastDecision := decisions[numDecisions-1]
-	return decisions, fmt.Sprintf("%s##%s", lastDecision.ActorUserID, lastDecision.RecipientUserID), nil
-}
-
-func (d *database) CountDecisions(ctx context.Context, filter store.DecisionFilter) (uint64, error) {
-	sb := addFilters(sq.Select("COUNT(*)").From("decisions"), filter)
-	var count uint64
-	err := sb.RunWith(d.db).QueryRowContext(ctx).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count decisions: %w", err)
-	}
-	return count, nil
-}
-
-func (d *database) UpsertDecision(ctx context.Context, decision store.Decision) error {
-	_, err := sq.Replace("decisions").Columns(
-		"actor_user_id",
-		"recipient_user_id",
-		"liked_recipient",
-		"last_modified",
-		"seen_by_recipient",
-	).Values(
-		decision.ActorUserID,
-		decision.RecipientUserID,
-		decision.LikedRecipient,
-		decision.LastModified,
-		decision.SeenByRecipient,
-	).RunWith(d.db).ExecContext(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to upsert decision: %w", err)
-	}
-	return nil
+	return sqlstore.New(db, dialect), db.Close, nil
 }
 
-func (d *database) MarkDecisionsAsSeen(
-	ctx context.Context,
-	recipientUserID string,
-	initPageToken, nextPageToken string,
-) error {
-	if nextPageToken == "" {
-		return fmt.Errorf("nextPage cannot be blank")
-	}
-	pageIDs := strings.Split(nextPageToken, "##")
-	sb := sq.Update("decisions").Set("seen_by_recipient", true).
-		Where("recipient_user_id=?", recipientUserID).
-		Where("actor_user_id<=?", pageIDs[0])
-
-	if initPageToken != "" {
-		pageIDs := strings.Split(initPageToken, "##")
-		sb = sb.Where("actor_user_id>?", pageIDs[0])
-	}
-
-	_, err := sb.RunWith(d.db).ExecContext(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to update decisions: %w", err)
-	}
-	return nil
-}
-
-func addFilters(sb sq.SelectBuilder, filter store.DecisionFilter) sq.SelectBuilder {
-	if filter.ActorUserID != nil {
-		sb = sb.Where("actor_user_id=?", *filter.ActorUserID)
-	}
-	if filter.RecipientUserID != nil {
-		sb = sb.Where("recipient_user_id=?", *filter.RecipientUserID)
-	}
-	if filter.LikedRecipient != nil {
-		sb = sb.Where("liked_recipient=?", *filter.LikedRecipient)
-	}
-	if filter.LastModified != nil {
-		sb = sb.Where("last_modified=?", *filter.LastModified)
-	}
-	if filter.SeenByRecipient != nil {
-		sb = sb.Where("seen_by_recipient=?", *filter.SeenByRecipient)
-	}
-
-	return sb
+// popHistoryQuery builds the statement removing the last version of a decision from its history.
+func popHistoryQuery(builder sq.StatementBuilderType, key store.DecisionKey) sq.DeleteBuilder {
+	return builder.Delete("decision_history").
+		Where("actor_user_id=? AND recipient_user_id=?", key.ActorUserID, key.RecipientUserID).
+		OrderBy("id DESC").Limit(1)
 }
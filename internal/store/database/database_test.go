@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/sqlstore"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -14,6 +17,25 @@ import (
 
 func ref[T any](t T) *T { return &t }
 
+const insertDecisionQuery = "INSERT INTO decisions (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient,created_at,decision_type,liked_at) VALUES (?,?,?,?,?,?,?,?)"
+
+const upsertDecisionQuery = insertDecisionQuery + " " +
+	"AS new ON DUPLICATE KEY UPDATE " +
+	"seen_by_recipient=IF(NOT liked_recipient AND new.liked_recipient OR decision_type<>3 AND new.decision_type=3,FALSE,seen_by_recipient)," +
+	"last_modified=IF(decision_type=new.decision_type,last_modified,new.last_modified)," +
+	"liked_at=IF(liked_recipient AND new.liked_recipient,liked_at,new.liked_at)," +
+	"liked_recipient=new.liked_recipient," +
+	"decision_type=new.decision_type"
+
+const getDecisionQuery = "SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions " +
+	"WHERE (actor_user_id,recipient_user_id) IN ((?,?))"
+
+const lockDecisionQuery = getDecisionQuery + " ORDER BY actor_user_id, recipient_user_id FOR UPDATE"
+
+const recordDecisionHistoryQuery = "INSERT INTO decision_history (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient,created_at,decision_type,liked_at) VALUES (?,?,?,?,?,?,?,?)"
+
+var decisionColumns = []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+
 type dbTestSuite struct {
 	suite.Suite
 	db       *sql.DB
@@ -37,12 +59,12 @@ func (s *dbTestSuite) AfterTest(_, testName string) {
 
 func (s *dbTestSuite) Test_ListDecisionsNoFilters() {
 	// GIVEN database set up with some expectations.
-	s.mock.ExpectQuery("SELECT * FROM decisions ORDER BY actor_user_id,recipient_user_id LIMIT 10").WillReturnRows(
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions ORDER BY actor_user_id,recipient_user_id LIMIT 10").WillReturnRows(
 		s.mock.NewRows(
-			[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient"},
-		).AddRow("actor", "recipient", true, 123, false),
+			[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+		).AddRow("actor", "recipient", true, 123, false, 100, 2, 123),
 	)
-	db := database{db: s.db}
+	db := sqlstore.New(s.db, dialect)
 
 	// WHEN ListDecisions is called with no filters.
 	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{}, "")
@@ -56,25 +78,28 @@ func (s *dbTestSuite) Test_ListDecisionsNoFilters() {
 			LikedRecipient:  true,
 			LastModified:    123,
 			SeenByRecipient: false,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         123,
 		},
 	}, got)
-	assert.Equal(s.T(), "actor##recipient", gotPage)
+	assert.Equal(s.T(), `["all","actor","recipient"]`, gotPage)
 	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
 
 func (s *dbTestSuite) Test_ListDecisionsWithPage() {
 	// GIVEN database set up with some expectations.
-	s.mock.ExpectQuery("SELECT * FROM decisions WHERE actor_user_id>? AND recipient_user_id>? ORDER BY actor_user_id,recipient_user_id LIMIT 10").
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE (actor_user_id,recipient_user_id)>(?,?) ORDER BY actor_user_id,recipient_user_id LIMIT 10").
 		WithArgs("actor", "recipient").
 		WillReturnRows(
 			s.mock.NewRows(
-				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient"},
-			).AddRow("actor", "recipient", true, 123, false),
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor", "recipient", true, 123, false, 100, 2, 123),
 		)
-	db := database{db: s.db}
+	db := sqlstore.New(s.db, dialect)
 
 	// WHEN ListDecisions is called with no filters.
-	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{}, "actor##recipient")
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{}, `["all","actor","recipient"]`)
 
 	// THEN the expectations are met and the result is as expected.
 	require.NoError(s.T(), err)
@@ -85,22 +110,252 @@ func (s *dbTestSuite) Test_ListDecisionsWithPage() {
 			LikedRecipient:  true,
 			LastModified:    123,
 			SeenByRecipient: false,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         123,
+		},
+	}, got)
+	assert.Equal(s.T(), `["all","actor","recipient"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListDecisionsMultiplePages() {
+	// GIVEN database set up with two pages of likes for the same recipient, which are sorted by type
+	// and actor as the recipient is fixed, so super-likes come first.
+	columns := []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? ORDER BY decision_type DESC,actor_user_id LIMIT 10").
+		WithArgs("recipient", true).
+		WillReturnRows(
+			s.mock.NewRows(columns).
+				AddRow("actor2", "recipient", true, 2, false, 2, 3, 2).
+				AddRow("actor1", "recipient", true, 1, false, 1, 2, 1),
+		)
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? AND (decision_type<? OR (decision_type=? AND actor_user_id>?)) ORDER BY decision_type DESC,actor_user_id LIMIT 10").
+		WithArgs("recipient", true, "2", "2", "actor1").
+		WillReturnRows(s.mock.NewRows(columns).AddRow("actor3", "recipient", true, 3, false, 3, 2, 3))
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? AND (decision_type<? OR (decision_type=? AND actor_user_id>?)) ORDER BY decision_type DESC,actor_user_id LIMIT 10").
+		WithArgs("recipient", true, "2", "2", "actor3").
+		WillReturnRows(s.mock.NewRows(columns))
+	db := sqlstore.New(s.db, dialect)
+	filter := store.DecisionFilter{RecipientUserID: ref("recipient"), LikedRecipient: ref(true)}
+
+	// WHEN ListDecisions is called following the page tokens until the end.
+	var got []store.Decision
+	page := ""
+	for {
+		decisions, nextPage, err := db.ListDecisions(context.Background(), filter, page)
+		require.NoError(s.T(), err)
+		got = append(got, decisions...)
+		if nextPage == "" {
+			break
+		}
+		page = nextPage
+	}
+
+	// THEN every like is returned once, as each page resumes right after the last row of the previous one.
+	assert.Equal(s.T(), []store.Decision{
+		{ActorUserID: "actor2", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 2, CreatedAt: 2, Type: store.DecisionSuperLike, LikedAt: 2},
+		{ActorUserID: "actor1", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 1, CreatedAt: 1, Type: store.DecisionLike, LikedAt: 1},
+		{ActorUserID: "actor3", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 3, CreatedAt: 3, Type: store.DecisionLike, LikedAt: 3},
+	}, got)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListDecisionsSortedByTime() {
+	columns := []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+	testMap := map[string]struct {
+		sort      store.DecisionSort
+		page      string
+		wantQuery string
+		wantArgs  []driver.Value
+		wantPage  string
+	}{
+		"newest first": {
+			sort:      store.SortNewest,
+			page:      `["newest","150","actor2"]`,
+			wantQuery: "SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? AND last_modified>=? AND (last_modified,actor_user_id)<(?,?) ORDER BY last_modified DESC,actor_user_id DESC LIMIT 10",
+			wantArgs:  []driver.Value{"recipient", true, 100, "150", "actor2"},
+			wantPage:  `["newest","120","actor1"]`,
+		},
+		"oldest first": {
+			sort:      store.SortOldest,
+			page:      `["oldest","110","actor2"]`,
+			wantQuery: "SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? AND last_modified>=? AND (last_modified,actor_user_id)>(?,?) ORDER BY last_modified,actor_user_id LIMIT 10",
+			wantArgs:  []driver.Value{"recipient", true, 100, "110", "actor2"},
+			wantPage:  `["oldest","120","actor1"]`,
+		},
+	}
+	for name, tc := range testMap {
+		s.Run(name, func() {
+			// GIVEN database set up with some expectations.
+			s.mock.ExpectQuery(tc.wantQuery).
+				WithArgs(tc.wantArgs...).
+				WillReturnRows(s.mock.NewRows(columns).AddRow("actor1", "recipient", true, 120, false, 100, 2, 120))
+			db := sqlstore.New(s.db, dialect)
+
+			// WHEN ListDecisions is called for the likes of a recipient sorted by time.
+			got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{
+				RecipientUserID: ref("recipient"),
+				LikedRecipient:  ref(true),
+				ModifiedSince:   ref(int64(100)),
+				Sort:            tc.sort,
+			}, tc.page)
+
+			// THEN the page resumes after the token, and the next token is tagged with the sort order.
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), []store.Decision{
+				{ActorUserID: "actor1", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 120, CreatedAt: 100, Type: store.DecisionLike, LikedAt: 120},
+			}, got)
+			assert.Equal(s.T(), tc.wantPage, gotPage)
+			assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (s *dbTestSuite) Test_ListDecisionsPageOfAnotherSort() {
+	// GIVEN database with no expectations, as no query should be run.
+	db := sqlstore.New(s.db, dialect)
+	filter := store.DecisionFilter{RecipientUserID: ref("recipient"), LikedRecipient: ref(true)}
+
+	for name, tc := range map[string]struct {
+		sort store.DecisionSort
+		page string
+	}{
+		"newest with an oldest token":   {sort: store.SortNewest, page: `["oldest","110","actor2"]`},
+		"oldest with a default token":   {sort: store.SortOldest, page: `["type","2","actor2"]`},
+		"default with a newest token":   {sort: store.SortDefault, page: `["newest","110","actor2"]`},
+		"newest with an untagged token": {sort: store.SortNewest, page: `["110","actor2","x"]`},
+	} {
+		s.Run(name, func() {
+			// WHEN ListDecisions is called with a page token returned for another sort order.
+			filter.Sort = tc.sort
+			got, _, err := db.ListDecisions(context.Background(), filter, tc.page)
+
+			// THEN an invalid cursor error is returned.
+			require.ErrorIs(s.T(), err, store.ErrInvalidCursor)
+			assert.Nil(s.T(), got)
+			assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (s *dbTestSuite) Test_ListDecisionsActorWithPage() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE actor_user_id=? AND (last_modified<? OR (last_modified=? AND recipient_user_id>?)) ORDER BY last_modified DESC,recipient_user_id LIMIT 10").
+		WithArgs("actor", "123", "123", "recipient1").
+		WillReturnRows(
+			s.mock.NewRows(
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor", "recipient2", true, 123, false, 100, 2, 123),
+		)
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListDecisions is called for an actor, which are sorted newest first following the actor index.
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{ActorUserID: ref("actor")}, `["actor","123","recipient1"]`)
+
+	// THEN the expectations are met and the result is as expected.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Decision{
+		{
+			ActorUserID:     "actor",
+			RecipientUserID: "recipient2",
+			LikedRecipient:  true,
+			LastModified:    123,
+			SeenByRecipient: false,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         123,
+		},
+	}, got)
+	assert.Equal(s.T(), `["actor","123","recipient2"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListDecisionsActorTypesAndTimeRange() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE actor_user_id=? AND decision_type IN (?,?) AND last_modified>=? AND last_modified<? ORDER BY last_modified DESC,recipient_user_id LIMIT 10").
+		WithArgs("actor", store.DecisionLike, store.DecisionSuperLike, 100, 200).
+		WillReturnRows(
+			s.mock.NewRows(
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor", "recipient", true, 150, false, 100, 3, 150),
+		)
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListDecisions is called for the likes of an actor changed within a time range.
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{
+		ActorUserID:   ref("actor"),
+		Types:         []store.DecisionType{store.DecisionLike, store.DecisionSuperLike},
+		ModifiedSince: ref(int64(100)),
+		ModifiedUntil: ref(int64(200)),
+	}, "")
+
+	// THEN the expectations are met and the result is as expected.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Decision{
+		{
+			ActorUserID:     "actor",
+			RecipientUserID: "recipient",
+			LikedRecipient:  true,
+			LastModified:    150,
+			CreatedAt:       100,
+			Type:            store.DecisionSuperLike,
+			LikedAt:         150,
 		},
 	}, got)
-	assert.Equal(s.T(), "actor##recipient", gotPage)
+	assert.Equal(s.T(), `["actor","150","recipient"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListDecisionsPageSize() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? ORDER BY decision_type DESC,actor_user_id LIMIT 25").
+		WithArgs("recipient", true).
+		WillReturnRows(
+			s.mock.NewRows(
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor", "recipient", true, 123, false, 100, 2, 123),
+		)
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListDecisions is called with a page size other than the default.
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{
+		RecipientUserID: ref("recipient"),
+		LikedRecipient:  ref(true),
+		PageSize:        25,
+	}, "")
+
+	// THEN the page is limited to that size.
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), got, 1)
+	assert.Equal(s.T(), `["type","2","actor"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListDecisionsInvalidPage() {
+	// GIVEN database with no expectations, as no query should be run.
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListDecisions is called with a page token that doesn't carry the whole sort key.
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{}, "actor")
+
+	// THEN an invalid cursor error is returned.
+	require.ErrorIs(s.T(), err, store.ErrInvalidCursor)
+	assert.Nil(s.T(), got)
+	assert.Equal(s.T(), "", gotPage)
 	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
 
 func (s *dbTestSuite) Test_ListDecisionsAllFilters() {
 	// GIVEN database set up with some expectations.
 	s.mock.ExpectQuery(
-		"SELECT * FROM decisions WHERE actor_user_id=? AND recipient_user_id=? AND liked_recipient=? AND last_modified=? AND seen_by_recipient=? ORDER BY actor_user_id,recipient_user_id LIMIT 10").
+		"SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE actor_user_id=? AND recipient_user_id=? AND liked_recipient=? AND last_modified=? AND seen_by_recipient=? ORDER BY recipient_user_id LIMIT 10").
 		WillReturnRows(
 			s.mock.NewRows(
-				[]string{"actor_user_id", "recipient_user_id", "seen_by_recipient", "seen_by_recipient", "seen_by_recipient"},
-			).AddRow("actor", "recipient", true, 123, false),
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor", "recipient", true, 123, false, 100, 2, 123),
 		)
-	db := database{db: s.db}
+	db := sqlstore.New(s.db, dialect)
 
 	// WHEN ListDecisions is called with filters.
 	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{
@@ -120,9 +375,50 @@ func (s *dbTestSuite) Test_ListDecisionsAllFilters() {
 			LikedRecipient:  true,
 			LastModified:    123,
 			SeenByRecipient: false,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         123,
 		},
 	}, got)
-	assert.Equal(s.T(), "actor##recipient", gotPage)
+	assert.Equal(s.T(), `["pair","recipient"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListDecisionsExcludeReciprocatedAndBlocked() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery(
+		"SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? AND NOT EXISTS (SELECT 1 FROM decisions r WHERE r.actor_user_id=decisions.recipient_user_id AND r.recipient_user_id=decisions.actor_user_id AND r.liked_recipient=?) AND NOT EXISTS (SELECT 1 FROM decisions b WHERE b.actor_user_id=decisions.recipient_user_id AND b.recipient_user_id=decisions.actor_user_id AND b.decision_type=?) AND (decision_type<? OR (decision_type=? AND actor_user_id>?)) ORDER BY decision_type DESC,actor_user_id LIMIT 10").
+		WithArgs("recipient", true, true, store.DecisionBlock, "2", "2", "actor1").
+		WillReturnRows(
+			s.mock.NewRows(
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor2", "recipient", true, 123, true, 100, 2, 123),
+		)
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListDecisions is called excluding the reciprocated and blocked likes.
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{
+		RecipientUserID:     ref("recipient"),
+		LikedRecipient:      ref(true),
+		ExcludeReciprocated: true,
+		ExcludeBlocked:      true,
+	}, `["type","2","actor1"]`)
+
+	// THEN the expectations are met and the result is as expected.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Decision{
+		{
+			ActorUserID:     "actor2",
+			RecipientUserID: "recipient",
+			LikedRecipient:  true,
+			LastModified:    123,
+			SeenByRecipient: true,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         123,
+		},
+	}, got)
+	assert.Equal(s.T(), `["type","2","actor2"]`, gotPage)
 	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
 
@@ -131,7 +427,7 @@ func (s *dbTestSuite) Test_CountDecisionsNoFilters() {
 	s.mock.ExpectQuery("SELECT COUNT(*) FROM decisions").WillReturnRows(
 		sqlmock.NewRows([]string{"count"}).AddRow(3),
 	)
-	db := database{db: s.db}
+	db := sqlstore.New(s.db, dialect)
 	got, err := db.CountDecisions(context.Background(), store.DecisionFilter{})
 	require.NoError(s.T(), err)
 	assert.Equal(s.T(), uint64(3), got)
@@ -144,7 +440,7 @@ func (s *dbTestSuite) Test_CountDecisionsAllFilters() {
 		WithArgs("actor", "recipient", true, 123, false).WillReturnRows(
 		sqlmock.NewRows([]string{"count"}).AddRow(3),
 	)
-	db := database{db: s.db}
+	db := sqlstore.New(s.db, dialect)
 
 	// WHEN CountDecisions is called with all the filters.
 	got, err := db.CountDecisions(context.Background(), store.DecisionFilter{
@@ -162,49 +458,514 @@ func (s *dbTestSuite) Test_CountDecisionsAllFilters() {
 }
 
 func (s *dbTestSuite) Test_UpsertDecision() {
-	// GIVEN database set up with some expectations.
-	s.mock.ExpectExec("REPLACE INTO decisions (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient) VALUES (?,?,?,?,?)").
-		WithArgs("actor", "recipient", true, 123, false).WillReturnResult(sqlmock.NewResult(1, 1))
-	db := database{db: s.db}
+	// GIVEN database set up with some expectations, keeping the previous version of the decision.
+	s.mock.ExpectQuery(getDecisionQuery).WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient", false, 100, true, 100, 1, 0))
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient", false, 100, true, 100, 1, 0))
+	s.mock.ExpectExec(recordDecisionHistoryQuery).
+		WithArgs("actor", "recipient", false, 100, true, 100, store.DecisionPass, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(upsertDecisionQuery).
+		WithArgs("actor", "recipient", true, 123, false, 100, store.DecisionLike, 123).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+	db := sqlstore.New(s.db, dialect)
 
 	// WHEN UpsertDecision is called.
-	err := db.UpsertDecision(context.Background(), store.Decision{
+	got, err := db.UpsertDecision(context.Background(), store.Decision{
 		ActorUserID:     "actor",
 		RecipientUserID: "recipient",
 		LikedRecipient:  true,
 		LastModified:    123,
 		SeenByRecipient: false,
+		CreatedAt:       100,
+		Type:            store.DecisionLike,
+		LikedAt:         123,
 	})
 
-	// THEN the expectations are met and the result is as expected.
+	// THEN the expectations are met, and the type of the pass replaced is returned.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), store.DecisionPass, got)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_UpsertDecisionInsertedConcurrently() {
+	// GIVEN database where the decision is missing, but a concurrent pass inserts it before the like.
+	s.mock.ExpectQuery(getDecisionQuery).WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(decisionColumns))
+	s.mock.ExpectExec(insertDecisionQuery).
+		WithArgs("actor", "recipient", true, 123, false, 123, store.DecisionLike, 123).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient", false, 120, false, 120, 1, 0))
+	s.mock.ExpectExec(recordDecisionHistoryQuery).
+		WithArgs("actor", "recipient", false, 120, false, 120, store.DecisionPass, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(upsertDecisionQuery).
+		WithArgs("actor", "recipient", true, 123, false, 123, store.DecisionLike, 123).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN UpsertDecision is called with the like.
+	got, err := db.UpsertDecision(context.Background(), store.Decision{
+		ActorUserID:     "actor",
+		RecipientUserID: "recipient",
+		LikedRecipient:  true,
+		LastModified:    123,
+		CreatedAt:       123,
+		Type:            store.DecisionLike,
+		LikedAt:         123,
+	})
+
+	// THEN the failed insert is caught, and the like is upserted over the pass, which it replaced.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), store.DecisionPass, got)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_UpsertDecisionDeadlock() {
+	like := store.Decision{
+		ActorUserID:     "actor",
+		RecipientUserID: "recipient",
+		LikedRecipient:  true,
+		LastModified:    123,
+		SeenByRecipient: false,
+		CreatedAt:       100,
+		Type:            store.DecisionLike,
+		LikedAt:         123,
+	}
+	expectUpsert := func(err error) {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient", true, 100, true, 100, 2, 100))
+		upsert := s.mock.ExpectExec(upsertDecisionQuery).WithArgs("actor", "recipient", true, 123, false, 100, store.DecisionLike, 123)
+		if err != nil {
+			upsert.WillReturnError(err)
+			s.mock.ExpectRollback()
+			return
+		}
+		upsert.WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mock.ExpectCommit()
+	}
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	s.Run("retried", func() {
+		// GIVEN database with the like already, failing its upsert with a deadlock once.
+		s.mock.ExpectQuery(getDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient", true, 100, true, 100, 2, 100))
+		expectUpsert(deadlock)
+		expectUpsert(nil)
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UpsertDecision is called.
+		_, err := db.UpsertDecision(context.Background(), like)
+
+		// THEN the transaction is run again, and succeeds.
+		require.NoError(s.T(), err)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("persistent", func() {
+		// GIVEN database with the like already, failing its upsert with a deadlock every time.
+		s.mock.ExpectQuery(getDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient", true, 100, true, 100, 2, 100))
+		for range 3 {
+			expectUpsert(deadlock)
+		}
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UpsertDecision is called.
+		_, err := db.UpsertDecision(context.Background(), like)
+
+		// THEN a conflict error is returned after the last attempt, keeping the driver one.
+		require.ErrorIs(s.T(), err, store.ErrConflict)
+		var mysqlErr *mysql.MySQLError
+		assert.ErrorAs(s.T(), err, &mysqlErr)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *dbTestSuite) Test_UpsertDecisions() {
+	// GIVEN database set up with some expectations, where the first recipient had been passed: the
+	// new decision is inserted, and the others upserted in order, after recording the pass once.
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(
+		"SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions "+
+			"WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?),(?,?)) ORDER BY actor_user_id, recipient_user_id FOR UPDATE").
+		WithArgs("actor", "recipient2", "actor", "recipient1", "actor", "recipient1").
+		WillReturnRows(s.mock.NewRows(decisionColumns).AddRow("actor", "recipient1", false, 100, true, 100, 1, 0))
+	s.mock.ExpectExec(recordDecisionHistoryQuery).
+		WithArgs("actor", "recipient1", false, 100, true, 100, store.DecisionPass, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(insertDecisionQuery).
+		WithArgs("actor", "recipient2", true, 123, false, 123, store.DecisionSuperLike, 123).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(
+		"INSERT INTO decisions (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient,created_at,decision_type,liked_at) VALUES (?,?,?,?,?,?,?,?),(?,?,?,?,?,?,?,?) "+
+			"AS new ON DUPLICATE KEY UPDATE "+
+			"seen_by_recipient=IF(NOT liked_recipient AND new.liked_recipient OR decision_type<>3 AND new.decision_type=3,FALSE,seen_by_recipient),"+
+			"last_modified=IF(decision_type=new.decision_type,last_modified,new.last_modified),"+
+			"liked_at=IF(liked_recipient AND new.liked_recipient,liked_at,new.liked_at),"+
+			"liked_recipient=new.liked_recipient,"+
+			"decision_type=new.decision_type").
+		WithArgs(
+			"actor", "recipient1", false, 123, false, 123, store.DecisionPass, 0,
+			"actor", "recipient1", true, 123, false, 123, store.DecisionLike, 123,
+		).WillReturnResult(sqlmock.NewResult(2, 2))
+	s.mock.ExpectCommit()
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN UpsertDecisions is called, with two decisions on the same recipient.
+	got, err := db.UpsertDecisions(context.Background(), []store.Decision{
+		{ActorUserID: "actor", RecipientUserID: "recipient2", LikedRecipient: true, LastModified: 123, CreatedAt: 123, Type: store.DecisionSuperLike, LikedAt: 123},
+		{ActorUserID: "actor", RecipientUserID: "recipient1", LikedRecipient: false, LastModified: 123, CreatedAt: 123, Type: store.DecisionPass},
+		{ActorUserID: "actor", RecipientUserID: "recipient1", LikedRecipient: true, LastModified: 123, CreatedAt: 123, Type: store.DecisionLike, LikedAt: 123},
+	})
+
+	// THEN the expectations are met, keeping the order of the decisions on the same recipient, and each
+	// decision replaced the previous one on its recipient.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.DecisionType{0, store.DecisionPass, store.DecisionPass}, got)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_UndoDecision() {
+	columns := []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+	lockDecisionQuery := "SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions " +
+		"WHERE (actor_user_id,recipient_user_id) IN ((?,?)) FOR UPDATE"
+	lockHistoryQuery := "SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decision_history " +
+		"WHERE actor_user_id=? AND recipient_user_id=? ORDER BY id DESC LIMIT 1 FOR UPDATE"
+	deleteHistoryQuery := "DELETE FROM decision_history WHERE (actor_user_id,recipient_user_id) IN ((?,?))"
+	key := store.DecisionKey{ActorUserID: "actor", RecipientUserID: "recipient"}
+	like := store.Decision{ActorUserID: "actor", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 200, CreatedAt: 100, Type: store.DecisionLike, LikedAt: 200}
+	pass := store.Decision{ActorUserID: "actor", RecipientUserID: "recipient", LastModified: 100, SeenByRecipient: true, CreatedAt: 100, Type: store.DecisionPass}
+
+	s.Run("previous version restored", func() {
+		// GIVEN a like that replaced a pass.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", true, 200, false, 100, 2, 200))
+		s.mock.ExpectQuery(lockHistoryQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", false, 100, true, 100, 1, 0))
+		s.mock.ExpectExec("UPDATE decisions SET liked_recipient = ?, last_modified = ?, seen_by_recipient = ?, created_at = ?, decision_type = ?, liked_at = ? "+
+			"WHERE (actor_user_id,recipient_user_id) IN ((?,?))").
+			WithArgs(false, 100, true, 100, store.DecisionPass, 0, "actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectExec("DELETE FROM decision_history WHERE actor_user_id=? AND recipient_user_id=? ORDER BY id DESC LIMIT 1").
+			WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UndoDecision is called within the window.
+		undone, restored, err := db.UndoDecision(context.Background(), key, 100)
+
+		// THEN the pass is restored, and its version removed from the history.
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), like, undone)
+		assert.Equal(s.T(), &pass, restored)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("previous version out of the window restored", func() {
+		// GIVEN a like that replaced a pass.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", true, 200, false, 100, 2, 200))
+		s.mock.ExpectQuery(lockHistoryQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", false, 100, true, 100, 1, 0))
+		s.mock.ExpectExec("UPDATE decisions SET liked_recipient = ?, last_modified = ?, seen_by_recipient = ?, created_at = ?, decision_type = ?, liked_at = ? "+
+			"WHERE (actor_user_id,recipient_user_id) IN ((?,?))").
+			WithArgs(false, 100, true, 100, store.DecisionPass, 0, "actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectExec(deleteHistoryQuery).WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 3))
+		s.mock.ExpectCommit()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UndoDecision is called within the window of the like, but not of the pass.
+		undone, restored, err := db.UndoDecision(context.Background(), key, 150)
+
+		// THEN the pass is restored, and the whole history deleted, as it can't be reached anymore.
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), like, undone)
+		assert.Equal(s.T(), &pass, restored)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("first decision deleted", func() {
+		// GIVEN a like with no previous version.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", true, 200, false, 100, 2, 200))
+		s.mock.ExpectQuery(lockHistoryQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns))
+		s.mock.ExpectExec("DELETE FROM decisions WHERE (actor_user_id,recipient_user_id) IN ((?,?))").
+			WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UndoDecision is called within the window.
+		undone, restored, err := db.UndoDecision(context.Background(), key, 150)
+
+		// THEN the like is deleted, with nothing restored.
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), like, undone)
+		assert.Nil(s.T(), restored)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("decision too old", func() {
+		// GIVEN a like modified before the window.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", true, 200, false, 100, 2, 200))
+		s.mock.ExpectExec(deleteHistoryQuery).WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UndoDecision is called.
+		_, _, err := db.UndoDecision(context.Background(), key, 300)
+
+		// THEN the decision is kept, its history that can't be restored anymore is deleted, and a
+		// failed precondition is returned.
+		require.ErrorIs(s.T(), err, store.ErrFailedPrecondition)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("no decision", func() {
+		// GIVEN no decision of the actor on the recipient.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockDecisionQuery).WithArgs("actor", "recipient").
+			WillReturnRows(s.mock.NewRows(columns))
+		s.mock.ExpectRollback()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN UndoDecision is called.
+		_, _, err := db.UndoDecision(context.Background(), key, 150)
+
+		// THEN a not found error is returned.
+		require.ErrorIs(s.T(), err, store.ErrNotFound)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *dbTestSuite) Test_DeleteDecision() {
+	// GIVEN database with a decision, deleted along with its history.
+	columns := []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions "+
+		"WHERE (actor_user_id,recipient_user_id) IN ((?,?)) FOR UPDATE").
+		WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", true, 200, false, 100, 2, 200))
+	s.mock.ExpectExec("DELETE FROM decisions WHERE (actor_user_id,recipient_user_id) IN ((?,?))").
+		WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE FROM decision_history WHERE (actor_user_id,recipient_user_id) IN ((?,?))").
+		WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN DeleteDecision is called.
+	deleted, err := db.DeleteDecision(context.Background(), store.DecisionKey{ActorUserID: "actor", RecipientUserID: "recipient"})
+
+	// THEN the expectations are met, and the decision deleted is returned.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), store.Decision{
+		ActorUserID:     "actor",
+		RecipientUserID: "recipient",
+		LikedRecipient:  true,
+		LastModified:    200,
+		CreatedAt:       100,
+		Type:            store.DecisionLike,
+		LikedAt:         200,
+	}, deleted)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_Unmatch() {
+	lockLikesQuery := "SELECT actor_user_id FROM decisions WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?)) AND liked_recipient=? FOR UPDATE"
+
+	s.Run("matched", func() {
+		// GIVEN database with the likes of both users on each other.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockLikesQuery).
+			WithArgs("user1", "user2", "user2", "user1", true).
+			WillReturnRows(s.mock.NewRows([]string{"actor_user_id"}).AddRow("user1").AddRow("user2"))
+		s.mock.ExpectExec("DELETE FROM decisions WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?))").
+			WithArgs("user1", "user2", "user2", "user1").WillReturnResult(sqlmock.NewResult(0, 2))
+		s.mock.ExpectExec("DELETE FROM decision_history WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?))").
+			WithArgs("user1", "user2", "user2", "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN Unmatch is called.
+		err := db.Unmatch(context.Background(), "user1", "user2")
+
+		// THEN both likes are deleted.
+		require.NoError(s.T(), err)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("not matched", func() {
+		// GIVEN database with only one of the likes.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockLikesQuery).
+			WithArgs("user1", "user2", "user2", "user1", true).
+			WillReturnRows(s.mock.NewRows([]string{"actor_user_id"}).AddRow("user1"))
+		s.mock.ExpectRollback()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN Unmatch is called.
+		err := db.Unmatch(context.Background(), "user1", "user2")
+
+		// THEN nothing is deleted, and a not found error is returned.
+		require.ErrorIs(s.T(), err, store.ErrNotFound)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *dbTestSuite) Test_GetDecisions() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery(
+		"SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?))").
+		WithArgs("actor1", "recipient", "actor2", "recipient").
+		WillReturnRows(
+			s.mock.NewRows(
+				[]string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"},
+			).AddRow("actor2", "recipient", true, 123, false, 100, 2, 123),
+		)
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN GetDecisions is called.
+	got, err := db.GetDecisions(context.Background(), []store.DecisionKey{
+		{ActorUserID: "actor1", RecipientUserID: "recipient"},
+		{ActorUserID: "actor2", RecipientUserID: "recipient"},
+	})
+
+	// THEN only the existing decisions are returned.
 	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Decision{
+		{
+			ActorUserID:     "actor2",
+			RecipientUserID: "recipient",
+			LikedRecipient:  true,
+			LastModified:    123,
+			SeenByRecipient: false,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         123,
+		},
+	}, got)
 	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
 
-func (s *dbTestSuite) Test_MarkDecisionsAsSeenNoInitPage() {
+func (s *dbTestSuite) Test_MarkDecisionsAsSeen() {
 	// GIVEN database set up with some expectations.
-	s.mock.ExpectExec("UPDATE decisions SET seen_by_recipient = ? WHERE recipient_user_id=? AND actor_user_id<=?").
-		WithArgs(true, "recipient", "actor20").WillReturnResult(sqlmock.NewResult(1, 1))
-	db := database{db: s.db}
+	s.mock.ExpectExec("UPDATE decisions SET seen_by_recipient = ? WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?))").
+		WithArgs(true, "actor10", "recipient", "actor20", "recipient").WillReturnResult(sqlmock.NewResult(0, 2))
+	db := sqlstore.New(s.db, dialect)
 
 	// WHEN MarkDecisionsAsSeen is called.
-	err := db.MarkDecisionsAsSeen(context.Background(), "recipient", "", "actor20##recipient")
+	err := db.MarkDecisionsAsSeen(context.Background(), []store.DecisionKey{
+		{ActorUserID: "actor10", RecipientUserID: "recipient"},
+		{ActorUserID: "actor20", RecipientUserID: "recipient"},
+	})
 
 	// THEN the expectations are met and the result is as expected.
 	require.NoError(s.T(), err)
 	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
 
-func (s *dbTestSuite) Test_MarkDecisionsAsSeenInitPage() {
+func (s *dbTestSuite) Test_MarkDecisionsAsSeenConcurrentLike() {
+	// GIVEN a page with the likes of actor10 and actor20 was listed, and the like of actor15 arrived
+	// right after, sorting in between them.
+	columns := []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions WHERE recipient_user_id=? AND liked_recipient=? ORDER BY decision_type DESC,actor_user_id LIMIT 10").
+		WithArgs("recipient", true).
+		WillReturnRows(
+			s.mock.NewRows(columns).
+				AddRow("actor10", "recipient", true, 1, false, 1, 2, 1).
+				AddRow("actor20", "recipient", true, 2, false, 2, 2, 2),
+		)
+	s.mock.ExpectQuery(getDecisionQuery).WithArgs("actor15", "recipient").WillReturnRows(s.mock.NewRows(columns))
+	s.mock.ExpectExec(insertDecisionQuery).
+		WithArgs("actor15", "recipient", true, 3, false, 3, store.DecisionLike, 3).WillReturnResult(sqlmock.NewResult(1, 1))
+	// Only the keys of the listed decisions are updated, so the like of actor15 stays unseen.
+	s.mock.ExpectExec("UPDATE decisions SET seen_by_recipient = ? WHERE (actor_user_id,recipient_user_id) IN ((?,?),(?,?))").
+		WithArgs(true, "actor10", "recipient", "actor20", "recipient").WillReturnResult(sqlmock.NewResult(0, 2))
+	db := sqlstore.New(s.db, dialect)
+	ctx := context.Background()
+
+	// WHEN the page is marked as seen after the concurrent like.
+	decisions, _, err := db.ListDecisions(ctx, store.DecisionFilter{
+		RecipientUserID: ref("recipient"),
+		LikedRecipient:  ref(true),
+	}, "")
+	require.NoError(s.T(), err)
+	_, err = db.UpsertDecision(ctx, store.Decision{
+		ActorUserID:     "actor15",
+		RecipientUserID: "recipient",
+		LikedRecipient:  true,
+		LastModified:    3,
+		CreatedAt:       3,
+		Type:            store.DecisionLike,
+		LikedAt:         3,
+	})
+	require.NoError(s.T(), err)
+	keys := []store.DecisionKey{}
+	for _, decision := range decisions {
+		keys = append(keys, decision.Key())
+	}
+	err = db.MarkDecisionsAsSeen(ctx, keys)
+
+	// THEN the expectations are met and the result is as expected.
+	require.NoError(s.T(), err)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_MarkDecisionsAsSeenNoKeys() {
+	// GIVEN database with no expectations, as no statement should be run.
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN MarkDecisionsAsSeen is called with no keys.
+	err := db.MarkDecisionsAsSeen(context.Background(), nil)
+
+	// THEN nothing is updated.
+	require.NoError(s.T(), err)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListMutualMatchesNoPage() {
 	// GIVEN database set up with some expectations.
-	s.mock.ExpectExec("UPDATE decisions SET seen_by_recipient = ? WHERE recipient_user_id=? AND actor_user_id<=? AND actor_user_id>?").
-		WithArgs(true, "recipient", "actor20", "actor10").WillReturnResult(sqlmock.NewResult(1, 1))
-	db := database{db: s.db}
+	s.mock.ExpectQuery("SELECT d.recipient_user_id, GREATEST(d.liked_at,r.liked_at) FROM decisions d JOIN decisions r ON r.actor_user_id=d.recipient_user_id AND r.recipient_user_id=d.actor_user_id WHERE d.actor_user_id=? AND d.liked_recipient=? AND r.liked_recipient=? ORDER BY d.recipient_user_id LIMIT 10").
+		WithArgs("user", true, true).
+		WillReturnRows(
+			s.mock.NewRows([]string{"recipient_user_id", "matched_at"}).
+				AddRow("partner1", 123).
+				AddRow("partner2", 456),
+		)
+	db := sqlstore.New(s.db, dialect)
 
-	// WHEN MarkDecisionsAsSeen is called.
-	err := db.MarkDecisionsAsSeen(context.Background(), "recipient", "actor10##recipient", "actor20##recipient")
+	// WHEN ListMutualMatches is called with no page.
+	got, gotPage, err := db.ListMutualMatches(context.Background(), "user", "")
 
 	// THEN the expectations are met and the result is as expected.
 	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Match{
+		{UserID: "user", PartnerUserID: "partner1", MatchedAt: 123},
+		{UserID: "user", PartnerUserID: "partner2", MatchedAt: 456},
+	}, got)
+	assert.Equal(s.T(), `["partner2"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *dbTestSuite) Test_ListMutualMatchesWithPage() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery("SELECT d.recipient_user_id, GREATEST(d.liked_at,r.liked_at) FROM decisions d JOIN decisions r ON r.actor_user_id=d.recipient_user_id AND r.recipient_user_id=d.actor_user_id WHERE d.actor_user_id=? AND d.liked_recipient=? AND r.liked_recipient=? AND d.recipient_user_id>? ORDER BY d.recipient_user_id LIMIT 10").
+		WithArgs("user", true, true, "partner2").
+		WillReturnRows(s.mock.NewRows([]string{"recipient_user_id", "matched_at"}))
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListMutualMatches is called with the last page.
+	got, gotPage, err := db.ListMutualMatches(context.Background(), "user", `["partner2"]`)
+
+	// THEN the expectations are met and no more matches are returned.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Match{}, got)
+	assert.Equal(s.T(), "", gotPage)
 	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
 }
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers translated into store errors.
+// See https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	errDupEntry           = 1062
+	errLockDeadlock       = 1213
+	errLockWaitTimeout    = 1205
+	errConCount           = 1040
+	errServerShutdown     = 1053
+	errQueryTimeout       = 3024
+	errOptionPreventsStmt = 1290 // i.e., the server is read only.
+)
+
+// translateError wraps an error coming from the MySQL driver with the store error it corresponds to,
+// keeping the original one for logging. Unknown errors are returned as they are.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sentinel error
+	var mysqlErr *mysql.MySQLError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		sentinel = store.ErrDeadline
+	case errors.Is(err, sql.ErrNoRows):
+		sentinel = store.ErrNotFound
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn), errors.As(err, &netErr):
+		sentinel = store.ErrUnavailable
+	case errors.As(err, &mysqlErr):
+		switch mysqlErr.Number {
+		case errDupEntry, errLockDeadlock:
+			sentinel = store.ErrConflict
+		case errLockWaitTimeout, errConCount, errServerShutdown, errOptionPreventsStmt:
+			sentinel = store.ErrUnavailable
+		case errQueryTimeout:
+			sentinel = store.ErrDeadline
+		}
+	}
+	if sentinel == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
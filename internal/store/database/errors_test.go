@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	testMap := map[string]struct {
+		err  error
+		want error
+	}{
+		"no error": {
+			err:  nil,
+			want: nil,
+		},
+		"no rows": {
+			err:  sql.ErrNoRows,
+			want: store.ErrNotFound,
+		},
+		"duplicate entry": {
+			err:  &mysql.MySQLError{Number: 1062},
+			want: store.ErrConflict,
+		},
+		"deadlock": {
+			err:  &mysql.MySQLError{Number: 1213},
+			want: store.ErrConflict,
+		},
+		"lock wait timeout": {
+			err:  &mysql.MySQLError{Number: 1205},
+			want: store.ErrUnavailable,
+		},
+		"too many connections": {
+			err:  &mysql.MySQLError{Number: 1040},
+			want: store.ErrUnavailable,
+		},
+		"query timeout": {
+			err:  &mysql.MySQLError{Number: 3024},
+			want: store.ErrDeadline,
+		},
+		"bad connection": {
+			err:  driver.ErrBadConn,
+			want: store.ErrUnavailable,
+		},
+		"invalid connection": {
+			err:  mysql.ErrInvalidConn,
+			want: store.ErrUnavailable,
+		},
+		"context deadline": {
+			err:  fmt.Errorf("waiting for connection: %w", context.DeadlineExceeded),
+			want: store.ErrDeadline,
+		},
+	}
+
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			got := translateError(tc.err)
+			if tc.want == nil {
+				assert.NoError(t, got)
+				return
+			}
+			assert.ErrorIs(t, got, tc.want)
+			assert.ErrorIs(t, got, tc.err)
+		})
+	}
+
+	t.Run("unknown error", func(t *testing.T) {
+		err := &mysql.MySQLError{Number: 1064}
+		got := translateError(err)
+		assert.Equal(t, err, got)
+		for _, sentinel := range []error{
+			store.ErrNotFound, store.ErrConflict, store.ErrUnavailable, store.ErrInvalidCursor, store.ErrDeadline,
+		} {
+			assert.False(t, errors.Is(got, sentinel))
+		}
+	})
+}
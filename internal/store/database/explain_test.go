@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlquery"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// explainDSNEnv holds the DSN of a MySQL server where the EXPLAIN based tests can create a scratch
+// database, like "root@tcp(localhost:3306)/". The tests are skipped when it's not set.
+const explainDSNEnv = "EXPLORE_TEST_MYSQL_DSN"
+
+// TestQueriesUseIndexes checks the plan of the hot queries, so it fails if any of them stops using
+// the index meant to serve it, or has to sort its results.
+func TestQueriesUseIndexes(t *testing.T) {
+	dsn := os.Getenv(explainDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", explainDSNEnv)
+	}
+	db := newScratchDatabase(t, dsn)
+	seedDecisions(t, db)
+
+	mustQuery := func(sb sq.SelectBuilder, _ sqlquery.Ordering, err error) sq.Sqlizer {
+		require.NoError(t, err)
+		return sb
+	}
+	mutualMatchesQuery, err := dialect.ListMutualMatchesQuery("a001", sqlquery.EncodePageToken("r1"))
+	require.NoError(t, err)
+	testMap := map[string]struct {
+		query sq.Sqlizer
+		// wantKeys are the indexes each table of the query can use, by its name or alias.
+		wantKeys map[string][]string
+	}{
+		"ListLikedYou": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID: ref("r1"),
+				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
+			}, "")),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_recipient_liked"}, "b": {"PRIMARY"}},
+		},
+		"ListLikedYou next page": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID: ref("r1"),
+				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
+			}, sqlquery.EncodePageToken("type", "2", "a100"))),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_recipient_liked"}, "b": {"PRIMARY"}},
+		},
+		"ListNewLikedYou": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID: ref("r1"),
+				LikedRecipient:  ref(true),
+				SeenByRecipient: ref(false),
+				ExcludeBlocked:  true,
+			}, sqlquery.EncodePageToken("type", "2", "a100"))),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_recipient_liked_seen"}, "b": {"PRIMARY"}},
+		},
+		"ListNewLikedYou unreciprocated": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID:     ref("r1"),
+				LikedRecipient:      ref(true),
+				SeenByRecipient:     ref(false),
+				ExcludeReciprocated: true,
+				ExcludeBlocked:      true,
+			}, "")),
+			wantKeys: map[string][]string{
+				"decisions": {"idx_decisions_recipient_liked_seen"},
+				"r":         {"PRIMARY"},
+				"b":         {"PRIMARY"},
+			},
+		},
+		"ListLikedYou newest next page": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID: ref("r1"),
+				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
+				Sort:            store.SortNewest,
+			}, sqlquery.EncodePageToken("newest", "300", "a300"))),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_recipient_liked_modified"}, "b": {"PRIMARY"}},
+		},
+		"ListNewLikedYou oldest within a time range": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID: ref("r1"),
+				LikedRecipient:  ref(true),
+				SeenByRecipient: ref(false),
+				ExcludeBlocked:  true,
+				Sort:            store.SortOldest,
+				ModifiedSince:   ref(int64(100)),
+				ModifiedUntil:   ref(int64(200)),
+			}, "")),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_recipient_liked_seen_modified"}, "b": {"PRIMARY"}},
+		},
+		"CountLikedYou": {
+			query: sqlquery.CountDecisions(sq.StatementBuilder, store.DecisionFilter{
+				RecipientUserID: ref("r1"),
+				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
+			}),
+			// Both recipient indexes cover the count equally well.
+			wantKeys: map[string][]string{
+				"decisions": {"idx_decisions_recipient_liked", "idx_decisions_recipient_liked_seen"},
+				"b":         {"PRIMARY"},
+			},
+		},
+		"ListYouLiked": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				ActorUserID:    ref("a001"),
+				ExcludeBlocked: true,
+			}, "")),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_actor_modified"}, "b": {"PRIMARY"}},
+		},
+		"ListYouLiked pending likes of some types next page": {
+			query: mustQuery(sqlquery.ListDecisions(sq.StatementBuilder, store.DecisionFilter{
+				ActorUserID:         ref("a001"),
+				LikedRecipient:      ref(true),
+				Types:               []store.DecisionType{store.DecisionSuperLike},
+				ModifiedSince:       ref(int64(0)),
+				ModifiedUntil:       ref(int64(400)),
+				ExcludeReciprocated: true,
+				ExcludeBlocked:      true,
+			}, sqlquery.EncodePageToken("actor", "1", "r5"))),
+			wantKeys: map[string][]string{"decisions": {"idx_decisions_actor_modified"}, "r": {"PRIMARY"}, "b": {"PRIMARY"}},
+		},
+		"PutDecision mutual check": {
+			query:    sqlquery.GetDecisions(sq.StatementBuilder, []store.DecisionKey{{ActorUserID: "r1", RecipientUserID: "a001"}}),
+			wantKeys: map[string][]string{"decisions": {"PRIMARY"}},
+		},
+		"GetRelationship": {
+			query: sqlquery.GetDecisions(sq.StatementBuilder, []store.DecisionKey{
+				{ActorUserID: "a001", RecipientUserID: "r1"},
+				{ActorUserID: "r1", RecipientUserID: "a001"},
+				{ActorUserID: "a001", RecipientUserID: "r2"},
+				{ActorUserID: "r2", RecipientUserID: "a001"},
+			}),
+			wantKeys: map[string][]string{"decisions": {"PRIMARY"}},
+		},
+		"ListMutualMatches": {
+			query:    mutualMatchesQuery,
+			wantKeys: map[string][]string{"d": {"PRIMARY"}, "r": {"PRIMARY"}},
+		},
+		"MarkDecisionsAsSeen": {
+			query: dialect.MarkDecisionsAsSeenQuery([]store.DecisionKey{
+				{ActorUserID: "a001", RecipientUserID: "r1"},
+				{ActorUserID: "a002", RecipientUserID: "r2"},
+			}),
+			wantKeys: map[string][]string{"decisions": {"PRIMARY"}},
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// WHEN: The plan of the query is explained.
+			plan := explain(t, db, tc.query)
+
+			// THEN: Every table is read through its index, and no sorting is needed.
+			require.Len(t, plan, len(tc.wantKeys))
+			for _, row := range plan {
+				assert.Contains(t, tc.wantKeys[row["table"]], row["key"], "index used by %s", row["table"])
+				assert.NotContains(t, row["Extra"], "Using filesort")
+			}
+		})
+	}
+}
+
+// scratchDatabases counts the scratch databases created, to name them apart.
+var scratchDatabases atomic.Int64
+
+// newScratchDatabase creates an empty database with the schema up to date, dropped at the end of the test.
+func newScratchDatabase(t *testing.T, dsn string) *sql.DB {
+	db := newEmptyDatabase(t, dsn)
+	migrator, err := migrations.NewMySQL(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+// newEmptyDatabase creates a database without any table, dropped at the end of the test.
+func newEmptyDatabase(t *testing.T, dsn string) *sql.DB {
+	cfg, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	server, err := sql.Open("mysql", cfg.FormatDSN())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+
+	name := fmt.Sprintf("explore_test_%d_%d", os.Getpid(), scratchDatabases.Add(1))
+	_, err = server.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = server.Exec("DROP DATABASE " + name) })
+
+	cfg.DBName = name
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// seedDecisions fills the decisions table with enough rows for the optimizer to prefer the indexes
+// over scanning the whole table.
+func seedDecisions(t *testing.T, db *sql.DB) {
+	ib := sq.Insert("decisions").Columns(sqlquery.DecisionColumns...)
+	for actor := 0; actor < 500; actor++ {
+		for recipient := 0; recipient < 10; recipient++ {
+			decisionType := store.DecisionPass
+			switch {
+			case actor%7 == 0:
+				decisionType = store.DecisionSuperLike
+			case actor%3 != 0:
+				decisionType = store.DecisionLike
+			}
+			ib = ib.Values(
+				fmt.Sprintf("a%03d", actor),
+				fmt.Sprintf("r%d", recipient),
+				decisionType.Liked(),
+				actor,
+				actor%2 == 0,
+				actor,
+				decisionType,
+			)
+		}
+	}
+	_, err := ib.RunWith(db).Exec()
+	require.NoError(t, err)
+	_, err = db.Exec("ANALYZE TABLE decisions")
+	require.NoError(t, err)
+}
+
+// explain returns the rows of the plan of a query, as column name to value.
+func explain(t *testing.T, db *sql.DB, query sq.Sqlizer) []map[string]string {
+	sqlQuery, args, err := query.ToSql()
+	require.NoError(t, err)
+	rows, err := db.Query("EXPLAIN "+sqlQuery, args...)
+	require.NoError(t, err)
+	defer rows.Close()
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+
+	var plan []map[string]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(pointers...))
+		row := map[string]string{}
+		for i, column := range columns {
+			row[column] = strings.TrimSpace(values[i].String)
+		}
+		plan = append(plan, row)
+	}
+	require.NoError(t, rows.Err())
+	return plan
+}
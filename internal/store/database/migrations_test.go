@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlstore"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// baselineSchema is the schema databases were created with before migrations were introduced.
+const baselineSchema = `CREATE TABLE decisions
+(
+    actor_user_id VARCHAR(10) NOT NULL,
+    recipient_user_id VARCHAR(10) NOT NULL,
+    liked_recipient BOOLEAN NOT NULL,
+    last_modified INT(11) NOT NULL,
+    seen_by_recipient BOOLEAN NOT NULL,
+    CONSTRAINT PK_decision PRIMARY KEY (actor_user_id,recipient_user_id)
+)`
+
+// TestMigrateFromBaseline checks a database created before migrations were introduced adopts them,
+// keeping its decisions. It's skipped when EXPLORE_TEST_MYSQL_DSN is not set, like the EXPLAIN based
+// tests.
+func TestMigrateFromBaseline(t *testing.T) {
+	dsn := os.Getenv(explainDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", explainDSNEnv)
+	}
+	// GIVEN: A database with the baseline schema, holding a like and a pass.
+	ctx := context.Background()
+	db := newEmptyDatabase(t, dsn)
+	_, err := db.Exec(baselineSchema)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO decisions VALUES ('actor','recipient1',TRUE,100,TRUE),('actor','recipient2',FALSE,200,FALSE)")
+	require.NoError(t, err)
+
+	// WHEN: All the migrations are applied.
+	migrator, err := migrations.NewMySQL(db)
+	require.NoError(t, err)
+	applied, err := migrator.Up(ctx)
+
+	// THEN: They are all applied, and the schema is up to date.
+	require.NoError(t, err)
+	assert.Equal(t, int(migrator.Latest()), applied)
+	require.NoError(t, migrator.Check(ctx))
+
+	// AND: The decisions are kept, created when they were last modified, with their types, and the
+	// like liked when it was last modified.
+	got, err := sqlstore.New(db, dialect).GetDecisions(ctx, []store.DecisionKey{
+		{ActorUserID: "actor", RecipientUserID: "recipient1"},
+		{ActorUserID: "actor", RecipientUserID: "recipient2"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []store.Decision{
+		{
+			ActorUserID:     "actor",
+			RecipientUserID: "recipient1",
+			LikedRecipient:  true,
+			LastModified:    100,
+			SeenByRecipient: true,
+			CreatedAt:       100,
+			Type:            store.DecisionLike,
+			LikedAt:         100,
+		},
+		{
+			ActorUserID:     "actor",
+			RecipientUserID: "recipient2",
+			LastModified:    200,
+			CreatedAt:       200,
+			Type:            store.DecisionPass,
+		},
+	}, got)
+}
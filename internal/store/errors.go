@@ -0,0 +1,20 @@
+package store
+
+import "errors"
+
+// Generic errors any store implementation returns, wrapping the underlying one, so callers can decide
+// how to react to them without depending on the details of the store.
+var (
+	// ErrNotFound is returned when the requested data doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the operation clashed with a concurrent one, and can be retried.
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable is returned when the store can't be reached, or is temporarily unable to serve.
+	ErrUnavailable = errors.New("store unavailable")
+	// ErrInvalidCursor is returned when a page token can't be used to resume a listing.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrDeadline is returned when the operation didn't finish in time.
+	ErrDeadline = errors.New("deadline exceeded")
+	// ErrFailedPrecondition is returned when the data isn't in the state the operation requires.
+	ErrFailedPrecondition = errors.New("failed precondition")
+)
@@ -0,0 +1,366 @@
+// This file contains an in-memory implementation of the store, meant for tests and for running the
+// service locally without a database. It follows the behaviour of the MySQL one, sorting and paging
+// the listings with the orderings of the SQL stores, so their page tokens are the same.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/sqlquery"
+	"slices"
+	"sync"
+)
+
+// Store keeps the decisions in memory, so they are lost when the process ends, and are not shared
+// between replicas. It's safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	decisions map[store.DecisionKey]store.Decision
+	// history holds the previous versions of each decision, the last one being the most recent.
+	history map[store.DecisionKey][]store.Decision
+}
+
+func New() *Store {
+	return &Store{
+		decisions: map[store.DecisionKey]store.Decision{},
+		history:   map[store.DecisionKey][]store.Decision{},
+	}
+}
+
+func (s *Store) ListDecisions(
+	ctx context.Context,
+	filter store.DecisionFilter,
+	page string,
+) ([]store.Decision, string, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, "", fmt.Errorf("failed to list decisions: %w", err)
+	}
+	order := sqlquery.OrderingFor(filter)
+	var after []string
+	if page != "" {
+		var err error
+		after, err = order.DecodePageToken(page)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	s.mu.RLock()
+	decisions := []store.Decision{}
+	for _, decision := range s.decisions {
+		if !s.matches(decision, filter) {
+			continue
+		}
+		if after != nil && order.Compare(order.SortKey(decision), after) <= 0 {
+			continue
+		}
+		decisions = append(decisions, decision)
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(decisions, func(a, b store.Decision) int {
+		return order.Compare(order.SortKey(a), order.SortKey(b))
+	})
+	pageSize := sqlquery.PageLength
+	if filter.PageSize > 0 {
+		pageSize = filter.PageSize
+	}
+	decisions = decisions[:min(len(decisions), pageSize)]
+	if len(decisions) == 0 {
+		return decisions, "", nil
+	}
+	return decisions, order.PageToken(decisions[len(decisions)-1]), nil
+}
+
+func (s *Store) CountDecisions(ctx context.Context, filter store.DecisionFilter) (uint64, error) {
+	if err := checkContext(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count decisions: %w", err)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var count uint64
+	for _, decision := range s.decisions {
+		if s.matches(decision, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UpsertDecision inserts a decision, or updates the existing one of the actor on the recipient,
+// following the same rules as the database: the creation time is kept, the modification time only
+// changes along with the type, and the seen state is only reset when the decision becomes a like, or
+// a super-like. The previous version of a decision changing its type is kept in its history. It
+// returns the type the decision had before, or zero if it's new.
+func (s *Store) UpsertDecision(ctx context.Context, decision store.Decision) (store.DecisionType, error) {
+	if err := checkContext(ctx); err != nil {
+		return 0, fmt.Errorf("failed to upsert decision: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upsertDecisions([]store.Decision{decision})[0], nil
+}
+
+// UpsertDecisions upserts a batch of decisions at once, applied in order, returning the type each of
+// them replaced.
+func (s *Store) UpsertDecisions(ctx context.Context, decisions []store.Decision) ([]store.DecisionType, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to upsert decisions: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upsertDecisions(decisions), nil
+}
+
+// upsertDecisions applies a batch of decisions, returning the type each of them replaced. As the
+// database does, the history gets the version of each decision from before the batch, if any decision
+// of the batch on the same pair changes its type.
+func (s *Store) upsertDecisions(decisions []store.Decision) []store.DecisionType {
+	recorded := map[store.DecisionKey]bool{}
+	for _, decision := range decisions {
+		key := decision.Key()
+		existing, ok := s.decisions[key]
+		if !ok || recorded[key] || existing.Type == decision.Type {
+			continue
+		}
+		s.history[key] = append(s.history[key], existing)
+		recorded[key] = true
+	}
+	previous := make([]store.DecisionType, 0, len(decisions))
+	for _, decision := range decisions {
+		existing, ok := s.decisions[decision.Key()]
+		previous = append(previous, existing.Type)
+		if !ok {
+			s.decisions[decision.Key()] = decision
+			continue
+		}
+		if !existing.LikedRecipient && decision.LikedRecipient ||
+			existing.Type != store.DecisionSuperLike && decision.Type == store.DecisionSuperLike {
+			existing.SeenByRecipient = false
+		}
+		if existing.Type != decision.Type {
+			existing.LastModified = decision.LastModified
+		}
+		if !existing.LikedRecipient || !decision.LikedRecipient {
+			existing.LikedAt = decision.LikedAt
+		}
+		existing.LikedRecipient = decision.LikedRecipient
+		existing.Type = decision.Type
+		s.decisions[decision.Key()] = existing
+	}
+	return previous
+}
+
+// UndoDecision reverts the last change of a decision, restoring its previous version, or deleting it
+// if it had none. Decisions last modified before notBefore can't be undone anymore. It returns the
+// decision undone, and the one restored, if any. As the database does, the versions that can't be
+// restored anymore are dropped from the history.
+func (s *Store) UndoDecision(
+	ctx context.Context,
+	key store.DecisionKey,
+	notBefore int64,
+) (store.Decision, *store.Decision, error) {
+	if err := checkContext(ctx); err != nil {
+		return store.Decision{}, nil, fmt.Errorf("failed to undo decision: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	undone, ok := s.decisions[key]
+	if !ok {
+		return store.Decision{}, nil, fmt.Errorf("failed to undo decision: %w", store.ErrNotFound)
+	}
+	if undone.LastModified < notBefore {
+		delete(s.history, key)
+		return store.Decision{}, nil, fmt.Errorf(
+			"failed to undo decision: %w: decision last modified at %d",
+			store.ErrFailedPrecondition,
+			undone.LastModified,
+		)
+	}
+	history := s.history[key]
+	if len(history) == 0 {
+		delete(s.decisions, key)
+		return undone, nil, nil
+	}
+	previous := history[len(history)-1]
+	s.decisions[key] = previous
+	if len(history) == 1 || previous.LastModified < notBefore {
+		delete(s.history, key)
+	} else {
+		s.history[key] = history[:len(history)-1]
+	}
+	return undone, &previous, nil
+}
+
+// DeleteDecision deletes the decision of the actor on the recipient, along with its history, so it
+// can't be undone. It returns the decision deleted.
+func (s *Store) DeleteDecision(ctx context.Context, key store.DecisionKey) (store.Decision, error) {
+	if err := checkContext(ctx); err != nil {
+		return store.Decision{}, fmt.Errorf("failed to delete decision: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted, ok := s.decisions[key]
+	if !ok {
+		return store.Decision{}, fmt.Errorf("failed to delete decision: %w", store.ErrNotFound)
+	}
+	s.deleteWithHistory(key)
+	return deleted, nil
+}
+
+// Unmatch deletes the likes of two matched users on each other, along with their history, so they
+// only match again if both like each other anew.
+func (s *Store) Unmatch(ctx context.Context, userID, partnerUserID string) error {
+	if err := checkContext(ctx); err != nil {
+		return fmt.Errorf("failed to unmatch: %w", err)
+	}
+	keys := []store.DecisionKey{
+		{ActorUserID: userID, RecipientUserID: partnerUserID},
+		{ActorUserID: partnerUserID, RecipientUserID: userID},
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if !s.decisions[key].LikedRecipient {
+			return fmt.Errorf(
+				"failed to unmatch: %w: users %q and %q are not matched",
+				store.ErrNotFound,
+				userID,
+				partnerUserID,
+			)
+		}
+	}
+	for _, key := range keys {
+		s.deleteWithHistory(key)
+	}
+	return nil
+}
+
+// GetDecisions returns the existing decisions with the given keys, in no particular order.
+func (s *Store) GetDecisions(ctx context.Context, keys []store.DecisionKey) ([]store.Decision, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get decisions: %w", err)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	decisions := []store.Decision{}
+	seen := map[store.DecisionKey]bool{}
+	for _, key := range keys {
+		decision, ok := s.decisions[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		decisions = append(decisions, decision)
+	}
+	return decisions, nil
+}
+
+// ListMutualMatches lists the users that liked userID and were liked back, ordered by partner. They
+// matched when the later of their likes was made. The page token encodes the last partner returned.
+func (s *Store) ListMutualMatches(
+	ctx context.Context,
+	userID string,
+	page string,
+) ([]store.Match, string, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, "", fmt.Errorf("failed to list mutual matches: %w", err)
+	}
+	var after string
+	if page != "" {
+		pageIDs, err := sqlquery.DecodePageToken(page, 1)
+		if err != nil {
+			return nil, "", err
+		}
+		after = pageIDs[0]
+	}
+
+	s.mu.RLock()
+	matches := []store.Match{}
+	for _, decision := range s.decisions {
+		if decision.ActorUserID != userID || !decision.LikedRecipient || page != "" && decision.RecipientUserID <= after {
+			continue
+		}
+		reverse, ok := s.decisions[reverseKey(decision)]
+		if !ok || !reverse.LikedRecipient {
+			continue
+		}
+		matches = append(matches, store.Match{
+			UserID:        userID,
+			PartnerUserID: decision.RecipientUserID,
+			MatchedAt:     max(decision.LikedAt, reverse.LikedAt),
+		})
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(matches, func(a, b store.Match) int { return cmp.Compare(a.PartnerUserID, b.PartnerUserID) })
+	matches = matches[:min(len(matches), sqlquery.PageLength)]
+	if len(matches) == 0 {
+		return matches, "", nil
+	}
+	return matches, sqlquery.EncodePageToken(matches[len(matches)-1].PartnerUserID), nil
+}
+
+// MarkDecisionsAsSeen marks exactly the given decisions as seen by their recipients.
+func (s *Store) MarkDecisionsAsSeen(ctx context.Context, keys []store.DecisionKey) error {
+	if err := checkContext(ctx); err != nil {
+		return fmt.Errorf("failed to update decisions: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if decision, ok := s.decisions[key]; ok {
+			decision.SeenByRecipient = true
+			s.decisions[key] = decision
+		}
+	}
+	return nil
+}
+
+// deleteWithHistory deletes a decision, and its history. The lock must be held.
+func (s *Store) deleteWithHistory(key store.DecisionKey) {
+	delete(s.decisions, key)
+	delete(s.history, key)
+}
+
+// matches tells if a decision matches a filter. The read lock must be held, as the reverse decision
+// is looked up to exclude the reciprocated and blocked ones.
+func (s *Store) matches(decision store.Decision, filter store.DecisionFilter) bool {
+	switch {
+	case filter.ActorUserID != nil && decision.ActorUserID != *filter.ActorUserID,
+		filter.RecipientUserID != nil && decision.RecipientUserID != *filter.RecipientUserID,
+		filter.LikedRecipient != nil && decision.LikedRecipient != *filter.LikedRecipient,
+		filter.LastModified != nil && decision.LastModified != int64(*filter.LastModified),
+		filter.SeenByRecipient != nil && decision.SeenByRecipient != *filter.SeenByRecipient,
+		len(filter.Types) > 0 && !slices.Contains(filter.Types, decision.Type),
+		filter.ModifiedSince != nil && decision.LastModified < *filter.ModifiedSince,
+		filter.ModifiedUntil != nil && decision.LastModified >= *filter.ModifiedUntil:
+		return false
+	}
+	reverse, ok := s.decisions[reverseKey(decision)]
+	if filter.ExcludeReciprocated && ok && reverse.LikedRecipient {
+		return false
+	}
+	if filter.ExcludeBlocked && ok && reverse.Type == store.DecisionBlock {
+		return false
+	}
+	return true
+}
+
+// reverseKey returns the key of the decision of the recipient on the actor.
+func reverseKey(decision store.Decision) store.DecisionKey {
+	return store.DecisionKey{ActorUserID: decision.RecipientUserID, RecipientUserID: decision.ActorUserID}
+}
+
+// checkContext returns the error of a context that is done, wrapping the store error it corresponds
+// to, as the database does.
+func checkContext(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", store.ErrDeadline, err)
+	}
+	return err
+}
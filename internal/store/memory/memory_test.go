@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"context"
+	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/storetest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ref[T any](t T) *T { return &t }
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store { return New() })
+}
+
+func TestUpsertDecision(t *testing.T) {
+	// GIVEN a store with a pass, seen by its recipient.
+	ctx := context.Background()
+	s := New()
+	key := store.DecisionKey{ActorUserID: "actor", RecipientUserID: "recipient"}
+	pass := store.Decision{
+		ActorUserID:     "actor",
+		RecipientUserID: "recipient",
+		LastModified:    100,
+		SeenByRecipient: true,
+		CreatedAt:       100,
+		Type:            store.DecisionPass,
+	}
+	previous, err := s.UpsertDecision(ctx, pass)
+	require.NoError(t, err)
+	assert.Zero(t, previous)
+
+	// WHEN the same pass is re-submitted, and then turned into a like.
+	again := pass
+	again.LastModified, again.CreatedAt = 200, 200
+	previousAgain, err := s.UpsertDecision(ctx, again)
+	require.NoError(t, err)
+	previousLike, err := s.UpsertDecision(ctx, store.Decision{
+		ActorUserID:     "actor",
+		RecipientUserID: "recipient",
+		LikedRecipient:  true,
+		LastModified:    300,
+		CreatedAt:       300,
+		Type:            store.DecisionLike,
+		LikedAt:         300,
+	})
+	require.NoError(t, err)
+
+	// THEN both replaced the pass, and the like keeps the creation time of the pass, and is new again.
+	assert.Equal(t, store.DecisionPass, previousAgain)
+	assert.Equal(t, store.DecisionPass, previousLike)
+	got, err := s.GetDecisions(ctx, []store.DecisionKey{key})
+	require.NoError(t, err)
+	like := store.Decision{
+		ActorUserID:     "actor",
+		RecipientUserID: "recipient",
+		LikedRecipient:  true,
+		LastModified:    300,
+		CreatedAt:       100,
+		Type:            store.DecisionLike,
+		LikedAt:         300,
+	}
+	assert.Equal(t, []store.Decision{like}, got)
+
+	// AND only the change of type can be undone, restoring the pass and then deleting it.
+	undone, restored, err := s.UndoDecision(ctx, key, 0)
+	require.NoError(t, err)
+	assert.Equal(t, like, undone)
+	assert.Equal(t, &pass, restored)
+	undone, restored, err = s.UndoDecision(ctx, key, 0)
+	require.NoError(t, err)
+	assert.Equal(t, pass, undone)
+	assert.Nil(t, restored)
+	_, _, err = s.UndoDecision(ctx, key, 0)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestListDecisionsMultiplePages(t *testing.T) {
+	// GIVEN a store with the likes of 3 actors on a recipient, one of them liked back and another one
+	// blocked.
+	ctx := context.Background()
+	s := New()
+	_, err := s.UpsertDecisions(ctx, []store.Decision{
+		{ActorUserID: "actor1", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 1, Type: store.DecisionLike, LikedAt: 1},
+		{ActorUserID: "actor2", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 2, Type: store.DecisionSuperLike, LikedAt: 2},
+		{ActorUserID: "actor3", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 3, Type: store.DecisionLike, LikedAt: 3},
+		{ActorUserID: "actor4", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 4, Type: store.DecisionLike, LikedAt: 4},
+		{ActorUserID: "recipient", RecipientUserID: "actor3", LikedRecipient: true, LastModified: 5, Type: store.DecisionLike, LikedAt: 5},
+		{ActorUserID: "recipient", RecipientUserID: "actor4", LastModified: 6, Type: store.DecisionBlock},
+	})
+	require.NoError(t, err)
+	testMap := map[string]struct {
+		filter    store.DecisionFilter
+		wantPages [][]string
+		wantToken string
+	}{
+		"super-likes first": {
+			filter:    store.DecisionFilter{RecipientUserID: ref("recipient"), LikedRecipient: ref(true), PageSize: 2},
+			wantPages: [][]string{{"actor2", "actor1"}, {"actor3", "actor4"}},
+			wantToken: `["type","2","actor4"]`,
+		},
+		"newest first, without the blocked ones": {
+			filter: store.DecisionFilter{
+				RecipientUserID: ref("recipient"),
+				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
+				Sort:            store.SortNewest,
+				PageSize:        2,
+			},
+			wantPages: [][]string{{"actor3", "actor2"}, {"actor1"}},
+			wantToken: `["newest","1","actor1"]`,
+		},
+		"not reciprocated within a time range": {
+			filter: store.DecisionFilter{
+				RecipientUserID:     ref("recipient"),
+				LikedRecipient:      ref(true),
+				ExcludeReciprocated: true,
+				ModifiedSince:       ref(int64(2)),
+				ModifiedUntil:       ref(int64(5)),
+				Sort:                store.SortOldest,
+			},
+			wantPages: [][]string{{"actor2", "actor4"}},
+			wantToken: `["oldest","4","actor4"]`,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// WHEN ListDecisions is called following the page tokens until the end.
+			var gotPages [][]string
+			var gotToken string
+			page := ""
+			for {
+				decisions, nextPage, err := s.ListDecisions(ctx, tc.filter, page)
+				require.NoError(t, err)
+				if len(decisions) == 0 {
+					break
+				}
+				var actors []string
+				for _, decision := range decisions {
+					actors = append(actors, decision.ActorUserID)
+				}
+				gotPages, gotToken, page = append(gotPages, actors), nextPage, nextPage
+			}
+
+			// THEN every decision is listed once in order, with the same page tokens as the database.
+			assert.Equal(t, tc.wantPages, gotPages)
+			assert.Equal(t, tc.wantToken, gotToken)
+
+			// AND they are all counted.
+			count, err := s.CountDecisions(ctx, tc.filter)
+			require.NoError(t, err)
+			wantCount := 0
+			for _, page := range tc.wantPages {
+				wantCount += len(page)
+			}
+			assert.Equal(t, uint64(wantCount), count)
+		})
+	}
+}
+
+func TestListDecisionsInvalidPage(t *testing.T) {
+	s := New()
+	for name, tc := range map[string]struct {
+		filter store.DecisionFilter
+		page   string
+	}{
+		"incomplete sort key": {filter: store.DecisionFilter{}, page: `["actor"]`},
+		"token of another sort": {
+			filter: store.DecisionFilter{RecipientUserID: ref("recipient"), Sort: store.SortNewest},
+			page:   `["oldest","1","actor"]`,
+		},
+		"type not a number": {filter: store.DecisionFilter{RecipientUserID: ref("recipient")}, page: `["type","like","actor"]`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// WHEN ListDecisions is called with a page token it can't resume from.
+			got, _, err := s.ListDecisions(context.Background(), tc.filter, tc.page)
+
+			// THEN an invalid cursor error is returned.
+			require.ErrorIs(t, err, store.ErrInvalidCursor)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func TestUnmatch(t *testing.T) {
+	// GIVEN a store with two users matched, and a like not liked back.
+	ctx := context.Background()
+	s := New()
+	_, err := s.UpsertDecisions(ctx, []store.Decision{
+		{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: 1, Type: store.DecisionLike, LikedAt: 1},
+		{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 2, Type: store.DecisionLike, LikedAt: 2},
+		{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, LastModified: 3, Type: store.DecisionLike, LikedAt: 3},
+	})
+	require.NoError(t, err)
+	matches, page, err := s.ListMutualMatches(ctx, "user1", "")
+	require.NoError(t, err)
+	assert.Equal(t, []store.Match{{UserID: "user1", PartnerUserID: "user2", MatchedAt: 2}}, matches)
+	assert.Equal(t, `["user2"]`, page)
+
+	// WHEN the users that are not matched are unmatched, and then the matched ones.
+	errNotMatched := s.Unmatch(ctx, "user1", "user3")
+	err = s.Unmatch(ctx, "user2", "user1")
+
+	// THEN only the likes of the matched users are deleted.
+	assert.ErrorIs(t, errNotMatched, store.ErrNotFound)
+	require.NoError(t, err)
+	matches, _, err = s.ListMutualMatches(ctx, "user1", "")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+	got, err := s.GetDecisions(ctx, []store.DecisionKey{
+		{ActorUserID: "user1", RecipientUserID: "user2"},
+		{ActorUserID: "user2", RecipientUserID: "user1"},
+		{ActorUserID: "user1", RecipientUserID: "user3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []store.Decision{
+		{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, LastModified: 3, Type: store.DecisionLike, LikedAt: 3},
+	}, got)
+}
+
+func TestCanceledContext(t *testing.T) {
+	// GIVEN a context past its deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	// WHEN the store is used with it.
+	_, err := New().UpsertDecision(ctx, store.Decision{ActorUserID: "actor", RecipientUserID: "recipient"})
+
+	// THEN the deadline error is returned, as the database does.
+	assert.ErrorIs(t, err, store.ErrDeadline)
+}
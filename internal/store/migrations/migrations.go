@@ -0,0 +1,344 @@
+// Package migrations versions the schema of the SQL databases. Migrations are SQL files embedded in
+// the binary, named <version>_<name>.up.sql and <version>_<name>.down.sql, and the ones applied are
+// recorded in the schema_migrations table together with a checksum, so edited migrations are
+// detected.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed mysql/*.sql
+var mysqlMigrations embed.FS
+
+//go:embed postgres/*.sql
+var postgresMigrations embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteMigrations embed.FS
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at BIGINT NOT NULL
+)`
+
+var (
+	// ErrSchemaBehind is returned when there are migrations pending to be applied.
+	ErrSchemaBehind = errors.New("database schema is behind")
+	// ErrChecksumMismatch is returned when an applied migration was edited afterwards.
+	ErrChecksumMismatch = errors.New("applied migration does not match its checksum")
+
+	fileNameRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+// Migration is a versioned change of the schema.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // Hex encoded SHA-256 of Up.
+}
+
+// Status is the state of a migration in the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt int64
+}
+
+// Migrator applies and reverts migrations on a database.
+type Migrator struct {
+	db          *sql.DB
+	migrations  []Migration // Sorted by version.
+	placeholder sq.PlaceholderFormat
+	nowFn       func() time.Time // Used to get the current time, overridden in tests.
+}
+
+// New creates a Migrator for the migrations in the root of fsys, using the given placeholder
+// format for the queries on schema_migrations.
+func New(db *sql.DB, fsys fs.FS, placeholder sq.PlaceholderFormat) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		placeholder: placeholder,
+		nowFn:       time.Now,
+	}, nil
+}
+
+// NewMySQL creates a Migrator for the MySQL schema of the decisions store.
+func NewMySQL(db *sql.DB) (*Migrator, error) {
+	fsys, err := fs.Sub(mysqlMigrations, "mysql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	return New(db, fsys, sq.Question)
+}
+
+// NewPostgres creates a Migrator for the PostgreSQL schema of the decisions store.
+func NewPostgres(db *sql.DB) (*Migrator, error) {
+	fsys, err := fs.Sub(postgresMigrations, "postgres")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	return New(db, fsys, sq.Dollar)
+}
+
+// NewSQLite creates a Migrator for the SQLite schema of the decisions store.
+func NewSQLite(db *sql.DB) (*Migrator, error) {
+	fsys, err := fs.Sub(sqliteMigrations, "sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	return New(db, fsys, sq.Question)
+}
+
+// Latest returns the version of the last migration known by the binary.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status returns the state of every migration known by the binary.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			if record.checksum != migration.Checksum {
+				return nil, fmt.Errorf("%w: version %d", ErrChecksumMismatch, migration.Version)
+			}
+			status.Applied = true
+			status.AppliedAt = record.appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Check returns ErrSchemaBehind if any migration is pending, so the service can refuse to run
+// against an outdated schema. Applied migrations unknown by the binary are tolerated, so older
+// binaries keep running while a newer version is rolled out.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			return fmt.Errorf("%w: version %d (%s) is pending", ErrSchemaBehind, status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+// Up applies all the pending migrations in order, returning how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+		if err := m.apply(ctx, status.Migration); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Down reverts the last steps applied migrations, in reverse order, returning how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	reverted := 0
+	for i := len(statuses) - 1; i >= 0 && reverted < steps; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		if err := m.revert(ctx, statuses[i].Migration); err != nil {
+			return reverted, err
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	log.Info().Int64("version", migration.Version).Str("name", migration.Name).Msg("applying migration")
+	return m.inTx(ctx, migration.Up, sq.Insert("schema_migrations").
+		Columns("version", "name", "checksum", "applied_at").
+		Values(migration.Version, migration.Name, migration.Checksum, m.nowFn().Unix()))
+}
+
+func (m *Migrator) revert(ctx context.Context, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d (%s) can't be reverted", migration.Version, migration.Name)
+	}
+	log.Info().Int64("version", migration.Version).Str("name", migration.Name).Msg("reverting migration")
+	return m.inTx(ctx, migration.Down, sq.Delete("schema_migrations").Where("version=?", migration.Version))
+}
+
+// inTx runs the statements of a migration script and records it in schema_migrations. Databases
+// that don't support transactional DDL (like MySQL) commit every schema change on their own, so a
+// failure may leave the migration partially applied. PostgreSQL and SQLite roll back the whole
+// migration.
+func (m *Migrator) inTx(ctx context.Context, script string, record sq.Sqlizer) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, statement := range statements(script) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to run migration: %w", err)
+		}
+	}
+	query, args, err := record.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if query, err = m.placeholder.ReplacePlaceholders(query); err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt int64
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+	if _, err := m.db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	results, err := sq.Select("version", "checksum", "applied_at").From("schema_migrations").
+		PlaceholderFormat(m.placeholder).RunWith(m.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer results.Close()
+	applied := map[int64]appliedMigration{}
+	for results.Next() {
+		var version int64
+		var record appliedMigration
+		if err := results.Scan(&version, &record.checksum, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = record
+	}
+	if err := results.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// load reads the migrations in the root of fsys, sorted by version.
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		matches := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// statements splits a script into the statements it contains, as not every driver accepts several
+// statements in a single call. Statements must end with a semicolon at the end of a line.
+func statements(script string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		if strings.HasSuffix(trimmed, ";") {
+			if statement := strings.TrimSpace(current.String()); statement != ";" {
+				result = append(result, strings.TrimSuffix(statement, ";"))
+			}
+			current.Reset()
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		result = append(result, statement)
+	}
+	return result
+}
@@ -0,0 +1,239 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectApplied   = "SELECT version, checksum, applied_at FROM schema_migrations"
+	insertMigration = "INSERT INTO schema_migrations (version,name,checksum,applied_at) VALUES (?,?,?,?)"
+	deleteMigration = "DELETE FROM schema_migrations WHERE version=?"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (id INT);\n")},
+	"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;\n")},
+	"0002_add_column.up.sql":     {Data: []byte("-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n")},
+	"0002_add_column.down.sql":   {Data: []byte("ALTER TABLE t DROP c;\n")},
+	"README.md":                  {Data: []byte("Not a migration.")},
+}
+
+func checksum(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	m, err := New(db, testMigrations, sq.Question)
+	require.NoError(t, err)
+	m.nowFn = func() time.Time { return time.Unix(123, 0) }
+	return m, mock
+}
+
+func expectApplied(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectExec(createMigrationsTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectApplied).WillReturnRows(rows)
+}
+
+func TestLoad(t *testing.T) {
+	// WHEN: The migrations are loaded.
+	got, err := load(testMigrations)
+
+	// THEN: They are sorted by version, with their checksums.
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{
+			Version:  1,
+			Name:     "create_table",
+			Up:       "CREATE TABLE t (id INT);\n",
+			Down:     "DROP TABLE t;\n",
+			Checksum: checksum("CREATE TABLE t (id INT);\n"),
+		},
+		{
+			Version:  2,
+			Name:     "add_column",
+			Up:       "-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n",
+			Down:     "ALTER TABLE t DROP c;\n",
+			Checksum: checksum("-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n"),
+		},
+	}, got)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	testMap := map[string]fstest.MapFS{
+		"no up script": {
+			"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		},
+		"two names for a version": {
+			"0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (id INT);")},
+			"0001_other_name.down.sql": {Data: []byte("DROP TABLE t;")},
+		},
+	}
+	for name, fsys := range testMap {
+		t.Run(name, func(t *testing.T) {
+			_, err := load(fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	got := statements("-- Comment.\nCREATE TABLE t\n(\n    id INT\n);\nUPDATE t SET id = 1;\n\nDELETE FROM t")
+	assert.Equal(t, []string{"CREATE TABLE t\n(\n    id INT\n)", "UPDATE t SET id = 1", "DELETE FROM t"}, got)
+}
+
+func TestUp(t *testing.T) {
+	// GIVEN: A database with the first migration applied.
+	m, mock := newTestMigrator(t)
+	expectApplied(mock, sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+		AddRow(1, checksum("CREATE TABLE t (id INT);\n"), 100))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE t ADD c INT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE t SET c = id").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertMigration).
+		WithArgs(2, "add_column", checksum("-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n"), 123).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// WHEN: Up is called.
+	got, err := m.Up(context.Background())
+
+	// THEN: Only the pending migration is applied and recorded.
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpChecksumMismatch(t *testing.T) {
+	// GIVEN: A database where the first migration was applied with a different content.
+	m, mock := newTestMigrator(t)
+	expectApplied(mock, sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+		AddRow(1, checksum("CREATE TABLE t (other INT);\n"), 100))
+
+	// WHEN: Up is called.
+	got, err := m.Up(context.Background())
+
+	// THEN: Nothing is applied.
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.Equal(t, 0, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheck(t *testing.T) {
+	testMap := map[string]struct {
+		applied *sqlmock.Rows
+		wantErr error
+	}{
+		"up to date": {
+			applied: sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+				AddRow(1, checksum("CREATE TABLE t (id INT);\n"), 100).
+				AddRow(2, checksum("-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n"), 100),
+			wantErr: nil,
+		},
+		"newer migration applied": {
+			applied: sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+				AddRow(1, checksum("CREATE TABLE t (id INT);\n"), 100).
+				AddRow(2, checksum("-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n"), 100).
+				AddRow(3, checksum("ALTER TABLE t ADD d INT;\n"), 100),
+			wantErr: nil,
+		},
+		"behind": {
+			applied: sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+				AddRow(1, checksum("CREATE TABLE t (id INT);\n"), 100),
+			wantErr: ErrSchemaBehind,
+		},
+		"empty database": {
+			applied: sqlmock.NewRows([]string{"version", "checksum", "applied_at"}),
+			wantErr: ErrSchemaBehind,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A database with some migrations applied.
+			m, mock := newTestMigrator(t)
+			expectApplied(mock, tc.applied)
+
+			// WHEN: Check is called.
+			err := m.Check(context.Background())
+
+			// THEN: The result should match the expectations.
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDown(t *testing.T) {
+	// GIVEN: A database with both migrations applied.
+	m, mock := newTestMigrator(t)
+	expectApplied(mock, sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+		AddRow(1, checksum("CREATE TABLE t (id INT);\n"), 100).
+		AddRow(2, checksum("-- Two statements.\nALTER TABLE t ADD c INT;\nUPDATE t SET c = id;\n"), 100))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE t DROP c").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(deleteMigration).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// WHEN: Down is called for one step.
+	got, err := m.Down(context.Background(), 1)
+
+	// THEN: Only the last migration is reverted.
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLMigrations(t *testing.T) {
+	// WHEN: The embedded MySQL migrations are loaded.
+	m, err := NewMySQL(nil)
+
+	// THEN: They are valid and can all be reverted.
+	require.NoError(t, err)
+	require.NotEmpty(t, m.migrations)
+	for i, migration := range m.migrations {
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Down, "migration %d can't be reverted", migration.Version)
+	}
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	// WHEN: The embedded PostgreSQL migrations are loaded.
+	m, err := NewPostgres(nil)
+
+	// THEN: They are valid and can all be reverted.
+	require.NoError(t, err)
+	require.NotEmpty(t, m.migrations)
+	for i, migration := range m.migrations {
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Down, "migration %d can't be reverted", migration.Version)
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	// WHEN: The embedded SQLite migrations are loaded.
+	m, err := NewSQLite(nil)
+
+	// THEN: They are valid and can all be reverted.
+	require.NoError(t, err)
+	require.NotEmpty(t, m.migrations)
+	for i, migration := range m.migrations {
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Down, "migration %d can't be reverted", migration.Version)
+	}
+}
@@ -1,4 +1,6 @@
-CREATE TABLE decisions
+-- IF NOT EXISTS, so databases created before migrations were introduced can adopt them. This is the
+-- schema they were created with, changed by the later migrations only.
+CREATE TABLE IF NOT EXISTS decisions
 (
     actor_user_id VARCHAR(10) NOT NULL,
     recipient_user_id VARCHAR(10) NOT NULL,
@@ -6,4 +8,4 @@ CREATE TABLE decisions
     last_modified INT(11) NOT NULL,
     seen_by_recipient BOOLEAN NOT NULL,
     CONSTRAINT PK_decision PRIMARY KEY (actor_user_id,recipient_user_id)
-);
\ No newline at end of file
+);
@@ -0,0 +1,4 @@
+-- The time of the first decision on a pair, kept across re-submissions. Decisions made before it was
+-- tracked take their modification time as their best known creation time.
+ALTER TABLE decisions ADD COLUMN created_at INT NOT NULL DEFAULT 0;
+UPDATE decisions SET created_at = last_modified;
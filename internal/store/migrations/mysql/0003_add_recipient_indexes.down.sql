@@ -0,0 +1,2 @@
+DROP INDEX idx_decisions_recipient_liked_seen ON decisions;
+DROP INDEX idx_decisions_recipient_liked ON decisions;
@@ -0,0 +1,6 @@
+-- Covering indexes for the recipient-side listings and counts, sorted by actor after the columns
+-- they filter by, so pages are read in order without sorting.
+CREATE INDEX idx_decisions_recipient_liked
+    ON decisions (recipient_user_id, liked_recipient, actor_user_id, last_modified, seen_by_recipient, created_at);
+CREATE INDEX idx_decisions_recipient_liked_seen
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, actor_user_id, last_modified, created_at);
@@ -0,0 +1,8 @@
+DROP INDEX idx_decisions_recipient_liked_seen ON decisions;
+DROP INDEX idx_decisions_recipient_liked ON decisions;
+CREATE INDEX idx_decisions_recipient_liked
+    ON decisions (recipient_user_id, liked_recipient, actor_user_id, last_modified, seen_by_recipient, created_at);
+CREATE INDEX idx_decisions_recipient_liked_seen
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, actor_user_id, last_modified, created_at);
+-- Blocks and super-likes are lost, becoming passes and likes.
+ALTER TABLE decisions DROP COLUMN decision_type;
@@ -0,0 +1,13 @@
+-- Decision types: 1 pass, 2 like, 3 super-like, 4 block. liked_recipient is kept in sync, being true
+-- for likes and super-likes, so the queries only interested in likes don't need to change.
+ALTER TABLE decisions ADD COLUMN decision_type TINYINT UNSIGNED NOT NULL DEFAULT 1;
+UPDATE decisions SET decision_type = 2 WHERE liked_recipient;
+
+-- Recipient-side listings show super-likes first, so the recipient indexes sort by type before
+-- the actor.
+DROP INDEX idx_decisions_recipient_liked_seen ON decisions;
+DROP INDEX idx_decisions_recipient_liked ON decisions;
+CREATE INDEX idx_decisions_recipient_liked
+    ON decisions (recipient_user_id, liked_recipient, decision_type DESC, actor_user_id, last_modified, seen_by_recipient, created_at);
+CREATE INDEX idx_decisions_recipient_liked_seen
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, decision_type DESC, actor_user_id, last_modified, created_at);
@@ -0,0 +1,15 @@
+-- Previous versions of the decisions, so their last change can be undone. A version is pushed when a
+-- decision changes its type, and popped when the change is undone.
+CREATE TABLE decision_history
+(
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    actor_user_id VARCHAR(10) NOT NULL,
+    recipient_user_id VARCHAR(10) NOT NULL,
+    liked_recipient BOOLEAN NOT NULL,
+    last_modified INT(11) NOT NULL,
+    seen_by_recipient BOOLEAN NOT NULL,
+    created_at INT(11) NOT NULL,
+    decision_type TINYINT UNSIGNED NOT NULL,
+    CONSTRAINT PK_decision_history PRIMARY KEY (id),
+    INDEX idx_decision_history_decision (actor_user_id, recipient_user_id, id)
+);
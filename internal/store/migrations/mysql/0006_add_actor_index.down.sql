@@ -0,0 +1 @@
+DROP INDEX idx_decisions_actor_modified ON decisions;
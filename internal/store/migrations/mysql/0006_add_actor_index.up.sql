@@ -0,0 +1,5 @@
+-- Actor-side listings show the newest decisions first, optionally within a time range, so they are
+-- served by an index sorted by modification time after the actor. It covers the rest of the columns
+-- to filter by type without reading the rows.
+CREATE INDEX idx_decisions_actor_modified
+    ON decisions (actor_user_id, last_modified DESC, recipient_user_id, decision_type, liked_recipient, seen_by_recipient, created_at);
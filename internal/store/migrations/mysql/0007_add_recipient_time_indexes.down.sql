@@ -0,0 +1,2 @@
+DROP INDEX idx_decisions_recipient_liked_seen_modified ON decisions;
+DROP INDEX idx_decisions_recipient_liked_modified ON decisions;
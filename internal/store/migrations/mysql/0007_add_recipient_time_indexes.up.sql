@@ -0,0 +1,6 @@
+-- Recipient-side listings sorted by time, oldest or newest first, read these indexes forwards or
+-- backwards, seeking straight to the start of their time range.
+CREATE INDEX idx_decisions_recipient_liked_modified
+    ON decisions (recipient_user_id, liked_recipient, last_modified, actor_user_id, seen_by_recipient, created_at, decision_type);
+CREATE INDEX idx_decisions_recipient_liked_seen_modified
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, last_modified, actor_user_id, created_at, decision_type);
@@ -0,0 +1,24 @@
+-- The time a decision became a like, kept while it stays one, or zero if it's not a like. Matches
+-- date from the later like of the pair, which upgrading a like to a super-like doesn't change. Likes
+-- made before it was tracked take their modification time as their best known like time.
+ALTER TABLE decisions ADD COLUMN liked_at INT NOT NULL DEFAULT 0;
+UPDATE decisions SET liked_at = last_modified WHERE liked_recipient;
+ALTER TABLE decision_history ADD COLUMN liked_at INT NOT NULL DEFAULT 0;
+UPDATE decision_history SET liked_at = last_modified WHERE liked_recipient;
+
+-- The listings read every column of the decisions, so their covering indexes carry the new one.
+DROP INDEX idx_decisions_actor_modified ON decisions;
+DROP INDEX idx_decisions_recipient_liked_seen_modified ON decisions;
+DROP INDEX idx_decisions_recipient_liked_modified ON decisions;
+DROP INDEX idx_decisions_recipient_liked_seen ON decisions;
+DROP INDEX idx_decisions_recipient_liked ON decisions;
+CREATE INDEX idx_decisions_recipient_liked
+    ON decisions (recipient_user_id, liked_recipient, decision_type DESC, actor_user_id, last_modified, seen_by_recipient, created_at, liked_at);
+CREATE INDEX idx_decisions_recipient_liked_seen
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, decision_type DESC, actor_user_id, last_modified, created_at, liked_at);
+CREATE INDEX idx_decisions_recipient_liked_modified
+    ON decisions (recipient_user_id, liked_recipient, last_modified, actor_user_id, seen_by_recipient, created_at, decision_type, liked_at);
+CREATE INDEX idx_decisions_recipient_liked_seen_modified
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, last_modified, actor_user_id, created_at, decision_type, liked_at);
+CREATE INDEX idx_decisions_actor_modified
+    ON decisions (actor_user_id, last_modified DESC, recipient_user_id, decision_type, liked_recipient, seen_by_recipient, created_at, liked_at);
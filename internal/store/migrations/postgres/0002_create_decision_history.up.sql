@@ -0,0 +1,15 @@
+-- Previous versions of the decisions, so their last change can be undone. A version is pushed when a
+-- decision changes its type, and popped when the change is undone.
+CREATE TABLE decision_history
+(
+    id BIGINT GENERATED ALWAYS AS IDENTITY,
+    actor_user_id VARCHAR(10) COLLATE "C" NOT NULL,
+    recipient_user_id VARCHAR(10) COLLATE "C" NOT NULL,
+    liked_recipient BOOLEAN NOT NULL,
+    last_modified BIGINT NOT NULL,
+    seen_by_recipient BOOLEAN NOT NULL,
+    created_at BIGINT NOT NULL,
+    decision_type SMALLINT NOT NULL,
+    CONSTRAINT pk_decision_history PRIMARY KEY (id)
+);
+CREATE INDEX idx_decision_history_decision ON decision_history (actor_user_id, recipient_user_id, id);
@@ -0,0 +1,27 @@
+-- Same schema as the MySQL one, at its latest version. User IDs are compared byte by byte (the
+-- default BINARY collation), as in the PostgreSQL and in-memory stores; MySQL's default collation is
+-- case-insensitive instead. The decisions are clustered by their primary key, as in InnoDB.
+CREATE TABLE decisions
+(
+    actor_user_id TEXT NOT NULL,
+    recipient_user_id TEXT NOT NULL,
+    liked_recipient BOOLEAN NOT NULL,
+    last_modified INTEGER NOT NULL,
+    seen_by_recipient BOOLEAN NOT NULL,
+    created_at INTEGER NOT NULL,
+    -- Decision types: 1 pass, 2 like, 3 super-like, 4 block. liked_recipient is kept in sync.
+    decision_type INTEGER NOT NULL,
+    PRIMARY KEY (actor_user_id, recipient_user_id)
+) WITHOUT ROWID;
+-- Recipient-side listings, showing super-likes first, or sorted by time.
+CREATE INDEX idx_decisions_recipient_liked
+    ON decisions (recipient_user_id, liked_recipient, decision_type DESC, actor_user_id, last_modified, seen_by_recipient, created_at);
+CREATE INDEX idx_decisions_recipient_liked_seen
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, decision_type DESC, actor_user_id, last_modified, created_at);
+CREATE INDEX idx_decisions_recipient_liked_modified
+    ON decisions (recipient_user_id, liked_recipient, last_modified, actor_user_id, seen_by_recipient, created_at, decision_type);
+CREATE INDEX idx_decisions_recipient_liked_seen_modified
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, last_modified, actor_user_id, created_at, decision_type);
+-- Actor-side listings, showing the newest decisions first.
+CREATE INDEX idx_decisions_actor_modified
+    ON decisions (actor_user_id, last_modified DESC, recipient_user_id, decision_type, liked_recipient, seen_by_recipient, created_at);
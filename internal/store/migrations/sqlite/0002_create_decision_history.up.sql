@@ -0,0 +1,14 @@
+-- Previous versions of the decisions, so their last change can be undone. A version is pushed when a
+-- decision changes its type, and popped when the change is undone.
+CREATE TABLE decision_history
+(
+    id INTEGER PRIMARY KEY,
+    actor_user_id TEXT NOT NULL,
+    recipient_user_id TEXT NOT NULL,
+    liked_recipient BOOLEAN NOT NULL,
+    last_modified INTEGER NOT NULL,
+    seen_by_recipient BOOLEAN NOT NULL,
+    created_at INTEGER NOT NULL,
+    decision_type INTEGER NOT NULL
+);
+CREATE INDEX idx_decision_history_decision ON decision_history (actor_user_id, recipient_user_id, id);
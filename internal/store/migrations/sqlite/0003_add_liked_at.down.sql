@@ -0,0 +1,17 @@
+DROP INDEX idx_decisions_actor_modified;
+DROP INDEX idx_decisions_recipient_liked_seen_modified;
+DROP INDEX idx_decisions_recipient_liked_modified;
+DROP INDEX idx_decisions_recipient_liked_seen;
+DROP INDEX idx_decisions_recipient_liked;
+CREATE INDEX idx_decisions_recipient_liked
+    ON decisions (recipient_user_id, liked_recipient, decision_type DESC, actor_user_id, last_modified, seen_by_recipient, created_at);
+CREATE INDEX idx_decisions_recipient_liked_seen
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, decision_type DESC, actor_user_id, last_modified, created_at);
+CREATE INDEX idx_decisions_recipient_liked_modified
+    ON decisions (recipient_user_id, liked_recipient, last_modified, actor_user_id, seen_by_recipient, created_at, decision_type);
+CREATE INDEX idx_decisions_recipient_liked_seen_modified
+    ON decisions (recipient_user_id, liked_recipient, seen_by_recipient, last_modified, actor_user_id, created_at, decision_type);
+CREATE INDEX idx_decisions_actor_modified
+    ON decisions (actor_user_id, last_modified DESC, recipient_user_id, decision_type, liked_recipient, seen_by_recipient, created_at);
+ALTER TABLE decision_history DROP COLUMN liked_at;
+ALTER TABLE decisions DROP COLUMN liked_at;
@@ -1,18 +1,79 @@
 // General type definitions that any store implementation would use.
 package store
 
+// DecisionType is the kind of decision of an actor on a recipient.
+type DecisionType uint8
+
+const (
+	DecisionPass DecisionType = iota + 1
+	DecisionLike
+	DecisionSuperLike
+	DecisionBlock // Hides both users from each other.
+)
+
+// Liked tells if the decision is a like, of any kind.
+func (t DecisionType) Liked() bool {
+	return t == DecisionLike || t == DecisionSuperLike
+}
+
 type Decision struct {
 	ActorUserID     string
 	RecipientUserID string
-	LikedRecipient  bool
-	LastModified    int64
+	LikedRecipient  bool  // Kept in sync with Type, true for any kind of like.
+	LastModified    int64 // Time of the last change of the decision.
 	SeenByRecipient bool
+	CreatedAt       int64 // Time of the first decision of the actor on the recipient.
+	Type            DecisionType
+	LikedAt         int64 // Time the decision became a like, kept while it stays one, zero if it's not a like.
+}
+
+// DecisionKey identifies a decision.
+type DecisionKey struct {
+	ActorUserID     string
+	RecipientUserID string
+}
+
+func (d Decision) Key() DecisionKey {
+	return DecisionKey{ActorUserID: d.ActorUserID, RecipientUserID: d.RecipientUserID}
 }
 
+// DecisionSort is the order decisions are listed in.
+type DecisionSort uint8
+
+const (
+	// SortDefault follows the index serving the filter: super-likes first and then by actor for the
+	// likes of a recipient, newest first for the decisions of an actor.
+	SortDefault DecisionSort = iota
+	SortNewest
+	SortOldest
+)
+
 type DecisionFilter struct {
 	ActorUserID     *string
 	RecipientUserID *string
 	LikedRecipient  *bool
 	LastModified    *uint64
 	SeenByRecipient *bool
+	// Types leaves out the decisions of any other type, when not empty.
+	Types []DecisionType
+	// ModifiedSince and ModifiedUntil leave out the decisions last changed before the first, or at or
+	// after the second.
+	ModifiedSince *int64
+	ModifiedUntil *int64
+	// Sort is the order of the listings of the likes of a recipient. It's ignored by counts.
+	Sort DecisionSort
+	// PageSize is the maximum number of decisions listed per page, or the default of the store if
+	// zero. It's ignored by counts.
+	PageSize int
+	// ExcludeReciprocated leaves out the decisions whose recipient liked the actor in return.
+	ExcludeReciprocated bool
+	// ExcludeBlocked leaves out the decisions whose recipient blocked the actor.
+	ExcludeBlocked bool
+}
+
+// Match is a pair of users that liked each other, seen from the side of one of them.
+type Match struct {
+	UserID        string
+	PartnerUserID string
+	MatchedAt     int64 // Time of the like that completed the match, the later LikedAt of the pair.
 }
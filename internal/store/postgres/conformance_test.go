@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlstore"
+	"muzz-explore/internal/store/storetest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDSNEnv holds the URL of a PostgreSQL server where the tests can create scratch databases, like
+// "postgres://postgres@localhost:5432/postgres?sslmode=disable". The tests are skipped when it's not
+// set.
+const testDSNEnv = "EXPLORE_TEST_POSTGRES_DSN"
+
+// TestConformance runs the store conformance suite against a real PostgreSQL server, on a scratch
+// database per subtest.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", testDSNEnv)
+	}
+	storetest.Run(t, func(t *testing.T) storetest.Store {
+		return sqlstore.New(newScratchDatabase(t, dsn), dialect)
+	})
+}
+
+// scratchDatabases counts the scratch databases created, to name them apart.
+var scratchDatabases atomic.Int64
+
+// newScratchDatabase creates an empty database with the schema up to date, dropped at the end of the test.
+func newScratchDatabase(t *testing.T, dsn string) *sql.DB {
+	serverURL, err := url.Parse(dsn)
+	require.NoError(t, err)
+	server, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+
+	name := fmt.Sprintf("explore_test_%d_%d", os.Getpid(), scratchDatabases.Add(1))
+	_, err = server.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = server.Exec("DROP DATABASE " + name + " WITH (FORCE)") })
+
+	serverURL.Path = name
+	db, err := sql.Open("postgres", serverURL.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	migrator, err := migrations.NewPostgres(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// PostgreSQL error codes translated into store errors.
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	errUniqueViolation        pq.ErrorCode = "23505"
+	errDeadlockDetected       pq.ErrorCode = "40P01"
+	errSerializationFailure   pq.ErrorCode = "40001"
+	errLockNotAvailable       pq.ErrorCode = "55P03"
+	errTooManyConnections     pq.ErrorCode = "53300"
+	errAdminShutdown          pq.ErrorCode = "57P01"
+	errCrashShutdown          pq.ErrorCode = "57P02"
+	errCannotConnectNow       pq.ErrorCode = "57P03"
+	errReadOnlySQLTransaction pq.ErrorCode = "25006" // i.e., the server is a read only replica.
+	errQueryCanceled          pq.ErrorCode = "57014" // i.e., the statement timed out.
+)
+
+// translateError wraps an error coming from the PostgreSQL driver with the store error it corresponds
+// to, keeping the original one for logging. Unknown errors are returned as they are.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sentinel error
+	var pqErr *pq.Error
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		sentinel = store.ErrDeadline
+	case errors.Is(err, sql.ErrNoRows):
+		sentinel = store.ErrNotFound
+	case errors.Is(err, driver.ErrBadConn), errors.As(err, &netErr):
+		sentinel = store.ErrUnavailable
+	case errors.As(err, &pqErr):
+		switch pqErr.Code {
+		case errUniqueViolation, errDeadlockDetected, errSerializationFailure:
+			sentinel = store.ErrConflict
+		case errLockNotAvailable, errTooManyConnections, errAdminShutdown, errCrashShutdown,
+			errCannotConnectNow, errReadOnlySQLTransaction:
+			sentinel = store.ErrUnavailable
+		case errQueryCanceled:
+			sentinel = store.ErrDeadline
+		}
+	}
+	if sentinel == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
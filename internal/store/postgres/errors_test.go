@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	testMap := map[string]struct {
+		err  error
+		want error
+	}{
+		"no error": {
+			err:  nil,
+			want: nil,
+		},
+		"no rows": {
+			err:  sql.ErrNoRows,
+			want: store.ErrNotFound,
+		},
+		"unique violation": {
+			err:  &pq.Error{Code: "23505"},
+			want: store.ErrConflict,
+		},
+		"deadlock": {
+			err:  &pq.Error{Code: "40P01"},
+			want: store.ErrConflict,
+		},
+		"serialization failure": {
+			err:  &pq.Error{Code: "40001"},
+			want: store.ErrConflict,
+		},
+		"too many connections": {
+			err:  &pq.Error{Code: "53300"},
+			want: store.ErrUnavailable,
+		},
+		"read only replica": {
+			err:  &pq.Error{Code: "25006"},
+			want: store.ErrUnavailable,
+		},
+		"statement timeout": {
+			err:  &pq.Error{Code: "57014"},
+			want: store.ErrDeadline,
+		},
+		"bad connection": {
+			err:  driver.ErrBadConn,
+			want: store.ErrUnavailable,
+		},
+		"context deadline": {
+			err:  fmt.Errorf("waiting for connection: %w", context.DeadlineExceeded),
+			want: store.ErrDeadline,
+		},
+	}
+
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			got := translateError(tc.err)
+			if tc.want == nil {
+				assert.NoError(t, got)
+				return
+			}
+			assert.ErrorIs(t, got, tc.want)
+			assert.ErrorIs(t, got, tc.err)
+		})
+	}
+
+	t.Run("unknown error", func(t *testing.T) {
+		err := &pq.Error{Code: "42601"}
+		got := translateError(err)
+		assert.Equal(t, err, got)
+		for _, sentinel := range []error{
+			store.ErrNotFound, store.ErrConflict, store.ErrUnavailable, store.ErrInvalidCursor, store.ErrDeadline,
+		} {
+			assert.False(t, errors.Is(got, sentinel))
+		}
+	})
+}
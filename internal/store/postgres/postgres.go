@@ -0,0 +1,45 @@
+// This file contains the database implementation on PostgreSQL, behaving as the MySQL one.
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlquery"
+	"muzz-explore/internal/store/sqlstore"
+	"net"
+	"net/url"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// dialect is the PostgreSQL dialect of the SQL store. Statements are built with numbered placeholders,
+// and subqueries with the default question marks, which are numbered along with the rest of the
+// statement. ON CONFLICT can't update the same row twice in a statement, and PostgreSQL doesn't sort
+// nor limit deletes.
+var dialect = sqlstore.Dialect{
+	Builder:          sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	UpsertSuffix:     sqlquery.OnConflictUpsertSuffix,
+	UpsertOncePerRow: true,
+	LockSuffix:       "FOR UPDATE",
+	Greatest:         "GREATEST",
+	PopHistory:       sqlstore.PopHistoryBySubquery,
+	TranslateError:   translateError,
+	NewMigrator:      migrations.NewPostgres,
+}
+
+// NewClient connects to a PostgreSQL database. TLS is left off, as the MySQL client does.
+func NewClient(user, pass, address, port, dbname string) (*sqlstore.Store, func() error, error) {
+	connURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, pass),
+		Host:     net.JoinHostPort(address, port),
+		Path:     dbname,
+		RawQuery: "sslmode=disable",
+	}
+	db, err := sql.Open("postgres", connURL.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return sqlstore.New(db, dialect), db.Close, nil
+}
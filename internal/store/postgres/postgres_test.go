@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/sqlstore"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func ref[T any](t T) *T { return &t }
+
+var columns = []string{"actor_user_id", "recipient_user_id", "liked_recipient", "last_modified", "seen_by_recipient", "created_at", "decision_type", "liked_at"}
+
+const upsertDecisionSuffixQuery = "ON CONFLICT (actor_user_id,recipient_user_id) DO UPDATE SET " +
+	"seen_by_recipient=CASE WHEN NOT decisions.liked_recipient AND EXCLUDED.liked_recipient OR decisions.decision_type<>3 AND EXCLUDED.decision_type=3 THEN FALSE ELSE decisions.seen_by_recipient END," +
+	"last_modified=CASE WHEN decisions.decision_type=EXCLUDED.decision_type THEN decisions.last_modified ELSE EXCLUDED.last_modified END," +
+	"liked_at=CASE WHEN decisions.liked_recipient AND EXCLUDED.liked_recipient THEN decisions.liked_at ELSE EXCLUDED.liked_at END," +
+	"liked_recipient=EXCLUDED.liked_recipient," +
+	"decision_type=EXCLUDED.decision_type"
+
+type pgTestSuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+}
+
+func TestPostgres(t *testing.T) {
+	suite.Run(t, &pgTestSuite{})
+}
+
+func (s *pgTestSuite) BeforeTest(_, _ string) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(s.T(), err)
+	s.db = db
+	s.mock = mock
+}
+
+func (s *pgTestSuite) Test_ListDecisionsWithPage() {
+	// GIVEN database set up with the next page of the unseen likes of a recipient, newest first.
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions "+
+		"WHERE recipient_user_id=$1 AND liked_recipient=$2 AND seen_by_recipient=$3 AND "+
+		"NOT EXISTS (SELECT 1 FROM decisions b WHERE b.actor_user_id=decisions.recipient_user_id AND b.recipient_user_id=decisions.actor_user_id AND b.decision_type=$4) AND "+
+		"(last_modified,actor_user_id)<($5,$6) ORDER BY last_modified DESC,actor_user_id DESC LIMIT 5").
+		WithArgs("recipient", true, false, store.DecisionBlock, "200", "actor2").
+		WillReturnRows(s.mock.NewRows(columns).AddRow("actor1", "recipient", true, 100, false, 100, 3, 100))
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListDecisions is called with the page token of the previous page.
+	got, gotPage, err := db.ListDecisions(context.Background(), store.DecisionFilter{
+		RecipientUserID: ref("recipient"),
+		LikedRecipient:  ref(true),
+		SeenByRecipient: ref(false),
+		ExcludeBlocked:  true,
+		Sort:            store.SortNewest,
+		PageSize:        5,
+	}, `["newest","200","actor2"]`)
+
+	// THEN the placeholders are numbered, and the page token has the same format as the MySQL store.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Decision{
+		{
+			ActorUserID:     "actor1",
+			RecipientUserID: "recipient",
+			LikedRecipient:  true,
+			LastModified:    100,
+			CreatedAt:       100,
+			Type:            store.DecisionSuperLike,
+			LikedAt:         100,
+		},
+	}, got)
+	assert.Equal(s.T(), `["newest","100","actor1"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *pgTestSuite) Test_CountDecisions() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery("SELECT COUNT(*) FROM decisions WHERE actor_user_id=$1 AND decision_type IN ($2,$3) AND last_modified>=$4 AND last_modified<$5").
+		WithArgs("actor", store.DecisionLike, store.DecisionSuperLike, 100, 200).
+		WillReturnRows(s.mock.NewRows([]string{"count"}).AddRow(3))
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN CountDecisions is called with some types within a time range.
+	got, err := db.CountDecisions(context.Background(), store.DecisionFilter{
+		ActorUserID:   ref("actor"),
+		Types:         []store.DecisionType{store.DecisionLike, store.DecisionSuperLike},
+		ModifiedSince: ref(int64(100)),
+		ModifiedUntil: ref(int64(200)),
+	})
+
+	// THEN the expectations are met and the count is returned.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), uint64(3), got)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *pgTestSuite) Test_UpsertDecisions() {
+	// GIVEN database set up to lock the existing decisions in key order, record the history of the
+	// ones changing, insert the new ones, and then upsert the others in two rounds, as two of them are
+	// on the same pair.
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(
+		"SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions "+
+			"WHERE (actor_user_id,recipient_user_id) IN (($1,$2),($3,$4),($5,$6)) ORDER BY actor_user_id, recipient_user_id FOR UPDATE").
+		WithArgs("actor", "recipient2", "actor", "recipient1", "actor", "recipient1").
+		WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient1", false, 100, true, 100, 1, 0))
+	s.mock.ExpectExec(
+		"INSERT INTO decision_history (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient,created_at,decision_type,liked_at) "+
+			"VALUES ($1,$2,$3,$4,$5,$6,$7,$8)").
+		WithArgs("actor", "recipient1", false, 100, true, 100, store.DecisionPass, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(
+		"INSERT INTO decisions (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient,created_at,decision_type,liked_at) "+
+			"VALUES ($1,$2,$3,$4,$5,$6,$7,$8)").
+		WithArgs("actor", "recipient2", true, 123, false, 123, store.DecisionSuperLike, 123).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	for _, decision := range []struct {
+		decisionType store.DecisionType
+		likedAt      int
+	}{{store.DecisionPass, 0}, {store.DecisionLike, 123}} {
+		s.mock.ExpectExec(
+			"INSERT INTO decisions (actor_user_id,recipient_user_id,liked_recipient,last_modified,seen_by_recipient,created_at,decision_type,liked_at) "+
+				"VALUES ($1,$2,$3,$4,$5,$6,$7,$8) "+upsertDecisionSuffixQuery).
+			WithArgs("actor", "recipient1", decision.decisionType.Liked(), 123, false, 123, decision.decisionType, decision.likedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	s.mock.ExpectCommit()
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN UpsertDecisions is called, with two decisions on the same recipient.
+	got, err := db.UpsertDecisions(context.Background(), []store.Decision{
+		{ActorUserID: "actor", RecipientUserID: "recipient2", LikedRecipient: true, LastModified: 123, CreatedAt: 123, Type: store.DecisionSuperLike, LikedAt: 123},
+		{ActorUserID: "actor", RecipientUserID: "recipient1", LikedRecipient: false, LastModified: 123, CreatedAt: 123, Type: store.DecisionPass},
+		{ActorUserID: "actor", RecipientUserID: "recipient1", LikedRecipient: true, LastModified: 123, CreatedAt: 123, Type: store.DecisionLike, LikedAt: 123},
+	})
+
+	// THEN the expectations are met, keeping the order of the decisions on the same recipient, and each
+	// decision replaced the previous one on its recipient.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.DecisionType{0, store.DecisionPass, store.DecisionPass}, got)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *pgTestSuite) Test_UndoDecision() {
+	// GIVEN a like that replaced a pass.
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decisions "+
+		"WHERE (actor_user_id,recipient_user_id) IN (($1,$2)) FOR UPDATE").
+		WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", true, 200, false, 100, 2, 200))
+	s.mock.ExpectQuery("SELECT actor_user_id, recipient_user_id, liked_recipient, last_modified, seen_by_recipient, created_at, decision_type, liked_at FROM decision_history "+
+		"WHERE actor_user_id=$1 AND recipient_user_id=$2 ORDER BY id DESC LIMIT 1 FOR UPDATE").
+		WithArgs("actor", "recipient").
+		WillReturnRows(s.mock.NewRows(columns).AddRow("actor", "recipient", false, 100, true, 100, 1, 0))
+	s.mock.ExpectExec("UPDATE decisions SET liked_recipient = $1, last_modified = $2, seen_by_recipient = $3, created_at = $4, decision_type = $5, liked_at = $6 "+
+		"WHERE (actor_user_id,recipient_user_id) IN (($7,$8))").
+		WithArgs(false, 100, true, 100, store.DecisionPass, 0, "actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE FROM decision_history WHERE id=(SELECT MAX(id) FROM decision_history WHERE actor_user_id=$1 AND recipient_user_id=$2)").
+		WithArgs("actor", "recipient").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN UndoDecision is called within the window.
+	undone, restored, err := db.UndoDecision(context.Background(), store.DecisionKey{ActorUserID: "actor", RecipientUserID: "recipient"}, 100)
+
+	// THEN the pass is restored, and its version removed from the history.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), store.Decision{ActorUserID: "actor", RecipientUserID: "recipient", LikedRecipient: true, LastModified: 200, CreatedAt: 100, Type: store.DecisionLike, LikedAt: 200}, undone)
+	assert.Equal(s.T(), &store.Decision{ActorUserID: "actor", RecipientUserID: "recipient", LastModified: 100, SeenByRecipient: true, CreatedAt: 100, Type: store.DecisionPass}, restored)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func (s *pgTestSuite) Test_Unmatch() {
+	lockLikesQuery := "SELECT actor_user_id FROM decisions WHERE (actor_user_id,recipient_user_id) IN (($1,$2),($3,$4)) AND liked_recipient=$5 FOR UPDATE"
+
+	s.Run("matched", func() {
+		// GIVEN database with the likes of both users on each other.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockLikesQuery).
+			WithArgs("user1", "user2", "user2", "user1", true).
+			WillReturnRows(s.mock.NewRows([]string{"actor_user_id"}).AddRow("user1").AddRow("user2"))
+		s.mock.ExpectExec("DELETE FROM decisions WHERE (actor_user_id,recipient_user_id) IN (($1,$2),($3,$4))").
+			WithArgs("user1", "user2", "user2", "user1").WillReturnResult(sqlmock.NewResult(0, 2))
+		s.mock.ExpectExec("DELETE FROM decision_history WHERE (actor_user_id,recipient_user_id) IN (($1,$2),($3,$4))").
+			WithArgs("user1", "user2", "user2", "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN Unmatch is called.
+		err := db.Unmatch(context.Background(), "user1", "user2")
+
+		// THEN both likes are deleted.
+		require.NoError(s.T(), err)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("not matched", func() {
+		// GIVEN database with only one of the likes.
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(lockLikesQuery).
+			WithArgs("user1", "user2", "user2", "user1", true).
+			WillReturnRows(s.mock.NewRows([]string{"actor_user_id"}).AddRow("user1"))
+		s.mock.ExpectRollback()
+		db := sqlstore.New(s.db, dialect)
+
+		// WHEN Unmatch is called.
+		err := db.Unmatch(context.Background(), "user1", "user2")
+
+		// THEN nothing is deleted, and a not found error is returned.
+		require.ErrorIs(s.T(), err, store.ErrNotFound)
+		assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *pgTestSuite) Test_ListMutualMatchesWithPage() {
+	// GIVEN database set up with some expectations.
+	s.mock.ExpectQuery("SELECT d.recipient_user_id, GREATEST(d.liked_at,r.liked_at) FROM decisions d "+
+		"JOIN decisions r ON r.actor_user_id=d.recipient_user_id AND r.recipient_user_id=d.actor_user_id "+
+		"WHERE d.actor_user_id=$1 AND d.liked_recipient=$2 AND r.liked_recipient=$3 AND d.recipient_user_id>$4 "+
+		"ORDER BY d.recipient_user_id LIMIT 10").
+		WithArgs("user", true, true, "partner1").
+		WillReturnRows(s.mock.NewRows([]string{"recipient_user_id", "matched_at"}).AddRow("partner2", 200))
+	db := sqlstore.New(s.db, dialect)
+
+	// WHEN ListMutualMatches is called with a page token.
+	got, gotPage, err := db.ListMutualMatches(context.Background(), "user", `["partner1"]`)
+
+	// THEN the expectations are met and the next page is listed.
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []store.Match{{UserID: "user", PartnerUserID: "partner2", MatchedAt: 200}}, got)
+	assert.Equal(s.T(), `["partner2"]`, gotPage)
+	assert.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"context"
+	"muzz-explore/internal/store/sqlstore"
+	"muzz-explore/internal/store/storetest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance runs the store conformance suite against SQLite, on a new database file per subtest.
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store {
+		return newTestDatabase(t)
+	})
+}
+
+// newTestDatabase creates a database file with the schema up to date, removed at the end of the test.
+func newTestDatabase(t *testing.T) *sqlstore.Store {
+	db, dbClose, err := NewClient(filepath.Join(t.TempDir(), "explore.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbClose() })
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
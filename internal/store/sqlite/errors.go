@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// translateError wraps an error coming from the SQLite driver with the store error it corresponds to,
+// keeping the original one for logging. Unknown errors are returned as they are.
+// See https://www.sqlite.org/rescode.html
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sentinel error
+	var sqliteErr *sqlitedriver.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		sentinel = store.ErrDeadline
+	case errors.Is(err, sql.ErrNoRows):
+		sentinel = store.ErrNotFound
+	case errors.Is(err, driver.ErrBadConn):
+		sentinel = store.ErrUnavailable
+	case errors.As(err, &sqliteErr):
+		// Extended result codes are checked first, then the primary code in their lowest byte.
+		switch code := sqliteErr.Code(); {
+		case code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, code == sqlite3.SQLITE_CONSTRAINT_UNIQUE,
+			code == sqlite3.SQLITE_BUSY_SNAPSHOT:
+			sentinel = store.ErrConflict
+		case code&0xff == sqlite3.SQLITE_BUSY, code&0xff == sqlite3.SQLITE_LOCKED,
+			code&0xff == sqlite3.SQLITE_READONLY, code&0xff == sqlite3.SQLITE_IOERR,
+			code&0xff == sqlite3.SQLITE_FULL, code&0xff == sqlite3.SQLITE_CANTOPEN:
+			sentinel = store.ErrUnavailable
+		case code == sqlite3.SQLITE_INTERRUPT: // i.e., the context of the statement is done.
+			sentinel = store.ErrDeadline
+		}
+	}
+	if sentinel == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
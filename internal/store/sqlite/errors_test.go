@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	// The SQLite errors can't be built outside of the driver, so they come from a real database.
+	path := filepath.Join(t.TempDir(), "explore.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT UNIQUE); INSERT INTO t VALUES (1, 'a')")
+	require.NoError(t, err)
+	readOnly, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = readOnly.Close() })
+
+	driverError := func(db *sql.DB, query string) error {
+		_, err := db.Exec(query)
+		require.Error(t, err)
+		return err
+	}
+	testMap := map[string]struct {
+		err  error
+		want error
+	}{
+		"no error": {
+			err:  nil,
+			want: nil,
+		},
+		"no rows": {
+			err:  sql.ErrNoRows,
+			want: store.ErrNotFound,
+		},
+		"primary key violation": {
+			err:  driverError(db, "INSERT INTO t VALUES (1, 'b')"),
+			want: store.ErrConflict,
+		},
+		"unique violation": {
+			err:  driverError(db, "INSERT INTO t VALUES (2, 'a')"),
+			want: store.ErrConflict,
+		},
+		"read only database": {
+			err:  driverError(readOnly, "INSERT INTO t VALUES (2, 'b')"),
+			want: store.ErrUnavailable,
+		},
+		"interrupted statement": {
+			err:  interruptedQuery(t, db),
+			want: store.ErrDeadline,
+		},
+		"bad connection": {
+			err:  driver.ErrBadConn,
+			want: store.ErrUnavailable,
+		},
+		"context deadline": {
+			err:  fmt.Errorf("waiting for connection: %w", context.DeadlineExceeded),
+			want: store.ErrDeadline,
+		},
+	}
+
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			got := translateError(tc.err)
+			if tc.want == nil {
+				assert.NoError(t, got)
+				return
+			}
+			assert.ErrorIs(t, got, tc.want)
+			assert.ErrorIs(t, got, tc.err)
+		})
+	}
+
+	t.Run("unknown error", func(t *testing.T) {
+		err := driverError(db, "SELEC 1")
+		got := translateError(err)
+		assert.Equal(t, err, got)
+		for _, sentinel := range []error{
+			store.ErrNotFound, store.ErrConflict, store.ErrUnavailable, store.ErrInvalidCursor, store.ErrDeadline,
+		} {
+			assert.False(t, errors.Is(got, sentinel))
+		}
+	})
+}
+
+// interruptedQuery runs a query that never ends until its context is done, and returns its error.
+func interruptedQuery(t *testing.T, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var n int64
+	err := db.QueryRowContext(ctx,
+		"WITH RECURSIVE c(n) AS (SELECT 1 UNION ALL SELECT n+1 FROM c) SELECT MAX(n) FROM c").Scan(&n)
+	require.Error(t, err)
+	return err
+}
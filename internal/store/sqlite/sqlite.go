@@ -0,0 +1,44 @@
+// This file contains the database implementation on an embedded SQLite file, behaving as the MySQL
+// one, so the service can run from a single binary.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlquery"
+	"muzz-explore/internal/store/sqlstore"
+	"net/url"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// busyTimeout is how long a statement waits for the write lock before failing, in milliseconds.
+const busyTimeout = 5000
+
+// dialect is the SQLite dialect of the SQL store. SQLite has no row locks, nor GREATEST, but its MAX
+// takes several arguments, and it only sorts and limits deletes when built to.
+var dialect = sqlstore.Dialect{
+	Builder:        sq.StatementBuilder,
+	UpsertSuffix:   sqlquery.OnConflictUpsertSuffix,
+	Greatest:       "MAX",
+	PopHistory:     sqlstore.PopHistoryBySubquery,
+	TranslateError: translateError,
+	NewMigrator:    migrations.NewSQLite,
+}
+
+// NewClient opens the SQLite database in the file at path, creating it if needed. It runs in WAL mode,
+// so readers don't block the writer, and its transactions take the write lock when they begin, as
+// SQLite has no row locks: writers are serialized instead of failing when they upgrade their lock.
+func NewClient(path string) (*sqlstore.Store, func() error, error) {
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout))
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	query.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return sqlstore.New(db, dialect), db.Close, nil
+}
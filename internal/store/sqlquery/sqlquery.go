@@ -0,0 +1,359 @@
+// Package sqlquery builds the parts of the queries of the SQL stores where their dialects agree:
+// filters, orderings, page tokens and key lookups. The orderings and page tokens are also used by the
+// in-memory store. Conditions are written with question mark
+// placeholders, and the statements are built with the statement builder of each store, which numbers
+// them if its dialect needs it.
+package sqlquery
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"muzz-explore/internal/store"
+	"slices"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	// PageLength is the number of decisions listed per page, unless the filter sets another one.
+	PageLength = 10
+
+	// OnConflictUpsertSuffix turns an insert into the upsert of the decisions, in the dialects with
+	// ON CONFLICT clauses. It keeps the first decision time on re-submissions, and only changes the
+	// modification time when the type of decision changes. The like becomes new again when it flips
+	// from not liked to liked, or is upgraded to a super-like (type 3), but its like time is kept
+	// while it stays a like. Every assignment sees the previous values of the row, so they can go in
+	// any order.
+	OnConflictUpsertSuffix = "ON CONFLICT (actor_user_id,recipient_user_id) DO UPDATE SET " +
+		"seen_by_recipient=CASE WHEN NOT decisions.liked_recipient AND EXCLUDED.liked_recipient OR decisions.decision_type<>3 AND EXCLUDED.decision_type=3 THEN FALSE ELSE decisions.seen_by_recipient END," +
+		"last_modified=CASE WHEN decisions.decision_type=EXCLUDED.decision_type THEN decisions.last_modified ELSE EXCLUDED.last_modified END," +
+		"liked_at=CASE WHEN decisions.liked_recipient AND EXCLUDED.liked_recipient THEN decisions.liked_at ELSE EXCLUDED.liked_at END," +
+		"liked_recipient=EXCLUDED.liked_recipient," +
+		"decision_type=EXCLUDED.decision_type"
+
+	// notReciprocatedCondition is the anti-join leaving out the decisions whose recipient liked the
+	// actor back, looking up the reverse decision by its primary key.
+	notReciprocatedCondition = "NOT EXISTS (SELECT 1 FROM decisions r WHERE " +
+		"r.actor_user_id=decisions.recipient_user_id AND r.recipient_user_id=decisions.actor_user_id AND " +
+		"r.liked_recipient=?)"
+
+	// notBlockedCondition is the anti-join leaving out the decisions whose recipient blocked the
+	// actor (type 4), looking up the reverse decision by its primary key.
+	notBlockedCondition = "NOT EXISTS (SELECT 1 FROM decisions b WHERE " +
+		"b.actor_user_id=decisions.recipient_user_id AND b.recipient_user_id=decisions.actor_user_id AND " +
+		"b.decision_type=?)"
+)
+
+// DecisionColumns are the columns of a decision, in the order they are scanned.
+var DecisionColumns = []string{
+	"actor_user_id",
+	"recipient_user_id",
+	"liked_recipient",
+	"last_modified",
+	"seen_by_recipient",
+	"created_at",
+	"decision_type",
+	"liked_at",
+}
+
+// ListDecisions builds the query listing a page of decisions, sorted by the ordering that lines up
+// with the indexes serving the filter.
+func ListDecisions(
+	builder sq.StatementBuilderType,
+	filter store.DecisionFilter,
+	page string,
+) (sq.SelectBuilder, Ordering, error) {
+	order := OrderingFor(filter)
+	sb := AddFilters(builder.Select(DecisionColumns...).From("decisions"), filter)
+	if page != "" {
+		// Keyset pagination: resume right after the last row returned, comparing the whole sort key
+		// as a row value so no row sharing a prefix with the token is skipped.
+		sortKey, err := order.DecodePageToken(page)
+		if err != nil {
+			return sq.SelectBuilder{}, Ordering{}, err
+		}
+		sb = sb.Where(order.After(sortKey))
+	}
+	pageSize := uint64(PageLength)
+	if filter.PageSize > 0 {
+		pageSize = uint64(filter.PageSize)
+	}
+	return sb.OrderBy(order.OrderBy()).Limit(pageSize), order, nil
+}
+
+// CountDecisions builds the query counting the decisions matching a filter.
+func CountDecisions(builder sq.StatementBuilderType, filter store.DecisionFilter) sq.SelectBuilder {
+	return AddFilters(builder.Select("COUNT(*)").From("decisions"), filter)
+}
+
+// GetDecisions builds the query getting the decisions with the given keys.
+func GetDecisions(builder sq.StatementBuilderType, keys []store.DecisionKey) sq.SelectBuilder {
+	return builder.Select(DecisionColumns...).From("decisions").Where(KeysIn(keys))
+}
+
+// AddFilters adds to a query on the decisions table the conditions of a filter.
+func AddFilters(sb sq.SelectBuilder, filter store.DecisionFilter) sq.SelectBuilder {
+	if filter.ActorUserID != nil {
+		sb = sb.Where("actor_user_id=?", *filter.ActorUserID)
+	}
+	if filter.RecipientUserID != nil {
+		sb = sb.Where("recipient_user_id=?", *filter.RecipientUserID)
+	}
+	if filter.LikedRecipient != nil {
+		sb = sb.Where("liked_recipient=?", *filter.LikedRecipient)
+	}
+	if filter.LastModified != nil {
+		sb = sb.Where("last_modified=?", *filter.LastModified)
+	}
+	if filter.SeenByRecipient != nil {
+		sb = sb.Where("seen_by_recipient=?", *filter.SeenByRecipient)
+	}
+	if len(filter.Types) > 0 {
+		sb = sb.Where(sq.Eq{"decision_type": filter.Types})
+	}
+	if filter.ModifiedSince != nil {
+		sb = sb.Where("last_modified>=?", *filter.ModifiedSince)
+	}
+	if filter.ModifiedUntil != nil {
+		sb = sb.Where("last_modified<?", *filter.ModifiedUntil)
+	}
+	if filter.ExcludeReciprocated {
+		sb = sb.Where(notReciprocatedCondition, true)
+	}
+	if filter.ExcludeBlocked {
+		sb = sb.Where(notBlockedCondition, store.DecisionBlock)
+	}
+
+	return sb
+}
+
+// KeysIn builds a condition matching the decisions with the given keys, comparing them as row values.
+func KeysIn(keys []store.DecisionKey) sq.Sqlizer {
+	placeholders := make([]string, 0, len(keys))
+	args := make([]any, 0, 2*len(keys))
+	for _, key := range keys {
+		placeholders = append(placeholders, "(?,?)")
+		args = append(args, key.ActorUserID, key.RecipientUserID)
+	}
+	return sq.Expr("(actor_user_id,recipient_user_id) IN ("+strings.Join(placeholders, ",")+")", args...)
+}
+
+// ScanDecision scans a row with the DecisionColumns.
+func ScanDecision(row interface{ Scan(dest ...any) error }) (store.Decision, error) {
+	var decision store.Decision
+	err := row.Scan(
+		&decision.ActorUserID,
+		&decision.RecipientUserID,
+		&decision.LikedRecipient,
+		&decision.LastModified,
+		&decision.SeenByRecipient,
+		&decision.CreatedAt,
+		&decision.Type,
+		&decision.LikedAt,
+	)
+	return decision, err
+}
+
+// Ordering is the sort key a listing is paginated by. It's shared with the in-memory store, which
+// sorts the decisions by it in Go, so every store pages in the same order with the same tokens.
+type Ordering struct {
+	// name tags the page tokens of the ordering, so a token can't be used to resume a listing in
+	// another order, even if its sort key has the same length.
+	name    string
+	columns []sortColumn
+}
+
+// sortColumn is a column of a sort key, sorted in ascending order unless desc is set. Numeric columns
+// are compared as numbers, and the rest as strings.
+type sortColumn struct {
+	name    string
+	value   func(store.Decision) string
+	numeric bool
+	desc    bool
+}
+
+var (
+	actorColumn = sortColumn{
+		name:  "actor_user_id",
+		value: func(d store.Decision) string { return d.ActorUserID },
+	}
+	recipientColumn = sortColumn{
+		name:  "recipient_user_id",
+		value: func(d store.Decision) string { return d.RecipientUserID },
+	}
+	modifiedColumn = sortColumn{
+		name:    "last_modified",
+		value:   func(d store.Decision) string { return strconv.FormatInt(d.LastModified, 10) },
+		numeric: true,
+	}
+	typeColumn = sortColumn{
+		name:    "decision_type",
+		value:   func(d store.Decision) string { return strconv.Itoa(int(d.Type)) },
+		numeric: true,
+	}
+)
+
+// OrderingFor returns the ordering to list the decisions matching a filter. Columns fixed by the
+// filter are left out of the sort key, so the rest of it matches the order of the index used:
+// recipient-side listings follow the recipient_user_id indexes, showing super-likes first unless
+// they are sorted by time, and actor-side ones the actor index, showing the newest decisions first.
+func OrderingFor(filter store.DecisionFilter) Ordering {
+	recipientSide := filter.RecipientUserID != nil && filter.ActorUserID == nil
+	switch {
+	case recipientSide && filter.Sort == store.SortNewest:
+		return Ordering{name: "newest", columns: []sortColumn{modifiedColumn.descending(), actorColumn.descending()}}
+	case recipientSide && filter.Sort == store.SortOldest:
+		return Ordering{name: "oldest", columns: []sortColumn{modifiedColumn, actorColumn}}
+	case recipientSide:
+		return Ordering{name: "type", columns: []sortColumn{typeColumn.descending(), actorColumn}}
+	case filter.ActorUserID != nil && filter.RecipientUserID == nil:
+		return Ordering{name: "actor", columns: []sortColumn{modifiedColumn.descending(), recipientColumn}}
+	case filter.ActorUserID != nil:
+		return Ordering{name: "pair", columns: []sortColumn{recipientColumn}}
+	default:
+		return Ordering{name: "all", columns: []sortColumn{actorColumn, recipientColumn}}
+	}
+}
+
+func (c sortColumn) descending() sortColumn {
+	c.desc = true
+	return c
+}
+
+// OrderBy returns the ORDER BY clause of the ordering.
+func (o Ordering) OrderBy() string {
+	columns := make([]string, 0, len(o.columns))
+	for _, column := range o.columns {
+		if column.desc {
+			columns = append(columns, column.name+" DESC")
+		} else {
+			columns = append(columns, column.name)
+		}
+	}
+	return strings.Join(columns, ",")
+}
+
+// After builds the condition matching the rows sorted after the given sort key.
+func (o Ordering) After(sortKey []string) sq.Sqlizer {
+	args := make([]any, 0, len(sortKey))
+	for _, value := range sortKey {
+		args = append(args, value)
+	}
+	if len(o.columns) == 1 {
+		return sq.Expr(o.columns[0].name+o.columns[0].op(), args...)
+	}
+	if !slices.ContainsFunc(o.columns, func(c sortColumn) bool { return c.desc != o.columns[0].desc }) {
+		names := make([]string, 0, len(o.columns))
+		for _, column := range o.columns {
+			names = append(names, column.name)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(o.columns)), ",")
+		op := ">"
+		if o.columns[0].desc {
+			op = "<"
+		}
+		return sq.Expr("("+strings.Join(names, ",")+")"+op+"("+placeholders+")", args...)
+	}
+	// Columns sorted in different directions can't be compared as a row value, so the comparison is
+	// expanded: the first column is after the key, or equal and the rest of the columns are after it.
+	clauses := make([]string, 0, len(o.columns))
+	var clauseArgs []any
+	for i, column := range o.columns {
+		conditions := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, o.columns[j].name+"=?")
+			clauseArgs = append(clauseArgs, args[j])
+		}
+		conditions = append(conditions, column.name+column.op())
+		clauseArgs = append(clauseArgs, args[i])
+		if len(conditions) == 1 {
+			clauses = append(clauses, conditions[0])
+		} else {
+			clauses = append(clauses, "("+strings.Join(conditions, " AND ")+")")
+		}
+	}
+	return sq.Expr("("+strings.Join(clauses, " OR ")+")", clauseArgs...)
+}
+
+// op returns the comparison matching the values sorted after a placeholder.
+func (c sortColumn) op() string {
+	if c.desc {
+		return "<?"
+	}
+	return ">?"
+}
+
+// SortKey returns the values of the sort key of a decision.
+func (o Ordering) SortKey(decision store.Decision) []string {
+	sortKey := make([]string, 0, len(o.columns))
+	for _, column := range o.columns {
+		sortKey = append(sortKey, column.value(decision))
+	}
+	return sortKey
+}
+
+// Compare compares two sort keys, which are known to be valid, in the order of the listing.
+func (o Ordering) Compare(a, b []string) int {
+	for i, column := range o.columns {
+		var c int
+		if column.numeric {
+			x, _ := strconv.ParseInt(a[i], 10, 64)
+			y, _ := strconv.ParseInt(b[i], 10, 64)
+			c = cmp.Compare(x, y)
+		} else {
+			c = cmp.Compare(a[i], b[i])
+		}
+		if column.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// PageToken builds the page token resuming right after a decision, tagged with the name of the
+// ordering.
+func (o Ordering) PageToken(decision store.Decision) string {
+	return EncodePageToken(append([]string{o.name}, o.SortKey(decision)...)...)
+}
+
+// DecodePageToken returns the sort key carried by a page token, checking it was built by the same
+// ordering, and that its numeric values are numbers.
+func (o Ordering) DecodePageToken(page string) ([]string, error) {
+	sortKey, err := DecodePageToken(page, len(o.columns)+1)
+	if err != nil {
+		return nil, err
+	}
+	if sortKey[0] != o.name {
+		return nil, fmt.Errorf("%w: page token %q", store.ErrInvalidCursor, page)
+	}
+	sortKey = sortKey[1:]
+	for i, column := range o.columns {
+		if _, err := strconv.ParseInt(sortKey[i], 10, 64); column.numeric && err != nil {
+			return nil, fmt.Errorf("%w: page token %q", store.ErrInvalidCursor, page)
+		}
+	}
+	return sortKey, nil
+}
+
+// EncodePageToken builds a page token out of the sort key of the last row of a page, as a JSON array
+// of strings, so the values can hold any character.
+func EncodePageToken(sortKey ...string) string {
+	page, _ := json.Marshal(sortKey) // Strings always marshal.
+	return string(page)
+}
+
+// DecodePageToken returns the sort key carried by a page token, checking it has as many values as
+// the ordering in use.
+func DecodePageToken(page string, keyLength int) ([]string, error) {
+	var sortKey []string
+	if err := json.Unmarshal([]byte(page), &sortKey); err != nil || len(sortKey) != keyLength {
+		return nil, fmt.Errorf("%w: page token %q", store.ErrInvalidCursor, page)
+	}
+	return sortKey, nil
+}
@@ -0,0 +1,107 @@
+package sqlquery
+
+import (
+	"muzz-explore/internal/store"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDecisionsPlaceholders(t *testing.T) {
+	// GIVEN: A filter on the likes of a recipient, and the page token of the previous page.
+	liked := true
+	recipient := "r1"
+	filter := store.DecisionFilter{RecipientUserID: &recipient, LikedRecipient: &liked}
+	page := EncodePageToken("type", "3", "a1")
+
+	// WHEN: The query is built with the placeholders of each dialect.
+	question, _, err := ListDecisions(sq.StatementBuilder, filter, page)
+	require.NoError(t, err)
+	dollar, _, err := ListDecisions(sq.StatementBuilder.PlaceholderFormat(sq.Dollar), filter, page)
+	require.NoError(t, err)
+
+	// THEN: Both have the same statement and arguments, only numbering the placeholders differently.
+	questionSQL, questionArgs, err := question.ToSql()
+	require.NoError(t, err)
+	dollarSQL, dollarArgs, err := dollar.ToSql()
+	require.NoError(t, err)
+	numbered, err := sq.Dollar.ReplacePlaceholders(questionSQL)
+	require.NoError(t, err)
+	assert.Equal(t, numbered, dollarSQL)
+	assert.Equal(t, questionArgs, dollarArgs)
+	assert.Contains(t, dollarSQL, "$1")
+}
+
+func TestDecodePageToken(t *testing.T) {
+	testMap := map[string]struct {
+		page    string
+		want    []string
+		wantErr error
+	}{
+		"valid": {
+			page: EncodePageToken("3", "a1"),
+			want: []string{"3", "a1"},
+		},
+		"malformed": {
+			page:    "3##a1",
+			wantErr: store.ErrInvalidCursor,
+		},
+		"too few values": {
+			page:    EncodePageToken("a1"),
+			wantErr: store.ErrInvalidCursor,
+		},
+		"too many values": {
+			page:    EncodePageToken("3", "a1", "r1"),
+			wantErr: store.ErrInvalidCursor,
+		},
+	}
+
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			got, err := DecodePageToken(tc.page, 2)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestOrderingDecodePageToken(t *testing.T) {
+	recipient := "r1"
+	order := OrderingFor(store.DecisionFilter{RecipientUserID: &recipient})
+	testMap := map[string]struct {
+		page    string
+		want    []string
+		wantErr error
+	}{
+		"valid": {
+			page: order.PageToken(store.Decision{ActorUserID: "a1", RecipientUserID: "r1", Type: store.DecisionLike}),
+			want: []string{"2", "a1"},
+		},
+		"token of another ordering": {
+			page:    OrderingFor(store.DecisionFilter{ActorUserID: &recipient}).PageToken(store.Decision{LastModified: 100}),
+			wantErr: store.ErrInvalidCursor,
+		},
+		"not a number": {
+			page:    EncodePageToken("type", "x", "a1"),
+			wantErr: store.ErrInvalidCursor,
+		},
+	}
+
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			got, err := order.DecodePageToken(tc.page)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,580 @@
+// Package sqlstore implements the decisions store on the SQL databases. The statements are the same
+// on all of them, built with the query building of sqlquery, and the few places where their dialects
+// differ are set by each database in its Dialect.
+package sqlstore
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"muzz-explore/internal/store"
+	"muzz-explore/internal/store/migrations"
+	"muzz-explore/internal/store/sqlquery"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+// maxTxAttempts is how many times a transaction is run before giving up on its conflicts.
+const maxTxAttempts = 3
+
+// Dialect is what sets a database apart from the others for the store.
+type Dialect struct {
+	// Builder builds the statements with the placeholders of the database.
+	Builder sq.StatementBuilderType
+	// UpsertSuffix turns the multi-row insert of a batch of decisions into their upsert, following the
+	// rules of Store.UpsertDecision.
+	UpsertSuffix string
+	// UpsertOncePerRow is set when an upsert can't update the same row twice, so the batches with
+	// several decisions on the same pair are written in rounds.
+	UpsertOncePerRow bool
+	// LockSuffix locks the rows read in a transaction until it ends, if the database has row locks.
+	LockSuffix string
+	// TxIsolation is the isolation level of the transactions of the store, or the default one of the
+	// database if zero.
+	TxIsolation sql.IsolationLevel
+	// Greatest is the function returning the largest of its arguments.
+	Greatest string
+	// PopHistory builds the statement removing the last version of a decision from its history.
+	PopHistory func(builder sq.StatementBuilderType, key store.DecisionKey) sq.DeleteBuilder
+	// TranslateError wraps an error of the driver with the store error it corresponds to.
+	TranslateError func(err error) error
+	// NewMigrator creates the migrator of the schema of the database.
+	NewMigrator func(db *sql.DB) (*migrations.Migrator, error)
+}
+
+// Store keeps the decisions in a SQL database.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// New creates a store on a database with the given dialect.
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// Migrator returns the migrator of the schema of the database.
+func (s *Store) Migrator() (*migrations.Migrator, error) {
+	return s.dialect.NewMigrator(s.db)
+}
+
+func (s *Store) ListDecisions(
+	ctx context.Context,
+	filter store.DecisionFilter,
+	page string,
+) ([]store.Decision, string, error) {
+	sb, order, err := sqlquery.ListDecisions(s.dialect.Builder, filter, page)
+	if err != nil {
+		return nil, "", err
+	}
+	results, err := sb.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list decisions: %w", s.dialect.TranslateError(err))
+	}
+	decisions, err := scanRows(results, sqlquery.ScanDecision)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list decisions: %w", s.dialect.TranslateError(err))
+	}
+	numDecisions := len(decisions)
+	if numDecisions == 0 {
+		return decisions, "", nil
+	}
+	return decisions, order.PageToken(decisions[numDecisions-1]), nil
+}
+
+func (s *Store) CountDecisions(ctx context.Context, filter store.DecisionFilter) (uint64, error) {
+	sb := sqlquery.CountDecisions(s.dialect.Builder, filter)
+	var count uint64
+	err := sb.RunWith(s.db).QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count decisions: %w", s.dialect.TranslateError(err))
+	}
+	return count, nil
+}
+
+// UpsertDecision inserts a decision, or updates the existing one of the actor on the recipient. The
+// creation time of existing decisions is kept, as is the like time of the likes that stay likes, and
+// their seen state is only reset when the decision becomes a like, or a super-like. The previous version of a decision changing its type is kept in
+// its history, so the change can be undone. It returns the type the decision had before, or zero if
+// it's new.
+func (s *Store) UpsertDecision(ctx context.Context, decision store.Decision) (store.DecisionType, error) {
+	previous, err := s.upsertDecisions(ctx, []store.Decision{decision})
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert decision: %w", s.dialect.TranslateError(err))
+	}
+	return previous[0], nil
+}
+
+// UpsertDecisions upserts a batch of decisions in a single transaction, or a single insert when they
+// are all new, so they are all written or none is. They are applied in order, following the same rules as UpsertDecision. It returns the type
+// each decision replaced, which is the one of the previous decision of the batch on the same pair, if
+// any.
+func (s *Store) UpsertDecisions(ctx context.Context, decisions []store.Decision) ([]store.DecisionType, error) {
+	if len(decisions) == 0 {
+		return []store.DecisionType{}, nil
+	}
+	previous, err := s.upsertDecisions(ctx, decisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert decisions: %w", s.dialect.TranslateError(err))
+	}
+	return previous, nil
+}
+
+func (s *Store) upsertDecisions(ctx context.Context, decisions []store.Decision) ([]store.DecisionType, error) {
+	keys := make([]store.DecisionKey, 0, len(decisions))
+	for _, decision := range decisions {
+		keys = append(keys, decision.Key())
+	}
+
+	// Most decisions are the first ones of their actors on their recipients, which are inserted in a
+	// single statement, with no transaction, when a plain read finds none of them. The insert fails if
+	// a concurrent decision created one of them in between, and the batch is then upserted as usual.
+	if distinctKeys(keys) {
+		results, err := sqlquery.GetDecisions(s.dialect.Builder, keys).RunWith(s.db).QueryContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := scanRows(results, sqlquery.ScanDecision)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) == 0 {
+			_, err := s.dialect.insertDecisionsQuery(decisions).RunWith(s.db).ExecContext(ctx)
+			if err == nil {
+				return make([]store.DecisionType, len(decisions)), nil
+			}
+			if !errors.Is(s.dialect.TranslateError(err), store.ErrConflict) {
+				return nil, err
+			}
+		}
+	}
+
+	var previous []store.DecisionType
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		// Only the existing decisions are locked, in key order. The missing ones are inserted without an
+		// upsert, so the transaction conflicts, and is run again, if a concurrent one inserts them first.
+		results, err := s.dialect.lock(sqlquery.GetDecisions(s.dialect.Builder, keys).
+			OrderBy("actor_user_id", "recipient_user_id")).RunWith(tx).QueryContext(ctx)
+		if err != nil {
+			return err
+		}
+		existing, err := scanRows(results, sqlquery.ScanDecision)
+		if err != nil {
+			return err
+		}
+		versions := make(map[store.DecisionKey]store.Decision, len(existing))
+		types := make(map[store.DecisionKey]store.DecisionType, len(keys))
+		for _, decision := range existing {
+			versions[decision.Key()] = decision
+			types[decision.Key()] = decision.Type
+		}
+		previous = make([]store.DecisionType, 0, len(decisions))
+		var history, inserts, upserts []store.Decision
+		for _, decision := range decisions {
+			previousType, ok := types[decision.Key()]
+			previous = append(previous, previousType)
+			types[decision.Key()] = decision.Type
+			if !ok {
+				inserts = append(inserts, decision)
+				continue
+			}
+			upserts = append(upserts, decision)
+			// The version stored is pushed to the history once, if the batch changes its type.
+			if version, ok := versions[decision.Key()]; ok && version.Type != decision.Type {
+				history = append(history, version)
+				delete(versions, decision.Key())
+			}
+		}
+
+		if len(history) > 0 {
+			if _, err := s.dialect.recordHistoryQuery(history).RunWith(tx).ExecContext(ctx); err != nil {
+				return err
+			}
+		}
+		if len(inserts) > 0 {
+			if _, err := s.dialect.insertDecisionsQuery(inserts).RunWith(tx).ExecContext(ctx); err != nil {
+				return err
+			}
+		}
+		rounds := [][]store.Decision{upserts}
+		if s.dialect.UpsertOncePerRow {
+			rounds = upsertRounds(upserts)
+		}
+		for _, round := range rounds {
+			if len(round) == 0 {
+				continue
+			}
+			if _, err := s.dialect.upsertDecisionsQuery(round).RunWith(tx).ExecContext(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return previous, err
+}
+
+// UndoDecision reverts the last change of a decision, restoring its previous version, or deleting it
+// if it had none. Decisions last modified before notBefore can't be undone anymore. It returns the
+// decision undone, and the one restored, if any. The versions that can't be restored anymore, as the
+// one after them is out of the window, are deleted from the history on the way.
+func (s *Store) UndoDecision(
+	ctx context.Context,
+	key store.DecisionKey,
+	notBefore int64,
+) (store.Decision, *store.Decision, error) {
+	var undone store.Decision
+	var restored *store.Decision
+	var expired bool
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		undone, err = s.getForUpdate(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if expired = undone.LastModified < notBefore; expired {
+			return s.deleteHistory(ctx, tx, key)
+		}
+		previous, err := sqlquery.ScanDecision(s.dialect.lock(
+			s.dialect.Builder.Select(sqlquery.DecisionColumns...).From("decision_history").
+				Where("actor_user_id=? AND recipient_user_id=?", key.ActorUserID, key.RecipientUserID).
+				OrderBy("id DESC").Limit(1),
+		).RunWith(tx).QueryRowContext(ctx))
+		if errors.Is(err, sql.ErrNoRows) {
+			_, err = s.dialect.deleteDecisionsQuery([]store.DecisionKey{key}).RunWith(tx).ExecContext(ctx)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		restored = &previous
+		if _, err := s.dialect.restoreDecisionQuery(previous).RunWith(tx).ExecContext(ctx); err != nil {
+			return err
+		}
+		if previous.LastModified < notBefore {
+			// The version restored can't be undone, so neither can the ones before it be restored.
+			return s.deleteHistory(ctx, tx, key)
+		}
+		_, err = s.dialect.PopHistory(s.dialect.Builder, key).RunWith(tx).ExecContext(ctx)
+		return err
+	})
+	if err != nil {
+		return store.Decision{}, nil, fmt.Errorf("failed to undo decision: %w", s.dialect.TranslateError(err))
+	}
+	if expired {
+		return store.Decision{}, nil, fmt.Errorf(
+			"failed to undo decision: %w: decision last modified at %d",
+			store.ErrFailedPrecondition,
+			undone.LastModified,
+		)
+	}
+	return undone, restored, nil
+}
+
+// DeleteDecision deletes the decision of the actor on the recipient, along with its history, so it
+// can't be undone. It returns the decision deleted.
+func (s *Store) DeleteDecision(ctx context.Context, key store.DecisionKey) (store.Decision, error) {
+	var deleted store.Decision
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		deleted, err = s.getForUpdate(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		return s.deleteWithHistory(ctx, tx, []store.DecisionKey{key})
+	})
+	if err != nil {
+		return store.Decision{}, fmt.Errorf("failed to delete decision: %w", s.dialect.TranslateError(err))
+	}
+	return deleted, nil
+}
+
+// Unmatch deletes the likes of two matched users on each other, along with their history, so they
+// only match again if both like each other anew.
+func (s *Store) Unmatch(ctx context.Context, userID, partnerUserID string) error {
+	keys := []store.DecisionKey{
+		{ActorUserID: userID, RecipientUserID: partnerUserID},
+		{ActorUserID: partnerUserID, RecipientUserID: userID},
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		// The likes are locked and counted here, as not every database can lock the rows of an
+		// aggregate.
+		results, err := s.dialect.lock(s.dialect.Builder.Select("actor_user_id").From("decisions").
+			Where(sqlquery.KeysIn(keys)).Where("liked_recipient=?", true)).RunWith(tx).QueryContext(ctx)
+		if err != nil {
+			return err
+		}
+		likes, err := scanRows(results, func(row scanner) (string, error) {
+			var actor string
+			err := row.Scan(&actor)
+			return actor, err
+		})
+		if err != nil {
+			return err
+		}
+		if len(likes) != len(keys) {
+			return fmt.Errorf("%w: users %q and %q are not matched", store.ErrNotFound, userID, partnerUserID)
+		}
+		return s.deleteWithHistory(ctx, tx, keys)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unmatch: %w", s.dialect.TranslateError(err))
+	}
+	return nil
+}
+
+// GetDecisions returns the existing decisions with the given keys, in no particular order.
+func (s *Store) GetDecisions(ctx context.Context, keys []store.DecisionKey) ([]store.Decision, error) {
+	if len(keys) == 0 {
+		return []store.Decision{}, nil
+	}
+	results, err := sqlquery.GetDecisions(s.dialect.Builder, keys).RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decisions: %w", s.dialect.TranslateError(err))
+	}
+	decisions, err := scanRows(results, sqlquery.ScanDecision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decisions: %w", s.dialect.TranslateError(err))
+	}
+	return decisions, nil
+}
+
+// ListMutualMatches lists the users that liked userID and were liked back, ordered by partner. They
+// matched when the later of their likes was made. The page token encodes the last partner returned.
+func (s *Store) ListMutualMatches(
+	ctx context.Context,
+	userID string,
+	page string,
+) ([]store.Match, string, error) {
+	sb, err := s.dialect.ListMutualMatchesQuery(userID, page)
+	if err != nil {
+		return nil, "", err
+	}
+	results, err := sb.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list mutual matches: %w", s.dialect.TranslateError(err))
+	}
+	matches, err := scanRows(results, func(row scanner) (store.Match, error) {
+		match := store.Match{UserID: userID}
+		err := row.Scan(&match.PartnerUserID, &match.MatchedAt)
+		return match, err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list mutual matches: %w", s.dialect.TranslateError(err))
+	}
+	numMatches := len(matches)
+	if numMatches == 0 {
+		return matches, "", nil
+	}
+	return matches, sqlquery.EncodePageToken(matches[numMatches-1].PartnerUserID), nil
+}
+
+// MarkDecisionsAsSeen marks exactly the given decisions as seen by their recipients, in a single
+// statement.
+func (s *Store) MarkDecisionsAsSeen(ctx context.Context, keys []store.DecisionKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.dialect.MarkDecisionsAsSeenQuery(keys).RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update decisions: %w", s.dialect.TranslateError(err))
+	}
+	return nil
+}
+
+// getForUpdate gets a decision in a transaction, locking it until the transaction ends.
+func (s *Store) getForUpdate(ctx context.Context, tx *sql.Tx, key store.DecisionKey) (store.Decision, error) {
+	sb := s.dialect.lock(sqlquery.GetDecisions(s.dialect.Builder, []store.DecisionKey{key}))
+	return sqlquery.ScanDecision(sb.RunWith(tx).QueryRowContext(ctx))
+}
+
+// deleteWithHistory deletes some decisions, and their history.
+func (s *Store) deleteWithHistory(ctx context.Context, tx *sql.Tx, keys []store.DecisionKey) error {
+	if _, err := s.dialect.deleteDecisionsQuery(keys).RunWith(tx).ExecContext(ctx); err != nil {
+		return err
+	}
+	return s.deleteHistory(ctx, tx, keys...)
+}
+
+// deleteHistory deletes the whole history of some decisions.
+func (s *Store) deleteHistory(ctx context.Context, tx *sql.Tx, keys ...store.DecisionKey) error {
+	_, err := s.dialect.Builder.Delete("decision_history").Where(sqlquery.KeysIn(keys)).RunWith(tx).ExecContext(ctx)
+	return err
+}
+
+// inTx runs fn in a transaction, committed if fn succeeds and rolled back otherwise. Transactions
+// conflicting with a concurrent one, such as the victims of a deadlock, are run again from scratch
+// up to maxTxAttempts times, so callers don't get the conflict unless the contention persists.
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	var err error
+	for range maxTxAttempts {
+		err = s.runTx(ctx, fn)
+		if !errors.Is(s.dialect.TranslateError(err), store.ErrConflict) {
+			return err
+		}
+		log.Debug().Err(err).Msg("retrying conflicting transaction")
+	}
+	return err
+}
+
+// runTx runs fn in a single transaction, committed if fn succeeds and rolled back otherwise.
+func (s *Store) runTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: s.dialect.TxIsolation})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Warn().Err(rollbackErr).Msg("failed to roll back transaction")
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanner is a row of the results of a query.
+type scanner = interface{ Scan(dest ...any) error }
+
+// scanRows scans all the rows of a query, closing them. Rows that fail to scan are logged and
+// skipped, as we don't want to lose the whole query.
+func scanRows[T any](results *sql.Rows, scan func(row scanner) (T, error)) ([]T, error) {
+	defer results.Close()
+	rows := []T{}
+	for results.Next() {
+		row, err := scan(results)
+		if err != nil {
+			log.Err(err).Msg("failed to scan row")
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, results.Err()
+}
+
+// ListMutualMatchesQuery builds the listing of a page of the mutual matches of a user.
+func (d Dialect) ListMutualMatchesQuery(userID string, page string) (sq.SelectBuilder, error) {
+	sb := d.Builder.Select("d.recipient_user_id", d.Greatest+"(d.liked_at,r.liked_at)").
+		From("decisions d").
+		Join("decisions r ON r.actor_user_id=d.recipient_user_id AND r.recipient_user_id=d.actor_user_id").
+		Where("d.actor_user_id=?", userID).
+		Where("d.liked_recipient=?", true).
+		Where("r.liked_recipient=?", true)
+	if page != "" {
+		pageIDs, err := sqlquery.DecodePageToken(page, 1)
+		if err != nil {
+			return sq.SelectBuilder{}, err
+		}
+		sb = sb.Where("d.recipient_user_id>?", pageIDs[0])
+	}
+	return sb.OrderBy("d.recipient_user_id").Limit(sqlquery.PageLength), nil
+}
+
+// MarkDecisionsAsSeenQuery builds the statement marking some decisions as seen by their recipients.
+func (d Dialect) MarkDecisionsAsSeenQuery(keys []store.DecisionKey) sq.UpdateBuilder {
+	return d.Builder.Update("decisions").Set("seen_by_recipient", true).Where(sqlquery.KeysIn(keys))
+}
+
+// upsertRounds splits a batch into rounds with at most one decision per pair. Running the rounds in
+// order applies the decisions in order.
+func upsertRounds(decisions []store.Decision) [][]store.Decision {
+	var rounds [][]store.Decision
+	upserts := map[store.DecisionKey]int{}
+	for _, decision := range decisions {
+		round := upserts[decision.Key()]
+		upserts[decision.Key()]++
+		if round == len(rounds) {
+			rounds = append(rounds, nil)
+		}
+		rounds[round] = append(rounds[round], decision)
+	}
+	return rounds
+}
+
+// distinctKeys reports whether no two keys are the same.
+func distinctKeys(keys []store.DecisionKey) bool {
+	seen := make(map[store.DecisionKey]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+	}
+	return true
+}
+
+// insertDecisionsQuery builds the multi-row insert of a batch of new decisions. Rows are sorted by
+// key, keeping the order of the decisions on the same pair, so concurrent batches lock rows in the
+// same order instead of deadlocking.
+func (d Dialect) insertDecisionsQuery(decisions []store.Decision) sq.InsertBuilder {
+	sorted := slices.Clone(decisions)
+	slices.SortStableFunc(sorted, func(a, b store.Decision) int {
+		return cmp.Or(
+			cmp.Compare(a.ActorUserID, b.ActorUserID),
+			cmp.Compare(a.RecipientUserID, b.RecipientUserID),
+		)
+	})
+	return withRows(d.Builder.Insert("decisions").Columns(sqlquery.DecisionColumns...), sorted)
+}
+
+// upsertDecisionsQuery builds the multi-row upsert of a batch of decisions, with the rows sorted as
+// in insertDecisionsQuery.
+func (d Dialect) upsertDecisionsQuery(decisions []store.Decision) sq.InsertBuilder {
+	return d.insertDecisionsQuery(decisions).Suffix(d.UpsertSuffix)
+}
+
+// recordHistoryQuery builds the statement pushing some versions of decisions to their history.
+func (d Dialect) recordHistoryQuery(versions []store.Decision) sq.InsertBuilder {
+	return withRows(d.Builder.Insert("decision_history").Columns(sqlquery.DecisionColumns...), versions)
+}
+
+// withRows adds a row with the DecisionColumns of each decision to an insert.
+func withRows(ib sq.InsertBuilder, decisions []store.Decision) sq.InsertBuilder {
+	for _, decision := range decisions {
+		ib = ib.Values(
+			decision.ActorUserID,
+			decision.RecipientUserID,
+			decision.LikedRecipient,
+			decision.LastModified,
+			decision.SeenByRecipient,
+			decision.CreatedAt,
+			decision.Type,
+			decision.LikedAt,
+		)
+	}
+	return ib
+}
+
+// restoreDecisionQuery builds the statement replacing a decision with a previous version of it.
+func (d Dialect) restoreDecisionQuery(decision store.Decision) sq.UpdateBuilder {
+	return d.Builder.Update("decisions").
+		Set("liked_recipient", decision.LikedRecipient).
+		Set("last_modified", decision.LastModified).
+		Set("seen_by_recipient", decision.SeenByRecipient).
+		Set("created_at", decision.CreatedAt).
+		Set("decision_type", decision.Type).
+		Set("liked_at", decision.LikedAt).
+		Where(sqlquery.KeysIn([]store.DecisionKey{decision.Key()}))
+}
+
+func (d Dialect) deleteDecisionsQuery(keys []store.DecisionKey) sq.DeleteBuilder {
+	return d.Builder.Delete("decisions").Where(sqlquery.KeysIn(keys))
+}
+
+// lock adds the LockSuffix to a query read in a transaction, if the database has one.
+func (d Dialect) lock(sb sq.SelectBuilder) sq.SelectBuilder {
+	if d.LockSuffix == "" {
+		return sb
+	}
+	return sb.Suffix(d.LockSuffix)
+}
+
+// PopHistoryBySubquery is the PopHistory of the databases that don't sort nor limit deletes, picking
+// the last version by a subquery.
+func PopHistoryBySubquery(builder sq.StatementBuilderType, key store.DecisionKey) sq.DeleteBuilder {
+	return builder.Delete("decision_history").
+		Where("id=(SELECT MAX(id) FROM decision_history WHERE actor_user_id=? AND recipient_user_id=?)",
+			key.ActorUserID, key.RecipientUserID)
+}
@@ -0,0 +1,712 @@
+// Package storetest contains the conformance suite every store implementation must pass, so they all
+// behave the same as seen from the server. A store runs it from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storetest.Store { return New() })
+//	}
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"muzz-explore/internal/store"
+	"muzz-explore/server"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Store is the contract of the stores, as the server defines it.
+type Store = server.DecisionStore
+
+// Run runs the whole suite, as subtests of t. newStore must return an empty store each time it's
+// called, as every subtest starts from scratch.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s Store)
+	}{
+		{"Upsert", testUpsert},
+		{"UpsertBatch", testUpsertBatch},
+		{"Filters", testFilters},
+		{"Orderings", testOrderings},
+		{"Pagination", testPagination},
+		{"InvalidPageTokens", testInvalidPageTokens},
+		{"GetDecisions", testGetDecisions},
+		{"MarkDecisionsAsSeen", testMarkDecisionsAsSeen},
+		{"UndoDecision", testUndoDecision},
+		{"DeleteDecision", testDeleteDecision},
+		{"Unmatch", testUnmatch},
+		{"ListMutualMatches", testListMutualMatches},
+		{"ConcurrentWriters", testConcurrentWriters},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStore(t))
+		})
+	}
+}
+
+func testUpsert(t *testing.T, s Store) {
+	// GIVEN a store with a pass, already seen by its recipient.
+	ctx := context.Background()
+	key := store.DecisionKey{ActorUserID: "a1", RecipientUserID: "r1"}
+	pass := decision("a1", "r1", store.DecisionPass, 100)
+	pass.SeenByRecipient = true
+	previous, err := s.UpsertDecision(ctx, pass)
+	require.NoError(t, err)
+	assert.Zero(t, previous, "a new decision replaces none")
+
+	steps := []struct {
+		name         string
+		in           store.Decision
+		want         store.Decision
+		wantPrevious store.DecisionType
+	}{
+		{
+			name:         "re-submitting the same type keeps the times",
+			in:           decision("a1", "r1", store.DecisionPass, 200),
+			want:         pass,
+			wantPrevious: store.DecisionPass,
+		},
+		{
+			name:         "a like changes the time and is new again",
+			in:           decision("a1", "r1", store.DecisionLike, 300),
+			want:         withSeen(withTimes(decision("a1", "r1", store.DecisionLike, 300), 300, 100), false),
+			wantPrevious: store.DecisionPass,
+		},
+		{
+			name:         "a like seen and upgraded to a super-like is new again, but keeps its like time",
+			in:           decision("a1", "r1", store.DecisionSuperLike, 400),
+			want:         withLikedAt(withTimes(decision("a1", "r1", store.DecisionSuperLike, 400), 400, 100), 300),
+			wantPrevious: store.DecisionLike,
+		},
+		{
+			name:         "a block replaces the like",
+			in:           decision("a1", "r1", store.DecisionBlock, 500),
+			want:         withSeen(withTimes(decision("a1", "r1", store.DecisionBlock, 500), 500, 100), true),
+			wantPrevious: store.DecisionSuperLike,
+		},
+	}
+	for _, step := range steps {
+		// WHEN the decision is upserted.
+		previous, err := s.UpsertDecision(ctx, step.in)
+		require.NoError(t, err, step.name)
+
+		// THEN the type it replaced is returned, and the stored decision follows the upsert rules.
+		assert.Equal(t, step.wantPrevious, previous, step.name)
+		got, err := s.GetDecisions(ctx, []store.DecisionKey{key})
+		require.NoError(t, err)
+		assert.Equal(t, []store.Decision{step.want}, got, step.name)
+		if step.want.Type == store.DecisionLike || step.want.Type == store.DecisionSuperLike {
+			// The recipient sees the like, so the next upgrade is checked against a seen like.
+			require.NoError(t, s.MarkDecisionsAsSeen(ctx, []store.DecisionKey{key}))
+		}
+	}
+}
+
+func testUpsertBatch(t *testing.T, s Store) {
+	// GIVEN an empty store.
+	ctx := context.Background()
+
+	// WHEN a batch with several decisions on the same pair is upserted.
+	previous, err := s.UpsertDecisions(ctx, []store.Decision{
+		decision("a2", "r1", store.DecisionLike, 100),
+		decision("a1", "r1", store.DecisionPass, 100),
+		decision("a1", "r1", store.DecisionLike, 200),
+	})
+	require.NoError(t, err)
+	previousEmpty, err := s.UpsertDecisions(ctx, nil)
+	require.NoError(t, err)
+
+	// THEN they are applied in order, so the last decision on a pair wins, replacing the one before it.
+	assert.Equal(t, []store.DecisionType{0, 0, store.DecisionPass}, previous)
+	assert.Empty(t, previousEmpty)
+	got, err := s.GetDecisions(ctx, []store.DecisionKey{
+		{ActorUserID: "a1", RecipientUserID: "r1"},
+		{ActorUserID: "a2", RecipientUserID: "r1"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []store.Decision{
+		withTimes(decision("a1", "r1", store.DecisionLike, 200), 200, 100),
+		decision("a2", "r1", store.DecisionLike, 100),
+	}, got)
+}
+
+func testFilters(t *testing.T, s Store) {
+	// GIVEN a store with decisions of every type on r1, and some of r1 on them.
+	ctx := context.Background()
+	_, err := s.UpsertDecisions(ctx, []store.Decision{
+		decision("a1", "r1", store.DecisionPass, 100),
+		decision("a2", "r1", store.DecisionLike, 200),
+		decision("a3", "r1", store.DecisionSuperLike, 300),
+		decision("a4", "r1", store.DecisionLike, 400),
+		decision("a5", "r1", store.DecisionLike, 500),
+		decision("a6", "r1", store.DecisionBlock, 600),
+		decision("r1", "a4", store.DecisionLike, 700),
+		decision("r1", "a5", store.DecisionBlock, 800),
+		decision("r1", "a1", store.DecisionPass, 900),
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.MarkDecisionsAsSeen(ctx, []store.DecisionKey{{ActorUserID: "a2", RecipientUserID: "r1"}}))
+
+	testMap := map[string]struct {
+		filter store.DecisionFilter
+		want   []string // Actors and recipients of the decisions, as "actor>recipient".
+	}{
+		"everything": {
+			filter: store.DecisionFilter{},
+			want:   []string{"a1>r1", "a2>r1", "a3>r1", "a4>r1", "a5>r1", "a6>r1", "r1>a1", "r1>a4", "r1>a5"},
+		},
+		"likes of a recipient": {
+			filter: store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true)},
+			want:   []string{"a2>r1", "a3>r1", "a4>r1", "a5>r1"},
+		},
+		"unseen likes": {
+			filter: store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true), SeenByRecipient: ref(false)},
+			want:   []string{"a3>r1", "a4>r1", "a5>r1"},
+		},
+		"likes not reciprocated": {
+			filter: store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true), ExcludeReciprocated: true},
+			want:   []string{"a2>r1", "a3>r1", "a5>r1"},
+		},
+		"likes not blocked": {
+			filter: store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true), ExcludeBlocked: true},
+			want:   []string{"a2>r1", "a3>r1", "a4>r1"},
+		},
+		"unseen likes neither reciprocated nor blocked": {
+			filter: store.DecisionFilter{
+				RecipientUserID:     ref("r1"),
+				LikedRecipient:      ref(true),
+				SeenByRecipient:     ref(false),
+				ExcludeReciprocated: true,
+				ExcludeBlocked:      true,
+			},
+			want: []string{"a3>r1"},
+		},
+		"decisions of an actor": {
+			filter: store.DecisionFilter{ActorUserID: ref("r1")},
+			want:   []string{"r1>a1", "r1>a4", "r1>a5"},
+		},
+		"decisions of an actor blocked by their recipient": {
+			filter: store.DecisionFilter{ActorUserID: ref("a5"), ExcludeBlocked: true},
+			want:   nil,
+		},
+		"decisions of an actor of some types": {
+			filter: store.DecisionFilter{ActorUserID: ref("r1"), Types: []store.DecisionType{store.DecisionLike, store.DecisionBlock}},
+			want:   []string{"r1>a4", "r1>a5"},
+		},
+		"decision of an actor on a recipient": {
+			filter: store.DecisionFilter{ActorUserID: ref("a3"), RecipientUserID: ref("r1")},
+			want:   []string{"a3>r1"},
+		},
+		"last modified at a time": {
+			filter: store.DecisionFilter{LastModified: ref(uint64(400))},
+			want:   []string{"a4>r1"},
+		},
+		"last modified within a time range": {
+			filter: store.DecisionFilter{RecipientUserID: ref("r1"), ModifiedSince: ref(int64(200)), ModifiedUntil: ref(int64(500))},
+			want:   []string{"a2>r1", "a3>r1", "a4>r1"},
+		},
+		"nothing": {
+			filter: store.DecisionFilter{RecipientUserID: ref("r2")},
+			want:   nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// WHEN the decisions are listed and counted with the filter.
+			got := listAll(t, s, tc.filter)
+			count, err := s.CountDecisions(ctx, tc.filter)
+
+			// THEN only the decisions matching the filter are listed, and counted.
+			require.NoError(t, err)
+			var gotPairs []string
+			for _, d := range got {
+				gotPairs = append(gotPairs, d.ActorUserID+">"+d.RecipientUserID)
+			}
+			assert.ElementsMatch(t, tc.want, gotPairs)
+			assert.Equal(t, uint64(len(tc.want)), count)
+		})
+	}
+}
+
+func testOrderings(t *testing.T, s Store) {
+	// GIVEN a store with likes on r1 and decisions of r1, some of them made at the same time.
+	ctx := context.Background()
+	_, err := s.UpsertDecisions(ctx, []store.Decision{
+		decision("a1", "r1", store.DecisionLike, 300),
+		decision("a2", "r1", store.DecisionSuperLike, 100),
+		decision("a3", "r1", store.DecisionLike, 200),
+		decision("a4", "r1", store.DecisionSuperLike, 300),
+		decision("r1", "a1", store.DecisionPass, 100),
+		decision("r1", "a2", store.DecisionLike, 300),
+		decision("r1", "a3", store.DecisionBlock, 100),
+	})
+	require.NoError(t, err)
+	likes := store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true), PageSize: 3}
+
+	testMap := map[string]struct {
+		filter store.DecisionFilter
+		want   []string
+	}{
+		"likes of a recipient, super-likes first": {
+			filter: likes,
+			want:   []string{"a2", "a4", "a1", "a3"},
+		},
+		"likes of a recipient, newest first": {
+			filter: withSort(likes, store.SortNewest),
+			want:   []string{"a4", "a1", "a3", "a2"},
+		},
+		"likes of a recipient, oldest first": {
+			filter: withSort(likes, store.SortOldest),
+			want:   []string{"a2", "a3", "a1", "a4"},
+		},
+		"decisions of an actor, newest first": {
+			filter: store.DecisionFilter{ActorUserID: ref("r1"), PageSize: 2},
+			want:   []string{"a2", "a1", "a3"},
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// WHEN the decisions are listed across pages.
+			got := listAll(t, s, tc.filter)
+
+			// THEN they are listed in the order of the filter, ties being sorted by the other user.
+			var gotUsers []string
+			for _, d := range got {
+				if tc.filter.ActorUserID != nil {
+					gotUsers = append(gotUsers, d.RecipientUserID)
+				} else {
+					gotUsers = append(gotUsers, d.ActorUserID)
+				}
+			}
+			assert.Equal(t, tc.want, gotUsers)
+		})
+	}
+}
+
+func testPagination(t *testing.T, s Store) {
+	// GIVEN a store with more likes than fit in a page, some of them changing while listing.
+	ctx := context.Background()
+	var decisions []store.Decision
+	var want []string
+	for i := range 25 {
+		actor := fmt.Sprintf("a%02d", i)
+		decisions = append(decisions, decision(actor, "r1", store.DecisionLike, int64(100+i/3)))
+		want = append(want, actor)
+	}
+	_, err := s.UpsertDecisions(ctx, decisions)
+	require.NoError(t, err)
+	filter := store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true)}
+
+	// WHEN the likes are listed with the default page size, following the page tokens until the end.
+	var got []string
+	var pages int
+	page := ""
+	for {
+		decisions, nextPage, err := s.ListDecisions(ctx, filter, page)
+		require.NoError(t, err)
+		if len(decisions) == 0 {
+			assert.Empty(t, nextPage)
+			break
+		}
+		assert.LessOrEqual(t, len(decisions), 10)
+		pages++
+		for _, d := range decisions {
+			got = append(got, d.ActorUserID)
+		}
+		page = nextPage
+		if pages == 1 {
+			// Likes changing after the first page don't make others be skipped nor repeated.
+			_, err := s.UpsertDecision(ctx, decision("a00", "r1", store.DecisionPass, 200))
+			require.NoError(t, err)
+			_, err = s.UpsertDecision(ctx, decision("a00", "r1", store.DecisionLike, 201))
+			require.NoError(t, err)
+		}
+	}
+
+	// THEN every like is listed once, in 3 pages.
+	assert.Equal(t, want, got)
+	assert.Equal(t, 3, pages)
+
+	// AND larger pages can be asked for.
+	filter.PageSize = 20
+	firstPage, _, err := s.ListDecisions(ctx, filter, "")
+	require.NoError(t, err)
+	assert.Len(t, firstPage, 20)
+
+	// AND user IDs with any character in them are paged through, one by one.
+	separated := []store.Decision{
+		decision("s\"]1", "r2", store.DecisionLike, 100),
+		decision("s##2", "r2", store.DecisionLike, 100),
+		decision("s##3", "r2", store.DecisionLike, 100),
+	}
+	_, err = s.UpsertDecisions(ctx, separated)
+	require.NoError(t, err)
+	assert.Equal(t, separated, listAll(t, s, store.DecisionFilter{RecipientUserID: ref("r2"), PageSize: 1}))
+}
+
+func testInvalidPageTokens(t *testing.T, s Store) {
+	// GIVEN a store with a like.
+	ctx := context.Background()
+	_, err := s.UpsertDecision(ctx, decision("a1", "r1", store.DecisionLike, 100))
+	require.NoError(t, err)
+	likes := store.DecisionFilter{RecipientUserID: ref("r1"), LikedRecipient: ref(true)}
+	_, newestPage, err := s.ListDecisions(ctx, withSort(likes, store.SortNewest), "")
+	require.NoError(t, err)
+	_, actorPage, err := s.ListDecisions(ctx, store.DecisionFilter{ActorUserID: ref("a1")}, "")
+	require.NoError(t, err)
+
+	testMap := map[string]struct {
+		filter store.DecisionFilter
+		page   string
+	}{
+		"malformed token":       {filter: store.DecisionFilter{}, page: "a1##r1"},
+		"incomplete sort key":   {filter: store.DecisionFilter{}, page: `["a1"]`},
+		"token of another sort": {filter: withSort(likes, store.SortOldest), page: newestPage},
+		"untagged token":        {filter: withSort(likes, store.SortNewest), page: `["100","a1"]`},
+		"token of the actor":    {filter: likes, page: actorPage},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// WHEN the decisions are listed with a page token that can't be used to resume the listing.
+			_, _, err := s.ListDecisions(ctx, tc.filter, tc.page)
+
+			// THEN an invalid cursor error is returned.
+			assert.ErrorIs(t, err, store.ErrInvalidCursor)
+		})
+	}
+
+	// AND the same goes for the mutual matches.
+	_, _, err = s.ListMutualMatches(ctx, "a1", `["a1","r1"]`)
+	assert.ErrorIs(t, err, store.ErrInvalidCursor)
+}
+
+func testGetDecisions(t *testing.T, s Store) {
+	// GIVEN a store with the decisions of two users on each other.
+	ctx := context.Background()
+	forth := decision("a1", "r1", store.DecisionLike, 100)
+	back := decision("r1", "a1", store.DecisionPass, 200)
+	_, err := s.UpsertDecisions(ctx, []store.Decision{forth, back})
+	require.NoError(t, err)
+
+	// WHEN they are got along with a decision that doesn't exist, and with no keys.
+	got, err := s.GetDecisions(ctx, []store.DecisionKey{back.Key(), {ActorUserID: "a2", RecipientUserID: "r1"}, forth.Key()})
+	require.NoError(t, err)
+	none, err := s.GetDecisions(ctx, nil)
+	require.NoError(t, err)
+
+	// THEN only the existing decisions are returned.
+	assert.ElementsMatch(t, []store.Decision{forth, back}, got)
+	assert.Empty(t, none)
+}
+
+func testMarkDecisionsAsSeen(t *testing.T, s Store) {
+	// GIVEN a store with two likes on r1.
+	ctx := context.Background()
+	_, err := s.UpsertDecisions(ctx, []store.Decision{
+		decision("a1", "r1", store.DecisionLike, 100),
+		decision("a2", "r1", store.DecisionLike, 100),
+	})
+	require.NoError(t, err)
+
+	// WHEN one of them is marked as seen, along with one that doesn't exist, and then none.
+	require.NoError(t, s.MarkDecisionsAsSeen(ctx, []store.DecisionKey{
+		{ActorUserID: "a1", RecipientUserID: "r1"},
+		{ActorUserID: "a3", RecipientUserID: "r1"},
+	}))
+	require.NoError(t, s.MarkDecisionsAsSeen(ctx, nil))
+
+	// THEN exactly that like is seen, and nothing is created.
+	unseen := listAll(t, s, store.DecisionFilter{RecipientUserID: ref("r1"), SeenByRecipient: ref(false)})
+	seen := listAll(t, s, store.DecisionFilter{RecipientUserID: ref("r1"), SeenByRecipient: ref(true)})
+	assert.Equal(t, []store.Decision{decision("a2", "r1", store.DecisionLike, 100)}, unseen)
+	assert.Equal(t, []store.Decision{withSeen(decision("a1", "r1", store.DecisionLike, 100), true)}, seen)
+}
+
+func testUndoDecision(t *testing.T, s Store) {
+	// GIVEN a store with decisions of 3 actors that changed from a pass to a like, and then to a
+	// super-like.
+	ctx := context.Background()
+	keys := make(map[string]store.DecisionKey)
+	for _, actor := range []string{"a1", "a2", "a3"} {
+		keys[actor] = store.DecisionKey{ActorUserID: actor, RecipientUserID: "r1"}
+		for _, d := range []store.Decision{
+			decision(actor, "r1", store.DecisionPass, 100),
+			decision(actor, "r1", store.DecisionLike, 200),
+			decision(actor, "r1", store.DecisionSuperLike, 300),
+		} {
+			_, err := s.UpsertDecision(ctx, d)
+			require.NoError(t, err)
+		}
+	}
+	pass := decision("a1", "r1", store.DecisionPass, 100)
+	like := withTimes(decision("a1", "r1", store.DecisionLike, 200), 200, 100)
+	superLike := withLikedAt(withTimes(decision("a1", "r1", store.DecisionSuperLike, 300), 300, 100), 200)
+
+	// WHEN the decision of a1 is undone too late.
+	_, _, err := s.UndoDecision(ctx, keys["a1"], 301)
+
+	// THEN it fails, and the decision doesn't change.
+	require.ErrorIs(t, err, store.ErrFailedPrecondition)
+	assertDecisions(t, s, []store.DecisionKey{keys["a1"]}, superLike)
+
+	// WHEN the decision of a2 is undone in time, over and over.
+	undone, restored, err := s.UndoDecision(ctx, keys["a2"], 100)
+	require.NoError(t, err)
+	assert.Equal(t, withActor(superLike, "a2"), undone)
+	assert.Equal(t, ref(withActor(like, "a2")), restored)
+	undone, restored, err = s.UndoDecision(ctx, keys["a2"], 100)
+	require.NoError(t, err)
+	assert.Equal(t, withActor(like, "a2"), undone)
+	assert.Equal(t, ref(withActor(pass, "a2")), restored)
+	undone, restored, err = s.UndoDecision(ctx, keys["a2"], 100)
+	require.NoError(t, err)
+	assert.Equal(t, withActor(pass, "a2"), undone)
+	assert.Nil(t, restored)
+
+	// THEN every version is restored in turn, until there's no decision left to undo.
+	assertDecisions(t, s, []store.DecisionKey{keys["a2"]})
+	_, _, err = s.UndoDecision(ctx, keys["a2"], 0)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// WHEN the decision of a3 is undone in time, restoring a like out of the window.
+	undone, restored, err = s.UndoDecision(ctx, keys["a3"], 250)
+	require.NoError(t, err)
+	assert.Equal(t, withActor(superLike, "a3"), undone)
+	assert.Equal(t, ref(withActor(like, "a3")), restored)
+
+	// THEN the versions that can't be restored anymore are gone from the history, after the decision
+	// undone too late, or restored out of the window: undoing them without a window restores nothing.
+	for _, actor := range []string{"a1", "a3"} {
+		_, restored, err := s.UndoDecision(ctx, keys[actor], 0)
+		require.NoError(t, err)
+		assert.Nil(t, restored, actor)
+	}
+}
+
+func testDeleteDecision(t *testing.T, s Store) {
+	// GIVEN a store with a decision that changed from a pass to a like.
+	ctx := context.Background()
+	key := store.DecisionKey{ActorUserID: "a1", RecipientUserID: "r1"}
+	_, err := s.UpsertDecision(ctx, decision("a1", "r1", store.DecisionPass, 100))
+	require.NoError(t, err)
+	_, err = s.UpsertDecision(ctx, decision("a1", "r1", store.DecisionLike, 200))
+	require.NoError(t, err)
+
+	// WHEN it's deleted, and then decided again.
+	deleted, err := s.DeleteDecision(ctx, key)
+	require.NoError(t, err)
+	_, err = s.DeleteDecision(ctx, key)
+	require.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.UpsertDecision(ctx, decision("a1", "r1", store.DecisionLike, 300))
+	require.NoError(t, err)
+
+	// THEN its history is gone along with it, so undoing the new decision leaves nothing.
+	assert.Equal(t, withTimes(decision("a1", "r1", store.DecisionLike, 200), 200, 100), deleted)
+	_, restored, err := s.UndoDecision(ctx, key, 0)
+	require.NoError(t, err)
+	assert.Nil(t, restored)
+	assertDecisions(t, s, []store.DecisionKey{key})
+}
+
+func testUnmatch(t *testing.T, s Store) {
+	// GIVEN a store with a match, a like not liked back, and a like passed back.
+	ctx := context.Background()
+	_, err := s.UpsertDecisions(ctx, []store.Decision{
+		decision("u1", "u2", store.DecisionLike, 100),
+		decision("u2", "u1", store.DecisionSuperLike, 200),
+		decision("u1", "u3", store.DecisionLike, 300),
+		decision("u1", "u4", store.DecisionLike, 400),
+		decision("u4", "u1", store.DecisionPass, 500),
+	})
+	require.NoError(t, err)
+
+	// WHEN the users not matched are unmatched.
+	errNotLikedBack := s.Unmatch(ctx, "u1", "u3")
+	errPassedBack := s.Unmatch(ctx, "u4", "u1")
+
+	// THEN it fails, and nothing is deleted.
+	assert.ErrorIs(t, errNotLikedBack, store.ErrNotFound)
+	assert.ErrorIs(t, errPassedBack, store.ErrNotFound)
+	count, err := s.CountDecisions(ctx, store.DecisionFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), count)
+
+	// WHEN the matched users are unmatched.
+	require.NoError(t, s.Unmatch(ctx, "u2", "u1"))
+
+	// THEN both likes are gone, along with their history.
+	assertDecisions(t, s, []store.DecisionKey{
+		{ActorUserID: "u1", RecipientUserID: "u2"},
+		{ActorUserID: "u2", RecipientUserID: "u1"},
+	})
+	matches, _, err := s.ListMutualMatches(ctx, "u1", "")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func testListMutualMatches(t *testing.T, s Store) {
+	// GIVEN a store where u0 matched more users than fit in a page, liked one that passed back, and
+	// was liked by another one without liking them back.
+	ctx := context.Background()
+	var decisions []store.Decision
+	var want []store.Match
+	for i := range 12 {
+		partner := fmt.Sprintf("p%02d", i)
+		decisions = append(decisions,
+			decision("u0", partner, store.DecisionLike, int64(100+i)),
+			decision(partner, "u0", store.DecisionSuperLike, int64(200-i)),
+		)
+		want = append(want, store.Match{UserID: "u0", PartnerUserID: partner, MatchedAt: max(int64(100+i), int64(200-i))})
+	}
+	decisions = append(decisions,
+		decision("u0", "q1", store.DecisionLike, 100),
+		decision("q1", "u0", store.DecisionPass, 100),
+		decision("q2", "u0", store.DecisionLike, 100),
+	)
+	_, err := s.UpsertDecisions(ctx, decisions)
+	require.NoError(t, err)
+	// Upgrading a like to a super-like doesn't move the match.
+	_, err = s.UpsertDecision(ctx, decision("u0", "p00", store.DecisionSuperLike, 300))
+	require.NoError(t, err)
+
+	// WHEN the matches of u0 are listed, following the page tokens until the end.
+	var got []store.Match
+	page := ""
+	for range len(want) + 1 {
+		matches, nextPage, err := s.ListMutualMatches(ctx, "u0", page)
+		require.NoError(t, err)
+		if len(matches) == 0 {
+			break
+		}
+		assert.LessOrEqual(t, len(matches), 10)
+		got = append(got, matches...)
+		page = nextPage
+	}
+
+	// THEN every match is listed once, by partner, at the time of the like completing it.
+	assert.Equal(t, want, got)
+}
+
+func testConcurrentWriters(t *testing.T, s Store) {
+	// GIVEN an empty store, and many writers deciding at once, both on their own and on shared pairs.
+	ctx := context.Background()
+	const writers = 8
+	const decisionsPerWriter = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range decisionsPerWriter {
+				own := decision(fmt.Sprintf("w%d", w), fmt.Sprintf("r%d", i), store.DecisionLike, int64(100+i))
+				// Every writer also decides on the same pair.
+				shared := decision("s1", "s2", store.DecisionLike, int64(100+i))
+				batch := []store.Decision{own, shared}
+				if _, err := s.UpsertDecisions(ctx, batch); err != nil {
+					errs <- err
+					return
+				}
+				if err := s.MarkDecisionsAsSeen(ctx, []store.DecisionKey{own.Key()}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	// WHEN they are all done.
+	wg.Wait()
+	close(errs)
+
+	// THEN every write succeeds, as the store resolves its own conflicts, and none is lost.
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	count, err := s.CountDecisions(ctx, store.DecisionFilter{SeenByRecipient: ref(true)})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(writers*decisionsPerWriter), count)
+	got, err := s.GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "s1", RecipientUserID: "s2"}})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, store.DecisionLike, got[0].Type)
+}
+
+// listAll lists the decisions matching a filter across all the pages, checking every page token
+// resumes the listing, and that it ends.
+func listAll(t *testing.T, s Store, filter store.DecisionFilter) []store.Decision {
+	t.Helper()
+	var all []store.Decision
+	page := ""
+	for range 100 {
+		decisions, nextPage, err := s.ListDecisions(context.Background(), filter, page)
+		require.NoError(t, err)
+		if len(decisions) == 0 {
+			return all
+		}
+		all = append(all, decisions...)
+		page = nextPage
+	}
+	require.FailNow(t, "listing did not end")
+	return nil
+}
+
+// assertDecisions checks the decisions stored with the given keys are exactly the ones wanted.
+func assertDecisions(t *testing.T, s Store, keys []store.DecisionKey, want ...store.Decision) {
+	t.Helper()
+	got, err := s.GetDecisions(context.Background(), keys)
+	require.NoError(t, err)
+	if len(want) == 0 {
+		assert.Empty(t, got)
+		return
+	}
+	assert.ElementsMatch(t, want, got)
+}
+
+// decision builds a new decision of the given type, made at the given time.
+func decision(actor, recipient string, decisionType store.DecisionType, at int64) store.Decision {
+	d := store.Decision{
+		ActorUserID:     actor,
+		RecipientUserID: recipient,
+		LikedRecipient:  decisionType.Liked(),
+		LastModified:    at,
+		CreatedAt:       at,
+		Type:            decisionType,
+	}
+	if d.LikedRecipient {
+		d.LikedAt = at
+	}
+	return d
+}
+
+func withTimes(d store.Decision, lastModified, createdAt int64) store.Decision {
+	d.LastModified, d.CreatedAt = lastModified, createdAt
+	return d
+}
+
+func withLikedAt(d store.Decision, likedAt int64) store.Decision {
+	d.LikedAt = likedAt
+	return d
+}
+
+func withActor(d store.Decision, actor string) store.Decision {
+	d.ActorUserID = actor
+	return d
+}
+
+func withSeen(d store.Decision, seen bool) store.Decision {
+	d.SeenByRecipient = seen
+	return d
+}
+
+func withSort(filter store.DecisionFilter, sort store.DecisionSort) store.DecisionFilter {
+	filter.Sort = sort
+	return filter
+}
+
+func ref[T any](t T) *T { return &t }
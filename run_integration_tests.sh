@@ -3,5 +3,6 @@ cd test
 docker-compose build
 docker-compose up --wait
 go run main.go
+EXPLORE_TEST_MYSQL_DSN="root@tcp(localhost:3306)/" go test ../internal/store/database -run TestQueriesUseIndexes -count=1
 docker-compose down -v
 cd ..
\ No newline at end of file
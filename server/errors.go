@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"muzz-explore/internal/store"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storeError turns an error returned by the store into the status handed to the client. The full
+// error is only logged, as it may carry details of the store; the client gets the status code
+// matching it and msg, which must be safe to expose.
+func storeError(err error, msg string) error {
+	code := storeErrorCode(err)
+	if code == codes.InvalidArgument {
+		// The cursor came from the client, so it's the only detail worth sharing with it.
+		msg = "invalid pagination token"
+	}
+	log.Error().Err(err).Str("code", code.String()).Msg(msg)
+	return status.Error(code, msg)
+}
+
+// storeErrorCode returns the status code matching an error returned by the store.
+func storeErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, store.ErrInvalidCursor):
+		return codes.InvalidArgument
+	case errors.Is(err, store.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, store.ErrConflict):
+		return codes.Aborted
+	case errors.Is(err, store.ErrFailedPrecondition):
+		return codes.FailedPrecondition
+	case errors.Is(err, store.ErrUnavailable):
+		return codes.Unavailable
+	case errors.Is(err, store.ErrDeadline), errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default limits of the in-process hub.
+const (
+	DefaultWatchBufferSize  = 64
+	DefaultWatchHistorySize = 10000
+)
+
+var (
+	// ErrCursorExpired is returned when subscribing after an event that is no longer retained, so the
+	// events following it can't be replayed.
+	ErrCursorExpired = errors.New("cursor expired")
+	// ErrSlowSubscriber ends a subscription that didn't keep up with its events and filled its buffer.
+	ErrSlowSubscriber = errors.New("subscriber fell behind")
+)
+
+// LikeEventKind tells what happened to the recipient of a LikeEvent.
+type LikeEventKind uint8
+
+const (
+	LikeEventLike    LikeEventKind = iota // Liked by the actor.
+	LikeEventMatch                        // Matched with the actor.
+	LikeEventUnmatch                      // The match with the actor was dissolved.
+)
+
+// LikeEvent is a like received by a user, or a match completed with them, or dissolved.
+type LikeEvent struct {
+	Cursor          string // Set by the hub when published, to resume right after the event.
+	RecipientUserID string
+	ActorUserID     string // The user that liked the recipient, or their partner if it's a match.
+	Kind            LikeEventKind
+	SuperLike       bool // Set on likes only.
+	Timestamp       int64
+}
+
+// LikesHub distributes the like events of the users to the streams watching them. The in-process
+// MemoryHub only reaches the streams of the same replica, so running several replicas needs a hub
+// backed by a broker shared by all of them.
+type LikesHub interface {
+	// Publish hands an event to the subscriptions of its recipient, without waiting for them.
+	Publish(ctx context.Context, event LikeEvent) error
+	// Subscribe subscribes to the events of a recipient, starting right after the event with the
+	// given cursor, or with the next event published if the cursor is empty.
+	Subscribe(ctx context.Context, recipientUserID, after string) (Subscription, error)
+}
+
+// Subscription is a stream of the events of a recipient.
+type Subscription interface {
+	// Events delivers the events in the order they were published. It's closed when the subscription
+	// ends, either because it was closed or because of the error returned by Err.
+	Events() <-chan LikeEvent
+	// Err returns the reason why the subscription ended on its own, if it did.
+	Err() error
+	// Close ends the subscription.
+	Close()
+}
+
+// MemoryHub is a LikesHub for a single replica. It keeps the last events published, to replay them
+// to subscriptions resuming from a cursor. Cursors are only valid for the hub that issued them.
+type MemoryHub struct {
+	epoch       string // Tells apart the cursors of this hub from the ones of a previous run.
+	bufferSize  int
+	historySize int
+
+	mu            sync.Mutex
+	seq           uint64
+	history       []LikeEvent // Last events published, oldest first.
+	subscriptions map[string]map[*memorySubscription]struct{}
+}
+
+// NewMemoryHub returns a hub buffering up to bufferSize events per subscription, and keeping the
+// last historySize events published to resume subscriptions.
+func NewMemoryHub(bufferSize, historySize int) *MemoryHub {
+	epoch := make([]byte, 8)
+	if _, err := rand.Read(epoch); err != nil {
+		panic(fmt.Sprintf("failed to generate hub epoch: %v", err))
+	}
+	return &MemoryHub{
+		epoch:         hex.EncodeToString(epoch),
+		bufferSize:    bufferSize,
+		historySize:   historySize,
+		subscriptions: map[string]map[*memorySubscription]struct{}{},
+	}
+}
+
+func (h *MemoryHub) Publish(_ context.Context, event LikeEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	event.Cursor = h.cursor(h.seq)
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+	for sub := range h.subscriptions[event.RecipientUserID] {
+		select {
+		case sub.events <- event:
+		default:
+			// Never block the publisher: the subscriber has to resume from its last cursor.
+			h.end(sub, ErrSlowSubscriber)
+		}
+	}
+	return nil
+}
+
+func (h *MemoryHub) Subscribe(_ context.Context, recipientUserID, after string) (Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var replay []LikeEvent
+	if after != "" {
+		afterSeq, err := h.parseCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		// The event right after the cursor must still be retained, or some could be missed.
+		if afterSeq+1 < h.firstSeq() {
+			return nil, ErrCursorExpired
+		}
+		for i, event := range h.history {
+			if h.firstSeq()+uint64(i) > afterSeq && event.RecipientUserID == recipientUserID {
+				replay = append(replay, event)
+			}
+		}
+	}
+	sub := &memorySubscription{
+		hub:       h,
+		recipient: recipientUserID,
+		events:    make(chan LikeEvent, h.bufferSize+len(replay)),
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+	if h.subscriptions[recipientUserID] == nil {
+		h.subscriptions[recipientUserID] = map[*memorySubscription]struct{}{}
+	}
+	h.subscriptions[recipientUserID][sub] = struct{}{}
+	return sub, nil
+}
+
+// firstSeq returns the sequence number of the oldest event retained. Must be called with mu held.
+func (h *MemoryHub) firstSeq() uint64 {
+	return h.seq - uint64(len(h.history)) + 1
+}
+
+func (h *MemoryHub) cursor(seq uint64) string {
+	return h.epoch + "." + strconv.FormatUint(seq, 10)
+}
+
+// parseCursor returns the sequence number of the event with the given cursor.
+func (h *MemoryHub) parseCursor(cursor string) (uint64, error) {
+	epoch, seqStr, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return 0, fmt.Errorf("%w: malformed cursor %q", ErrCursorExpired, cursor)
+	}
+	if epoch != h.epoch {
+		// Issued by a previous run of the hub, whose events are gone.
+		return 0, ErrCursorExpired
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > h.seq {
+		return 0, fmt.Errorf("%w: malformed cursor %q", ErrCursorExpired, cursor)
+	}
+	return seq, nil
+}
+
+// end removes a subscription, closing its events. Must be called with mu held.
+func (h *MemoryHub) end(sub *memorySubscription, err error) {
+	subs := h.subscriptions[sub.recipient]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscriptions, sub.recipient)
+	}
+	sub.err = err
+	close(sub.events)
+}
+
+type memorySubscription struct {
+	hub       *MemoryHub
+	recipient string
+	events    chan LikeEvent
+	err       error // Guarded by the mutex of the hub.
+}
+
+func (s *memorySubscription) Events() <-chan LikeEvent {
+	return s.events
+}
+
+func (s *memorySubscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+func (s *memorySubscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.end(s, nil)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive returns the events pending on a subscription, without waiting for more.
+func receive(sub Subscription) []LikeEvent {
+	var events []LikeEvent
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// actors returns the actors of some events, to compare them easily.
+func actors(events []LikeEvent) []string {
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ActorUserID)
+	}
+	return ids
+}
+
+func TestMemoryHubPublish(t *testing.T) {
+	// GIVEN: A hub with two subscriptions to different recipients.
+	ctx := context.Background()
+	hub := NewMemoryHub(10, 10)
+	sub1, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+	sub2, err := hub.Subscribe(ctx, "user2", "")
+	require.NoError(t, err)
+
+	// WHEN: Events for both recipients are published.
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user3"}))
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user2", ActorUserID: "user4"}))
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user5", Kind: LikeEventMatch}))
+
+	// THEN: Each subscription only gets the events of its recipient, in order, with their cursor.
+	events := receive(sub1)
+	assert.Equal(t, []string{"user3", "user5"}, actors(events))
+	assert.NotEmpty(t, events[0].Cursor)
+	assert.NotEqual(t, events[0].Cursor, events[1].Cursor)
+	assert.Equal(t, LikeEventMatch, events[1].Kind)
+	assert.Equal(t, []string{"user4"}, actors(receive(sub2)))
+}
+
+func TestMemoryHubResume(t *testing.T) {
+	// GIVEN: A hub with some events already published, one of them seen by the recipient.
+	ctx := context.Background()
+	hub := NewMemoryHub(10, 10)
+	sub, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user2"}))
+	seen := receive(sub)
+	require.Len(t, seen, 1)
+	sub.Close()
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user3"}))
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user4", ActorUserID: "user5"}))
+
+	// WHEN: The recipient subscribes again after the event seen, and another one is published.
+	sub, err = hub.Subscribe(ctx, "user1", seen[0].Cursor)
+	require.NoError(t, err)
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user6"}))
+
+	// THEN: The events missed are replayed before the new one.
+	assert.Equal(t, []string{"user3", "user6"}, actors(receive(sub)))
+}
+
+func TestMemoryHubCursorExpired(t *testing.T) {
+	ctx := context.Background()
+	hub := NewMemoryHub(10, 2)
+	sub, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user2"}))
+	seen := receive(sub)
+	require.Len(t, seen, 1)
+
+	t.Run("last event retained", func(t *testing.T) {
+		// GIVEN: Only the event seen was published.
+		// WHEN: Subscribing after it.
+		_, err := hub.Subscribe(ctx, "user1", seen[0].Cursor)
+
+		// THEN: The subscription is accepted.
+		require.NoError(t, err)
+	})
+
+	t.Run("cursor of another hub", func(t *testing.T) {
+		// GIVEN: A hub of another run.
+		other := NewMemoryHub(10, 2)
+
+		// WHEN: Subscribing to it with a cursor of the first hub.
+		_, err := other.Subscribe(ctx, "user1", seen[0].Cursor)
+
+		// THEN: The cursor is expired.
+		require.ErrorIs(t, err, ErrCursorExpired)
+	})
+
+	t.Run("events following the cursor dropped", func(t *testing.T) {
+		// GIVEN: More events published than retained.
+		for _, actor := range []string{"user3", "user4", "user5"} {
+			require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: actor}))
+		}
+
+		// WHEN: Subscribing after the event seen.
+		_, err := hub.Subscribe(ctx, "user1", seen[0].Cursor)
+
+		// THEN: The cursor is expired.
+		require.ErrorIs(t, err, ErrCursorExpired)
+	})
+}
+
+func TestMemoryHubSlowSubscriber(t *testing.T) {
+	// GIVEN: A hub with a subscription not reading its events.
+	ctx := context.Background()
+	hub := NewMemoryHub(2, 10)
+	slow, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+	fast, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+
+	// WHEN: More events than fit in its buffer are published.
+	for _, actor := range []string{"user2", "user3"} {
+		require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: actor}))
+	}
+	assert.Equal(t, []string{"user2", "user3"}, actors(receive(fast)))
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user4"}))
+
+	// THEN: The slow subscription ends after its buffered events, without affecting the other one.
+	assert.Equal(t, []string{"user2", "user3"}, actors(receive(slow)))
+	_, open := <-slow.Events()
+	assert.False(t, open)
+	assert.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+	assert.Equal(t, []string{"user4"}, actors(receive(fast)))
+}
+
+func TestMemoryHubClose(t *testing.T) {
+	// GIVEN: A hub with a subscription.
+	ctx := context.Background()
+	hub := NewMemoryHub(10, 10)
+	sub, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+
+	// WHEN: The subscription is closed, twice, and an event published.
+	sub.Close()
+	sub.Close()
+	require.NoError(t, hub.Publish(ctx, LikeEvent{RecipientUserID: "user1", ActorUserID: "user2"}))
+
+	// THEN: The subscription ended without error, and is no longer tracked by the hub.
+	_, open := <-sub.Events()
+	assert.False(t, open)
+	assert.NoError(t, sub.Err())
+	assert.Empty(t, hub.subscriptions)
+}
@@ -80,6 +80,122 @@ func (_c *DecisionStore_CountDecisions_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
+// DeleteDecision provides a mock function with given fields: ctx, key
+func (_m *DecisionStore) DeleteDecision(ctx context.Context, key store.DecisionKey) (store.Decision, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDecision")
+	}
+
+	var r0 store.Decision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.DecisionKey) (store.Decision, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.DecisionKey) store.Decision); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(store.Decision)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.DecisionKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecisionStore_DeleteDecision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDecision'
+type DecisionStore_DeleteDecision_Call struct {
+	*mock.Call
+}
+
+// DeleteDecision is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key store.DecisionKey
+func (_e *DecisionStore_Expecter) DeleteDecision(ctx interface{}, key interface{}) *DecisionStore_DeleteDecision_Call {
+	return &DecisionStore_DeleteDecision_Call{Call: _e.mock.On("DeleteDecision", ctx, key)}
+}
+
+func (_c *DecisionStore_DeleteDecision_Call) Run(run func(ctx context.Context, key store.DecisionKey)) *DecisionStore_DeleteDecision_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(store.DecisionKey))
+	})
+	return _c
+}
+
+func (_c *DecisionStore_DeleteDecision_Call) Return(_a0 store.Decision, _a1 error) *DecisionStore_DeleteDecision_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DecisionStore_DeleteDecision_Call) RunAndReturn(run func(context.Context, store.DecisionKey) (store.Decision, error)) *DecisionStore_DeleteDecision_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDecisions provides a mock function with given fields: ctx, keys
+func (_m *DecisionStore) GetDecisions(ctx context.Context, keys []store.DecisionKey) ([]store.Decision, error) {
+	ret := _m.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDecisions")
+	}
+
+	var r0 []store.Decision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []store.DecisionKey) ([]store.Decision, error)); ok {
+		return rf(ctx, keys)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []store.DecisionKey) []store.Decision); ok {
+		r0 = rf(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.Decision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []store.DecisionKey) error); ok {
+		r1 = rf(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecisionStore_GetDecisions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDecisions'
+type DecisionStore_GetDecisions_Call struct {
+	*mock.Call
+}
+
+// GetDecisions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []store.DecisionKey
+func (_e *DecisionStore_Expecter) GetDecisions(ctx interface{}, keys interface{}) *DecisionStore_GetDecisions_Call {
+	return &DecisionStore_GetDecisions_Call{Call: _e.mock.On("GetDecisions", ctx, keys)}
+}
+
+func (_c *DecisionStore_GetDecisions_Call) Run(run func(ctx context.Context, keys []store.DecisionKey)) *DecisionStore_GetDecisions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]store.DecisionKey))
+	})
+	return _c
+}
+
+func (_c *DecisionStore_GetDecisions_Call) Return(_a0 []store.Decision, _a1 error) *DecisionStore_GetDecisions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DecisionStore_GetDecisions_Call) RunAndReturn(run func(context.Context, []store.DecisionKey) ([]store.Decision, error)) *DecisionStore_GetDecisions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListDecisions provides a mock function with given fields: ctx, filter, page
 func (_m *DecisionStore) ListDecisions(ctx context.Context, filter store.DecisionFilter, page string) ([]store.Decision, string, error) {
 	ret := _m.Called(ctx, filter, page)
@@ -147,17 +263,84 @@ func (_c *DecisionStore_ListDecisions_Call) RunAndReturn(run func(context.Contex
 	return _c
 }
 
-// MarkDecisionsAsSeen provides a mock function with given fields: ctx, RecipientUserID, initPageToken, nextPageToken
-func (_m *DecisionStore) MarkDecisionsAsSeen(ctx context.Context, RecipientUserID string, initPageToken string, nextPageToken string) error {
-	ret := _m.Called(ctx, RecipientUserID, initPageToken, nextPageToken)
+// ListMutualMatches provides a mock function with given fields: ctx, userID, page
+func (_m *DecisionStore) ListMutualMatches(ctx context.Context, userID string, page string) ([]store.Match, string, error) {
+	ret := _m.Called(ctx, userID, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMutualMatches")
+	}
+
+	var r0 []store.Match
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]store.Match, string, error)); ok {
+		return rf(ctx, userID, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []store.Match); ok {
+		r0 = rf(ctx, userID, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.Match)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) string); ok {
+		r1 = rf(ctx, userID, page)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, userID, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DecisionStore_ListMutualMatches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMutualMatches'
+type DecisionStore_ListMutualMatches_Call struct {
+	*mock.Call
+}
+
+// ListMutualMatches is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - page string
+func (_e *DecisionStore_Expecter) ListMutualMatches(ctx interface{}, userID interface{}, page interface{}) *DecisionStore_ListMutualMatches_Call {
+	return &DecisionStore_ListMutualMatches_Call{Call: _e.mock.On("ListMutualMatches", ctx, userID, page)}
+}
+
+func (_c *DecisionStore_ListMutualMatches_Call) Run(run func(ctx context.Context, userID string, page string)) *DecisionStore_ListMutualMatches_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *DecisionStore_ListMutualMatches_Call) Return(_a0 []store.Match, _a1 string, _a2 error) *DecisionStore_ListMutualMatches_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *DecisionStore_ListMutualMatches_Call) RunAndReturn(run func(context.Context, string, string) ([]store.Match, string, error)) *DecisionStore_ListMutualMatches_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkDecisionsAsSeen provides a mock function with given fields: ctx, keys
+func (_m *DecisionStore) MarkDecisionsAsSeen(ctx context.Context, keys []store.DecisionKey) error {
+	ret := _m.Called(ctx, keys)
 
 	if len(ret) == 0 {
 		panic("no return value specified for MarkDecisionsAsSeen")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
-		r0 = rf(ctx, RecipientUserID, initPageToken, nextPageToken)
+	if rf, ok := ret.Get(0).(func(context.Context, []store.DecisionKey) error); ok {
+		r0 = rf(ctx, keys)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -172,16 +355,14 @@ type DecisionStore_MarkDecisionsAsSeen_Call struct {
 
 // MarkDecisionsAsSeen is a helper method to define mock.On call
 //   - ctx context.Context
-//   - RecipientUserID string
-//   - initPageToken string
-//   - nextPageToken string
-func (_e *DecisionStore_Expecter) MarkDecisionsAsSeen(ctx interface{}, RecipientUserID interface{}, initPageToken interface{}, nextPageToken interface{}) *DecisionStore_MarkDecisionsAsSeen_Call {
-	return &DecisionStore_MarkDecisionsAsSeen_Call{Call: _e.mock.On("MarkDecisionsAsSeen", ctx, RecipientUserID, initPageToken, nextPageToken)}
+//   - keys []store.DecisionKey
+func (_e *DecisionStore_Expecter) MarkDecisionsAsSeen(ctx interface{}, keys interface{}) *DecisionStore_MarkDecisionsAsSeen_Call {
+	return &DecisionStore_MarkDecisionsAsSeen_Call{Call: _e.mock.On("MarkDecisionsAsSeen", ctx, keys)}
 }
 
-func (_c *DecisionStore_MarkDecisionsAsSeen_Call) Run(run func(ctx context.Context, RecipientUserID string, initPageToken string, nextPageToken string)) *DecisionStore_MarkDecisionsAsSeen_Call {
+func (_c *DecisionStore_MarkDecisionsAsSeen_Call) Run(run func(ctx context.Context, keys []store.DecisionKey)) *DecisionStore_MarkDecisionsAsSeen_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+		run(args[0].(context.Context), args[1].([]store.DecisionKey))
 	})
 	return _c
 }
@@ -191,27 +372,152 @@ func (_c *DecisionStore_MarkDecisionsAsSeen_Call) Return(_a0 error) *DecisionSto
 	return _c
 }
 
-func (_c *DecisionStore_MarkDecisionsAsSeen_Call) RunAndReturn(run func(context.Context, string, string, string) error) *DecisionStore_MarkDecisionsAsSeen_Call {
+func (_c *DecisionStore_MarkDecisionsAsSeen_Call) RunAndReturn(run func(context.Context, []store.DecisionKey) error) *DecisionStore_MarkDecisionsAsSeen_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UndoDecision provides a mock function with given fields: ctx, key, notBefore
+func (_m *DecisionStore) UndoDecision(ctx context.Context, key store.DecisionKey, notBefore int64) (store.Decision, *store.Decision, error) {
+	ret := _m.Called(ctx, key, notBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UndoDecision")
+	}
+
+	var r0 store.Decision
+	var r1 *store.Decision
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.DecisionKey, int64) (store.Decision, *store.Decision, error)); ok {
+		return rf(ctx, key, notBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.DecisionKey, int64) store.Decision); ok {
+		r0 = rf(ctx, key, notBefore)
+	} else {
+		r0 = ret.Get(0).(store.Decision)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.DecisionKey, int64) *store.Decision); ok {
+		r1 = rf(ctx, key, notBefore)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*store.Decision)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, store.DecisionKey, int64) error); ok {
+		r2 = rf(ctx, key, notBefore)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DecisionStore_UndoDecision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UndoDecision'
+type DecisionStore_UndoDecision_Call struct {
+	*mock.Call
+}
+
+// UndoDecision is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key store.DecisionKey
+//   - notBefore int64
+func (_e *DecisionStore_Expecter) UndoDecision(ctx interface{}, key interface{}, notBefore interface{}) *DecisionStore_UndoDecision_Call {
+	return &DecisionStore_UndoDecision_Call{Call: _e.mock.On("UndoDecision", ctx, key, notBefore)}
+}
+
+func (_c *DecisionStore_UndoDecision_Call) Run(run func(ctx context.Context, key store.DecisionKey, notBefore int64)) *DecisionStore_UndoDecision_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(store.DecisionKey), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *DecisionStore_UndoDecision_Call) Return(_a0 store.Decision, _a1 *store.Decision, _a2 error) *DecisionStore_UndoDecision_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *DecisionStore_UndoDecision_Call) RunAndReturn(run func(context.Context, store.DecisionKey, int64) (store.Decision, *store.Decision, error)) *DecisionStore_UndoDecision_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unmatch provides a mock function with given fields: ctx, userID, partnerUserID
+func (_m *DecisionStore) Unmatch(ctx context.Context, userID string, partnerUserID string) error {
+	ret := _m.Called(ctx, userID, partnerUserID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unmatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, partnerUserID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DecisionStore_Unmatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unmatch'
+type DecisionStore_Unmatch_Call struct {
+	*mock.Call
+}
+
+// Unmatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - partnerUserID string
+func (_e *DecisionStore_Expecter) Unmatch(ctx interface{}, userID interface{}, partnerUserID interface{}) *DecisionStore_Unmatch_Call {
+	return &DecisionStore_Unmatch_Call{Call: _e.mock.On("Unmatch", ctx, userID, partnerUserID)}
+}
+
+func (_c *DecisionStore_Unmatch_Call) Run(run func(ctx context.Context, userID string, partnerUserID string)) *DecisionStore_Unmatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *DecisionStore_Unmatch_Call) Return(_a0 error) *DecisionStore_Unmatch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DecisionStore_Unmatch_Call) RunAndReturn(run func(context.Context, string, string) error) *DecisionStore_Unmatch_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // UpsertDecision provides a mock function with given fields: ctx, decision
-func (_m *DecisionStore) UpsertDecision(ctx context.Context, decision store.Decision) error {
+func (_m *DecisionStore) UpsertDecision(ctx context.Context, decision store.Decision) (store.DecisionType, error) {
 	ret := _m.Called(ctx, decision)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpsertDecision")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, store.Decision) error); ok {
+	var r0 store.DecisionType
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.Decision) (store.DecisionType, error)); ok {
+		return rf(ctx, decision)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.Decision) store.DecisionType); ok {
 		r0 = rf(ctx, decision)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(store.DecisionType)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, store.Decision) error); ok {
+		r1 = rf(ctx, decision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // DecisionStore_UpsertDecision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertDecision'
@@ -233,12 +539,71 @@ func (_c *DecisionStore_UpsertDecision_Call) Run(run func(ctx context.Context, d
 	return _c
 }
 
-func (_c *DecisionStore_UpsertDecision_Call) Return(_a0 error) *DecisionStore_UpsertDecision_Call {
-	_c.Call.Return(_a0)
+func (_c *DecisionStore_UpsertDecision_Call) Return(_a0 store.DecisionType, _a1 error) *DecisionStore_UpsertDecision_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DecisionStore_UpsertDecision_Call) RunAndReturn(run func(context.Context, store.Decision) (store.DecisionType, error)) *DecisionStore_UpsertDecision_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpsertDecisions provides a mock function with given fields: ctx, decisions
+func (_m *DecisionStore) UpsertDecisions(ctx context.Context, decisions []store.Decision) ([]store.DecisionType, error) {
+	ret := _m.Called(ctx, decisions)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertDecisions")
+	}
+
+	var r0 []store.DecisionType
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []store.Decision) ([]store.DecisionType, error)); ok {
+		return rf(ctx, decisions)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []store.Decision) []store.DecisionType); ok {
+		r0 = rf(ctx, decisions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.DecisionType)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []store.Decision) error); ok {
+		r1 = rf(ctx, decisions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecisionStore_UpsertDecisions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertDecisions'
+type DecisionStore_UpsertDecisions_Call struct {
+	*mock.Call
+}
+
+// UpsertDecisions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - decisions []store.Decision
+func (_e *DecisionStore_Expecter) UpsertDecisions(ctx interface{}, decisions interface{}) *DecisionStore_UpsertDecisions_Call {
+	return &DecisionStore_UpsertDecisions_Call{Call: _e.mock.On("UpsertDecisions", ctx, decisions)}
+}
+
+func (_c *DecisionStore_UpsertDecisions_Call) Run(run func(ctx context.Context, decisions []store.Decision)) *DecisionStore_UpsertDecisions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]store.Decision))
+	})
+	return _c
+}
+
+func (_c *DecisionStore_UpsertDecisions_Call) Return(_a0 []store.DecisionType, _a1 error) *DecisionStore_UpsertDecisions_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *DecisionStore_UpsertDecision_Call) RunAndReturn(run func(context.Context, store.Decision) error) *DecisionStore_UpsertDecision_Call {
+func (_c *DecisionStore_UpsertDecisions_Call) RunAndReturn(run func(context.Context, []store.Decision) ([]store.DecisionType, error)) *DecisionStore_UpsertDecisions_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -2,13 +2,15 @@ package server
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	pb "muzz-explore/internal/api"
+	"muzz-explore/internal/pagetoken"
 	"muzz-explore/internal/store"
 
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func ref[T any](t T) *T { return &t }
@@ -17,105 +19,205 @@ func ref[T any](t T) *T { return &t }
 type DecisionStore interface {
 	ListDecisions(ctx context.Context, filter store.DecisionFilter, page string) ([]store.Decision, string, error)
 	CountDecisions(ctx context.Context, filter store.DecisionFilter) (uint64, error)
-	UpsertDecision(ctx context.Context, decision store.Decision) error
-	MarkDecisionsAsSeen(ctx context.Context, RecipientUserID string, initPageToken, nextPageToken string) error
+	UpsertDecision(ctx context.Context, decision store.Decision) (store.DecisionType, error)
+	UpsertDecisions(ctx context.Context, decisions []store.Decision) ([]store.DecisionType, error)
+	GetDecisions(ctx context.Context, keys []store.DecisionKey) ([]store.Decision, error)
+	UndoDecision(ctx context.Context, key store.DecisionKey, notBefore int64) (store.Decision, *store.Decision, error)
+	DeleteDecision(ctx context.Context, key store.DecisionKey) (store.Decision, error)
+	Unmatch(ctx context.Context, userID, partnerUserID string) error
+	MarkDecisionsAsSeen(ctx context.Context, keys []store.DecisionKey) error
+	ListMutualMatches(ctx context.Context, userID string, page string) ([]store.Match, string, error)
 }
 
 type ServiceServer struct {
 	pb.UnimplementedExploreServiceServer
-	ds    DecisionStore
-	nowFn func() time.Time // Used to get the current time, overridden in tests.
+	ds          DecisionStore
+	pageTokens  *pagetoken.Codec
+	userIDRules UserIDRules
+	likesHub    LikesHub
+	undoWindow  time.Duration
+	maxPageSize int
+	nowFn       func() time.Time // Used to get the current time, overridden in tests.
 }
 
-func NewServiceServer(ds DecisionStore) *ServiceServer {
-	return &ServiceServer{
-		ds:    ds,
-		nowFn: time.Now,
+// DefaultUndoWindow is how long after a decision changes it can be undone, by default.
+const DefaultUndoWindow = time.Minute
+
+// DefaultMaxPageSize is the largest page of likes clients can ask for, by default.
+const DefaultMaxPageSize = 100
+
+// Option configures optional behaviour of a ServiceServer.
+type Option func(*ServiceServer)
+
+// WithPageTokenCodec sets the codec used to turn store cursors into the pagination tokens handed to
+// clients. By default, a codec with a random key is used, so tokens don't survive restarts.
+func WithPageTokenCodec(c *pagetoken.Codec) Option {
+	return func(s *ServiceServer) { s.pageTokens = c }
+}
+
+// WithLikesHub sets the hub the like events are published to, and watched from. By default, an
+// in-process MemoryHub is used, which only works with a single replica.
+func WithLikesHub(h LikesHub) Option {
+	return func(s *ServiceServer) { s.likesHub = h }
+}
+
+// WithUndoWindow sets how long after a decision changes it can be undone.
+func WithUndoWindow(d time.Duration) Option {
+	return func(s *ServiceServer) { s.undoWindow = d }
+}
+
+// WithMaxPageSize sets the largest page of likes clients can ask for. Larger pages are capped to it.
+func WithMaxPageSize(n int) Option {
+	return func(s *ServiceServer) { s.maxPageSize = n }
+}
+
+func NewServiceServer(ds DecisionStore, opts ...Option) *ServiceServer {
+	s := &ServiceServer{
+		ds:          ds,
+		userIDRules: DefaultUserIDRules,
+		undoWindow:  DefaultUndoWindow,
+		maxPageSize: DefaultMaxPageSize,
+		nowFn:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if s.pageTokens == nil {
+		s.pageTokens = pagetoken.NewRandomCodec()
+	}
+	if s.likesHub == nil {
+		s.likesHub = NewMemoryHub(DefaultWatchBufferSize, DefaultWatchHistorySize)
+	}
+	return s
 }
 
 func (s *ServiceServer) ListLikedYou(
 	ctx context.Context,
 	in *pb.ListLikedYouRequest,
 ) (*pb.ListLikedYouResponse, error) {
-	pageToken := in.GetPaginationToken()
-	decisions, nextPage, err := s.ds.ListDecisions(
-		ctx,
-		store.DecisionFilter{
-			RecipientUserID: ref(in.GetRecipientUserId()),
-			LikedRecipient:  ref(true),
-		},
-		pageToken,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list decisions: %v", err)
-	}
-	if len(decisions) == 0 {
-		return &pb.ListLikedYouResponse{}, nil
+	if err := s.validateListLikedYouRequest(in); err != nil {
+		return nil, err
 	}
-
-	// Asynchronously update decisions sent to mark them as seen.
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.ds.MarkDecisionsAsSeen(ctx, in.RecipientUserId, pageToken, nextPage); err != nil {
-			log.Warn().Err(err).Msg("failed to mark decisions as seen")
-		}
-	}()
-
-	return &pb.ListLikedYouResponse{
-		Likers:              storeToListLikedYouResponse_Liker(decisions),
-		NextPaginationToken: &nextPage,
-	}, nil
+	return s.listLikedYou(ctx, in, likedYouFilter(in))
 }
 
 func (s *ServiceServer) ListNewLikedYou(
 	ctx context.Context,
 	in *pb.ListLikedYouRequest,
 ) (*pb.ListLikedYouResponse, error) {
-	recipient := in.GetRecipientUserId()
-	pageToken := in.GetPaginationToken()
-	decisions, nextPage, err := s.ds.ListDecisions(
-		ctx,
-		store.DecisionFilter{
-			RecipientUserID: &recipient,
-			LikedRecipient:  ref(true),
-			SeenByRecipient: ref(false),
-		},
-		pageToken,
-	)
+	if err := s.validateListLikedYouRequest(in); err != nil {
+		return nil, err
+	}
+	return s.listLikedYou(ctx, in, newLikesFilter(in))
+}
+
+// listLikedYou lists a page of the likes matching the filter, with pages of the size requested up to
+// the maximum of the server. The likes across all the pages are only counted if the client asks for it.
+func (s *ServiceServer) listLikedYou(
+	ctx context.Context,
+	in *pb.ListLikedYouRequest,
+	filter store.DecisionFilter,
+) (*pb.ListLikedYouResponse, error) {
+	pageToken, err := s.decodePageToken(in.GetPaginationToken())
 	if err != nil {
-		return nil, fmt.Errorf("failed to list decisions: %v", err)
+		return nil, err
+	}
+	if in.PageSize != nil {
+		filter.PageSize = min(int(in.GetPageSize()), s.maxPageSize)
+	}
+	decisions, nextPage, err := s.ds.ListDecisions(ctx, filter, pageToken)
+	if err != nil {
+		return nil, storeError(err, "failed to list decisions")
+	}
+	resp := &pb.ListLikedYouResponse{}
+	if in.GetIncludeTotalCount() {
+		count, err := s.ds.CountDecisions(ctx, filter)
+		if err != nil {
+			return nil, storeError(err, "failed to count decisions")
+		}
+		resp.TotalCount = &count
 	}
 	if len(decisions) == 0 {
-		return &pb.ListLikedYouResponse{}, nil
+		return resp, nil
+	}
+	nextPageToken, err := s.encodePageToken(nextPage)
+	if err != nil {
+		return nil, err
 	}
 
-	// Asynchronously update decisions sent to mark them as seen.
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.ds.MarkDecisionsAsSeen(ctx, recipient, pageToken, nextPage); err != nil {
-			log.Warn().Err(err).Msg("failed to mark decisions as seen")
-		}
-	}()
+	if in.GetAcknowledgementMode() != pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT {
+		s.markDecisionsAsSeenAsync(decisions)
+	}
+
+	resp.Likers = storeToListLikedYouResponse_Liker(decisions)
+	resp.NextPaginationToken = &nextPageToken
+	return resp, nil
+}
 
-	return &pb.ListLikedYouResponse{
-		Likers:              storeToListLikedYouResponse_Liker(decisions),
-		NextPaginationToken: &nextPage,
+// ListYouLiked lists the decisions of the actor on other users, newest first. Pending likes are the
+// ones not liked in return yet.
+func (s *ServiceServer) ListYouLiked(
+	ctx context.Context,
+	in *pb.ListYouLikedRequest,
+) (*pb.ListYouLikedResponse, error) {
+	if err := s.validateListYouLikedRequest(in); err != nil {
+		return nil, err
+	}
+	pageToken, err := s.decodePageToken(in.GetPaginationToken())
+	if err != nil {
+		return nil, err
+	}
+	decisions, nextPage, err := s.ds.ListDecisions(ctx, youLikedFilter(in), pageToken)
+	if err != nil {
+		return nil, storeError(err, "failed to list decisions")
+	}
+	if len(decisions) == 0 {
+		return &pb.ListYouLikedResponse{}, nil
+	}
+	nextPageToken, err := s.encodePageToken(nextPage)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListYouLikedResponse{
+		Decisions:           storeToListYouLikedResponse_Decision(decisions),
+		NextPaginationToken: &nextPageToken,
 	}, nil
 }
 
+// youLikedFilter builds the filter of the decisions of the actor matching a ListYouLiked request. The
+// users that blocked the actor are left out, as blocks hide both users from each other.
+func youLikedFilter(in *pb.ListYouLikedRequest) store.DecisionFilter {
+	filter := store.DecisionFilter{ActorUserID: ref(in.GetActorUserId()), ExcludeBlocked: true}
+	for _, decisionType := range in.GetDecisionTypes() {
+		filter.Types = append(filter.Types, store.DecisionType(decisionType))
+	}
+	if in.GetPendingOnly() {
+		filter.LikedRecipient = ref(true)
+		filter.ExcludeReciprocated = true
+	}
+	if in.GetSinceUnixTimestamp() != 0 {
+		filter.ModifiedSince = ref(int64(in.GetSinceUnixTimestamp()))
+	}
+	if in.GetUntilUnixTimestamp() != 0 {
+		filter.ModifiedUntil = ref(int64(in.GetUntilUnixTimestamp()))
+	}
+	return filter
+}
+
 func (s *ServiceServer) CountLikedYou(
 	ctx context.Context,
 	in *pb.CountLikedYouRequest,
 ) (*pb.CountLikedYouResponse, error) {
+	if err := s.validateCountLikedYouRequest(in); err != nil {
+		return nil, err
+	}
 	count, err := s.ds.CountDecisions(ctx, store.DecisionFilter{
 		RecipientUserID: ref(in.GetRecipientUserId()),
 		LikedRecipient:  ref(true),
+		ExcludeBlocked:  true,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to count decisions: %v", err)
+		return nil, storeError(err, "failed to count decisions")
 	}
 	return &pb.CountLikedYouResponse{Count: count}, nil
 }
@@ -124,41 +226,519 @@ func (s *ServiceServer) PutDecision(
 	ctx context.Context,
 	in *pb.PutDecisionRequest,
 ) (*pb.PutDecisionResponse, error) {
-	err := s.ds.UpsertDecision(ctx, store.Decision{
-		ActorUserID:     in.GetActorUserId(),
-		RecipientUserID: in.GetRecipientUserId(),
-		LikedRecipient:  in.GetLikedRecipient(),
-		LastModified:    s.nowFn().Unix(),
-	})
+	if err := s.validatePutDecisionRequest(in); err != nil {
+		return nil, err
+	}
+	resp, _, err := s.putDecision(ctx, in)
+	return resp, err
+}
+
+// putDecision records a validated decision, and checks if it completed a mutual like. A like on a
+// user that blocked its actor is recorded, but never completes a match nor reaches that user. A pass
+// or a block replacing the like of a match dissolves it, and both users get an unmatch event. It also
+// tells if the decision completed a match just now, rather than re-submitting a like of a match. If
+// the check fails, the decision is still recorded and the response is returned along with the error.
+func (s *ServiceServer) putDecision(
+	ctx context.Context,
+	in *pb.PutDecisionRequest,
+) (*pb.PutDecisionResponse, bool, error) {
+	now := s.nowFn().Unix()
+	decision := toStoreDecision(in, now)
+	previous, err := s.ds.UpsertDecision(ctx, decision)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upsert decision: %v", err)
+		return nil, false, storeError(err, "failed to upsert decision")
 	}
 
-	// Check if it's mutual.
+	// A like turned into a pass or a block dissolves the match it completed.
 	resp := pb.PutDecisionResponse{MutualLikes: false}
-	if in.GetLikedRecipient() {
-		decisions, _, err := s.ds.ListDecisions(ctx, store.DecisionFilter{
-			ActorUserID:     ref(in.GetRecipientUserId()),
-			RecipientUserID: ref(in.GetActorUserId()),
-			LikedRecipient:  ref(true),
-		}, "")
+	if !decision.LikedRecipient && previous.Liked() {
+		replaced := decision
+		replaced.LikedRecipient, replaced.Type = true, previous
+		s.publishUnmatchIfMutual(ctx, replaced, now)
+	}
+
+	// Check if it's mutual, or if the recipient blocked the actor.
+	if decision.LikedRecipient {
+		reverseDecisions, err := s.ds.GetDecisions(ctx, []store.DecisionKey{reverseKey(decision)})
 		if err != nil {
-			return &resp, fmt.Errorf("failed to check if it's mutual: %v", err)
+			// Not published, as the recipient may have blocked the actor.
+			return &resp, false, storeError(err, "failed to check if it's mutual")
+		}
+		var reverseType store.DecisionType
+		if len(reverseDecisions) > 0 {
+			reverseType = reverseDecisions[0].Type
+		}
+		if reverseType != store.DecisionBlock {
+			resp.MutualLikes = reverseType.Liked()
+			s.publishDecision(decision, previous, resp.MutualLikes)
+		}
+	}
+	return &resp, resp.MutualLikes && !previous.Liked(), nil
+}
+
+// PutDecisions records a batch of decisions in a single transaction. Invalid decisions are reported
+// in their result and left out, while a failure of the store fails the whole batch. A failure to
+// check if the likes are mutual once they are recorded is reported in the mutual check error of the
+// result of each of them.
+func (s *ServiceServer) PutDecisions(
+	ctx context.Context,
+	in *pb.PutDecisionsRequest,
+) (*pb.PutDecisionsResponse, error) {
+	if err := s.validatePutDecisionsRequest(in); err != nil {
+		return nil, err
+	}
+	now := s.nowFn().Unix()
+	resp := pb.PutDecisionsResponse{Results: make([]*pb.PutDecisionsResponse_Result, len(in.GetDecisions()))}
+	decisions := make([]store.Decision, 0, len(in.GetDecisions()))
+	positions := make([]int, 0, len(in.GetDecisions())) // Position in the request of each decision.
+	for i, decision := range in.GetDecisions() {
+		resp.Results[i] = &pb.PutDecisionsResponse_Result{}
+		if err := s.validatePutDecisionRequest(decision); err != nil {
+			resp.Results[i].Error = toResultError(err)
+			continue
 		}
-		if len(decisions) > 0 {
-			resp.MutualLikes = true
+		decisions = append(decisions, toStoreDecision(decision, now))
+		positions = append(positions, i)
+	}
+	if len(decisions) == 0 {
+		return &resp, nil
+	}
+	previous, err := s.ds.UpsertDecisions(ctx, decisions)
+	if err != nil {
+		return nil, storeError(err, "failed to upsert decisions")
+	}
+
+	// Check which likes are mutual, or blocked by their recipient, and which likes turned into a pass
+	// or a block dissolved a match, looking up all the reverse decisions at once.
+	var reverseKeys []store.DecisionKey
+	for i, decision := range decisions {
+		if decision.LikedRecipient || previous[i].Liked() {
+			reverseKeys = append(reverseKeys, reverseKey(decision))
+		}
+	}
+	if len(reverseKeys) == 0 {
+		return &resp, nil
+	}
+	reverseDecisions, err := s.ds.GetDecisions(ctx, reverseKeys)
+	if err != nil {
+		// The decisions are recorded, so the ones that needed the check get the error apart from the
+		// one of the decisions not recorded. They are not published, as their recipients may have
+		// blocked the actors.
+		checkErr := toResultError(storeError(err, "failed to check if they are mutual"))
+		for i, decision := range decisions {
+			if decision.LikedRecipient || previous[i].Liked() {
+				resp.Results[positions[i]].MutualCheckError = checkErr
+			}
+		}
+		return &resp, nil
+	}
+	reverseTypes := make(map[store.DecisionKey]store.DecisionType, len(reverseDecisions))
+	for _, decision := range reverseDecisions {
+		reverseTypes[decision.Key()] = decision.Type
+	}
+	for i, decision := range decisions {
+		reverseType := reverseTypes[reverseKey(decision)]
+		switch {
+		case !decision.LikedRecipient:
+			if previous[i].Liked() && reverseType.Liked() {
+				s.publishUnmatch(decision.Key(), now)
+			}
+		case reverseType != store.DecisionBlock:
+			resp.Results[positions[i]].MutualLikes = reverseType.Liked()
+			s.publishDecision(decision, previous[i], reverseType.Liked())
 		}
 	}
 	return &resp, nil
 }
 
+// UndoDecision reverts the last change of the decision of the actor on the recipient, as long as it
+// happened within the undo window. If the decision undone completed a match, the match is dissolved
+// and both users get an unmatch event, while if it dissolved one, by replacing a like with a pass or a
+// block, the match is back and both users get a match event.
+func (s *ServiceServer) UndoDecision(
+	ctx context.Context,
+	in *pb.UndoDecisionRequest,
+) (*pb.UndoDecisionResponse, error) {
+	if err := s.validateUndoDecisionRequest(in); err != nil {
+		return nil, err
+	}
+	now := s.nowFn()
+	undone, restored, err := s.ds.UndoDecision(
+		ctx,
+		store.DecisionKey{ActorUserID: in.GetActorUserId(), RecipientUserID: in.GetRecipientUserId()},
+		now.Add(-s.undoWindow).Unix(),
+	)
+	if err != nil {
+		return nil, storeError(err, "failed to undo decision")
+	}
+	resp := &pb.UndoDecisionResponse{}
+	if restored != nil {
+		resp.DecisionType = pb.DecisionType(restored.Type)
+	}
+
+	switch {
+	case restored == nil || !restored.LikedRecipient:
+		// Undoing a like dissolves the match it completed, unless it restores another like.
+		s.publishUnmatchIfMutual(ctx, undone, now.Unix())
+	case !undone.LikedRecipient:
+		// Undoing a pass or a block restoring a like brings back the match it dissolved.
+		s.publishMatchIfMutual(ctx, *restored, now.Unix())
+	}
+	return resp, nil
+}
+
+// DeleteDecision deletes the decision of the actor on the recipient. If it completed a match, the
+// match is dissolved and both users get an unmatch event, but the like of the recipient is kept.
+func (s *ServiceServer) DeleteDecision(
+	ctx context.Context,
+	in *pb.DeleteDecisionRequest,
+) (*pb.DeleteDecisionResponse, error) {
+	if err := s.validateDeleteDecisionRequest(in); err != nil {
+		return nil, err
+	}
+	deleted, err := s.ds.DeleteDecision(
+		ctx,
+		store.DecisionKey{ActorUserID: in.GetActorUserId(), RecipientUserID: in.GetRecipientUserId()},
+	)
+	if err != nil {
+		return nil, storeError(err, "failed to delete decision")
+	}
+	s.publishUnmatchIfMutual(ctx, deleted, s.nowFn().Unix())
+	return &pb.DeleteDecisionResponse{}, nil
+}
+
+// Unmatch dissolves the match of two users, deleting the likes of both, so neither shows up in the
+// lists of the other, and they only match again if both like each other anew.
+func (s *ServiceServer) Unmatch(ctx context.Context, in *pb.UnmatchRequest) (*pb.UnmatchResponse, error) {
+	if err := s.validateUnmatchRequest(in); err != nil {
+		return nil, err
+	}
+	if err := s.ds.Unmatch(ctx, in.GetUserId(), in.GetPartnerUserId()); err != nil {
+		return nil, storeError(err, "failed to unmatch")
+	}
+	s.publishUnmatch(
+		store.DecisionKey{ActorUserID: in.GetUserId(), RecipientUserID: in.GetPartnerUserId()},
+		s.nowFn().Unix(),
+	)
+	return &pb.UnmatchResponse{}, nil
+}
+
+// GetRelationship returns the decisions between the viewer and each of the other users, looking them
+// all up at once. A block of the viewer is reported as no decision, so it's not disclosed to them.
+func (s *ServiceServer) GetRelationship(
+	ctx context.Context,
+	in *pb.GetRelationshipRequest,
+) (*pb.GetRelationshipResponse, error) {
+	if err := s.validateGetRelationshipRequest(in); err != nil {
+		return nil, err
+	}
+	if len(in.GetOtherUserIds()) == 0 {
+		return &pb.GetRelationshipResponse{}, nil
+	}
+	viewer := in.GetViewerUserId()
+	keys := make([]store.DecisionKey, 0, 2*len(in.GetOtherUserIds()))
+	for _, other := range in.GetOtherUserIds() {
+		keys = append(keys,
+			store.DecisionKey{ActorUserID: viewer, RecipientUserID: other},
+			store.DecisionKey{ActorUserID: other, RecipientUserID: viewer},
+		)
+	}
+	decisions, err := s.ds.GetDecisions(ctx, keys)
+	if err != nil {
+		return nil, storeError(err, "failed to get decisions")
+	}
+	byKey := make(map[store.DecisionKey]store.Decision, len(decisions))
+	for _, decision := range decisions {
+		byKey[decision.Key()] = decision
+	}
+
+	resp := &pb.GetRelationshipResponse{
+		Relationships: make([]*pb.GetRelationshipResponse_Relationship, 0, len(in.GetOtherUserIds())),
+	}
+	for _, other := range in.GetOtherUserIds() {
+		viewerDecision := byKey[store.DecisionKey{ActorUserID: viewer, RecipientUserID: other}]
+		otherDecision := byKey[store.DecisionKey{ActorUserID: other, RecipientUserID: viewer}]
+		relationship := &pb.GetRelationshipResponse_Relationship{
+			OtherUserId:        other,
+			ViewerDecisionType: pb.DecisionType(viewerDecision.Type),
+			Matched:            viewerDecision.LikedRecipient && otherDecision.LikedRecipient,
+		}
+		if otherDecision.Type != store.DecisionBlock {
+			relationship.OtherDecisionType = pb.DecisionType(otherDecision.Type)
+		}
+		resp.Relationships = append(resp.Relationships, relationship)
+	}
+	return resp, nil
+}
+
+func (s *ServiceServer) ListMutualMatches(
+	ctx context.Context,
+	in *pb.ListMutualMatchesRequest,
+) (*pb.ListMutualMatchesResponse, error) {
+	if err := s.validateListMutualMatchesRequest(in); err != nil {
+		return nil, err
+	}
+	pageToken, err := s.decodePageToken(in.GetPaginationToken())
+	if err != nil {
+		return nil, err
+	}
+	matches, nextPage, err := s.ds.ListMutualMatches(ctx, in.GetUserId(), pageToken)
+	if err != nil {
+		return nil, storeError(err, "failed to list mutual matches")
+	}
+	if len(matches) == 0 {
+		return &pb.ListMutualMatchesResponse{}, nil
+	}
+	nextPageToken, err := s.encodePageToken(nextPage)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListMutualMatchesResponse{
+		Matches:             storeToListMutualMatchesResponse_Match(matches),
+		NextPaginationToken: &nextPageToken,
+	}, nil
+}
+
+func (s *ServiceServer) AcknowledgeLikes(
+	ctx context.Context,
+	in *pb.AcknowledgeLikesRequest,
+) (*pb.AcknowledgeLikesResponse, error) {
+	if err := s.validateAcknowledgeLikesRequest(in); err != nil {
+		return nil, err
+	}
+	if len(in.GetActorUserIds()) == 0 {
+		return &pb.AcknowledgeLikesResponse{}, nil
+	}
+	keys := make([]store.DecisionKey, 0, len(in.GetActorUserIds()))
+	for _, actor := range in.GetActorUserIds() {
+		keys = append(keys, store.DecisionKey{ActorUserID: actor, RecipientUserID: in.GetRecipientUserId()})
+	}
+	if err := s.ds.MarkDecisionsAsSeen(ctx, keys); err != nil {
+		return nil, storeError(err, "failed to acknowledge likes")
+	}
+	return &pb.AcknowledgeLikesResponse{}, nil
+}
+
+// likedYouFilter builds the filter of the likes of the recipient, in the order and time range of the
+// request.
+func likedYouFilter(in *pb.ListLikedYouRequest) store.DecisionFilter {
+	filter := store.DecisionFilter{
+		RecipientUserID: ref(in.GetRecipientUserId()),
+		LikedRecipient:  ref(true),
+		ExcludeBlocked:  true,
+	}
+	switch in.GetSortOrder() {
+	case pb.SortOrder_SORT_ORDER_NEWEST:
+		filter.Sort = store.SortNewest
+	case pb.SortOrder_SORT_ORDER_OLDEST:
+		filter.Sort = store.SortOldest
+	}
+	if in.GetSinceUnixTimestamp() != 0 {
+		filter.ModifiedSince = ref(int64(in.GetSinceUnixTimestamp()))
+	}
+	if in.GetUntilUnixTimestamp() != 0 {
+		filter.ModifiedUntil = ref(int64(in.GetUntilUnixTimestamp()))
+	}
+	return filter
+}
+
+// newLikesFilter returns the filter of the likes considered new by a ListNewLikedYou request.
+func newLikesFilter(in *pb.ListLikedYouRequest) store.DecisionFilter {
+	filter := likedYouFilter(in)
+	switch in.GetNewLikesFilter() {
+	case pb.NewLikesFilter_NEW_LIKES_FILTER_UNRECIPROCATED:
+		filter.ExcludeReciprocated = true
+	case pb.NewLikesFilter_NEW_LIKES_FILTER_UNSEEN_AND_UNRECIPROCATED:
+		filter.SeenByRecipient = ref(false)
+		filter.ExcludeReciprocated = true
+	default:
+		filter.SeenByRecipient = ref(false)
+	}
+	return filter
+}
+
+// decisionType returns the type of a decision requested, taking it from liked_recipient for clients
+// that don't set it.
+func decisionType(in *pb.PutDecisionRequest) store.DecisionType {
+	switch in.GetDecisionType() {
+	case pb.DecisionType_DECISION_TYPE_UNSPECIFIED:
+		if in.GetLikedRecipient() {
+			return store.DecisionLike
+		}
+		return store.DecisionPass
+	default:
+		return store.DecisionType(in.GetDecisionType())
+	}
+}
+
+// toStoreDecision returns the decision to record for a validated request.
+func toStoreDecision(in *pb.PutDecisionRequest, now int64) store.Decision {
+	decisionType := decisionType(in)
+	decision := store.Decision{
+		ActorUserID:     in.GetActorUserId(),
+		RecipientUserID: in.GetRecipientUserId(),
+		LikedRecipient:  decisionType.Liked(),
+		LastModified:    now,
+		CreatedAt:       now, // Only used if it's the first decision of the actor on the recipient.
+		Type:            decisionType,
+	}
+	if decision.LikedRecipient {
+		decision.LikedAt = now // Only used if it wasn't a like already.
+	}
+	return decision
+}
+
+// reverseKey returns the key of the decision of the recipient on the actor.
+func reverseKey(decision store.Decision) store.DecisionKey {
+	return store.DecisionKey{ActorUserID: decision.RecipientUserID, RecipientUserID: decision.ActorUserID}
+}
+
+// publishDecision publishes the events of a like recorded over a decision of the previous type: a
+// like for its recipient if it's new to them or, if it completed a match, a match for both users.
+// Re-submitting a like publishes nothing, as it changes nothing. Failing to publish doesn't fail the
+// decision, as watchers can still catch up by listing the new likes.
+func (s *ServiceServer) publishDecision(decision store.Decision, previous store.DecisionType, mutual bool) {
+	switch {
+	case mutual && !previous.Liked():
+		s.publishMatch(decision.Key(), decision.LastModified)
+	case !mutual && (!previous.Liked() || previous != store.DecisionSuperLike && decision.Type == store.DecisionSuperLike):
+		// A like is new again when it's upgraded to a super-like, as the store does.
+		s.publish(LikeEvent{
+			RecipientUserID: decision.RecipientUserID,
+			ActorUserID:     decision.ActorUserID,
+			SuperLike:       decision.Type == store.DecisionSuperLike,
+			Timestamp:       decision.LastModified,
+		})
+	}
+}
+
+// publishUnmatchIfMutual publishes the unmatch events of a like removed, if its recipient liked the
+// actor back. The decision is already gone, so failing to check it is only logged rather than failing
+// the request, whose retry would remove another version of the decision. The partners still find out
+// when listing their matches.
+func (s *ServiceServer) publishUnmatchIfMutual(ctx context.Context, removed store.Decision, timestamp int64) {
+	if !removed.LikedRecipient {
+		return
+	}
+	reverseDecisions, err := s.ds.GetDecisions(ctx, []store.DecisionKey{reverseKey(removed)})
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to check if the decision removed was mutual")
+		return
+	}
+	if len(reverseDecisions) > 0 && reverseDecisions[0].LikedRecipient {
+		s.publishUnmatch(removed.Key(), timestamp)
+	}
+}
+
+// publishMatchIfMutual publishes the match events of a like restored, if its recipient liked the
+// actor back. As for publishUnmatchIfMutual, failing to check it is only logged.
+func (s *ServiceServer) publishMatchIfMutual(ctx context.Context, restored store.Decision, timestamp int64) {
+	reverseDecisions, err := s.ds.GetDecisions(ctx, []store.DecisionKey{reverseKey(restored)})
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to check if the decision restored is mutual")
+		return
+	}
+	if len(reverseDecisions) > 0 && reverseDecisions[0].LikedRecipient {
+		s.publishMatch(restored.Key(), timestamp)
+	}
+}
+
+// publishMatch publishes the events of a match to both of its users.
+func (s *ServiceServer) publishMatch(key store.DecisionKey, timestamp int64) {
+	s.publish(
+		LikeEvent{RecipientUserID: key.RecipientUserID, ActorUserID: key.ActorUserID, Kind: LikeEventMatch, Timestamp: timestamp},
+		LikeEvent{RecipientUserID: key.ActorUserID, ActorUserID: key.RecipientUserID, Kind: LikeEventMatch, Timestamp: timestamp},
+	)
+}
+
+// publishUnmatch publishes the events of a match dissolved to both of its users.
+func (s *ServiceServer) publishUnmatch(key store.DecisionKey, timestamp int64) {
+	s.publish(
+		LikeEvent{RecipientUserID: key.RecipientUserID, ActorUserID: key.ActorUserID, Kind: LikeEventUnmatch, Timestamp: timestamp},
+		LikeEvent{RecipientUserID: key.ActorUserID, ActorUserID: key.RecipientUserID, Kind: LikeEventUnmatch, Timestamp: timestamp},
+	)
+}
+
+// publish hands events to the likes hub. Failing to publish is only logged, as watchers can catch up
+// by listing.
+func (s *ServiceServer) publish(events ...LikeEvent) {
+	for _, event := range events {
+		// Not bound to the context of the request, so a cancelled caller doesn't lose the event.
+		if err := s.likesHub.Publish(context.Background(), event); err != nil {
+			log.Warn().Err(err).Str("recipient", event.RecipientUserID).Msg("failed to publish like event")
+		}
+	}
+}
+
+// markDecisionsAsSeenAsync marks exactly the decisions returned in a page as seen in the background,
+// as there's no need to make the caller wait for that update to be done. Used by the implicit
+// acknowledgement mode.
+func (s *ServiceServer) markDecisionsAsSeenAsync(decisions []store.Decision) {
+	keys := make([]store.DecisionKey, 0, len(decisions))
+	for _, decision := range decisions {
+		keys = append(keys, decision.Key())
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.ds.MarkDecisionsAsSeen(ctx, keys); err != nil {
+			log.Warn().Err(err).Msg("failed to mark decisions as seen")
+		}
+	}()
+}
+
+// decodePageToken returns the store cursor carried by a pagination token received from a client.
+func (s *ServiceServer) decodePageToken(token string) (string, error) {
+	cursor, err := s.pageTokens.Decode(token)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, "invalid pagination token")
+	}
+	return cursor, nil
+}
+
+// encodePageToken turns a store cursor into the opaque pagination token handed to clients.
+func (s *ServiceServer) encodePageToken(cursor string) (string, error) {
+	token, err := s.pageTokens.Encode(cursor)
+	if err != nil {
+		log.Err(err).Msg("failed to encode pagination token")
+		return "", status.Error(codes.Internal, "failed to encode pagination token")
+	}
+	return token, nil
+}
+
 func storeToListLikedYouResponse_Liker(decisions []store.Decision) []*pb.ListLikedYouResponse_Liker {
 	var likers []*pb.ListLikedYouResponse_Liker
 	for _, decision := range decisions {
 		likers = append(likers, &pb.ListLikedYouResponse_Liker{
-			ActorId:       decision.ActorUserID,
-			UnixTimestamp: uint64(decision.LastModified),
+			ActorId:              decision.ActorUserID,
+			UnixTimestamp:        uint64(decision.LastModified),
+			CreatedUnixTimestamp: uint64(decision.CreatedAt),
+			DecisionType:         pb.DecisionType(decision.Type),
 		})
 	}
 	return likers
 }
+
+func storeToListYouLikedResponse_Decision(decisions []store.Decision) []*pb.ListYouLikedResponse_Decision {
+	var pbDecisions []*pb.ListYouLikedResponse_Decision
+	for _, decision := range decisions {
+		pbDecisions = append(pbDecisions, &pb.ListYouLikedResponse_Decision{
+			RecipientId:          decision.RecipientUserID,
+			DecisionType:         pb.DecisionType(decision.Type),
+			UnixTimestamp:        uint64(decision.LastModified),
+			CreatedUnixTimestamp: uint64(decision.CreatedAt),
+		})
+	}
+	return pbDecisions
+}
+
+func storeToListMutualMatchesResponse_Match(matches []store.Match) []*pb.ListMutualMatchesResponse_Match {
+	var pbMatches []*pb.ListMutualMatchesResponse_Match
+	for _, match := range matches {
+		pbMatches = append(pbMatches, &pb.ListMutualMatchesResponse_Match{
+			PartnerId:     match.PartnerUserID,
+			UnixTimestamp: uint64(match.MatchedAt),
+		})
+	}
+	return pbMatches
+}
@@ -4,16 +4,38 @@ import (
 	"context"
 	"fmt"
 	pb "muzz-explore/internal/api"
+	"muzz-explore/internal/pagetoken"
 	"muzz-explore/internal/store"
 	"muzz-explore/server/mocks"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+var testPageTokens = func() *pagetoken.Codec {
+	c, err := pagetoken.NewCodec([]byte("0123456789abcdef0123456789abcdef"), false)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+// testPageToken returns the pagination token handed to clients for a store cursor.
+func testPageToken(cursor string) string {
+	token, err := testPageTokens.Encode(cursor)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
 func TestListLikedYou(t *testing.T) {
 	testMap := map[string]struct {
 		storeReturnedDecisions []store.Decision
@@ -33,27 +55,45 @@ func TestListLikedYou(t *testing.T) {
 		"multiple decisions": {
 			in: &pb.ListLikedYouRequest{RecipientUserId: "user1"},
 			storeReturnedDecisions: []store.Decision{
-				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
-				{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: false, LastModified: 2},
-				{ActorUserID: "user4", RecipientUserID: "user1", LikedRecipient: false, LastModified: 3},
+				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1, CreatedAt: 1, Type: store.DecisionSuperLike},
+				{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: true, LastModified: 2, CreatedAt: 1, Type: store.DecisionLike},
+				{ActorUserID: "user4", RecipientUserID: "user1", LikedRecipient: true, LastModified: 3, CreatedAt: 2, Type: store.DecisionLike},
 			},
 			storeReturnedPageToken: "user4##user1",
 			storeReturnedError:     nil,
 			wantErr:                nil,
+			want: &pb.ListLikedYouResponse{
+				Likers: []*pb.ListLikedYouResponse_Liker{
+					{ActorId: "user2", UnixTimestamp: 1, CreatedUnixTimestamp: 1, DecisionType: pb.DecisionType_DECISION_TYPE_SUPER_LIKE},
+					{ActorId: "user3", UnixTimestamp: 2, CreatedUnixTimestamp: 1, DecisionType: pb.DecisionType_DECISION_TYPE_LIKE},
+					{ActorId: "user4", UnixTimestamp: 3, CreatedUnixTimestamp: 2, DecisionType: pb.DecisionType_DECISION_TYPE_LIKE},
+				},
+				NextPaginationToken: ref(testPageToken("user4##user1")),
+			},
+		},
+		"explicit acknowledgement": {
+			in: &pb.ListLikedYouRequest{
+				RecipientUserId:     "user1",
+				AcknowledgementMode: pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT,
+			},
+			storeReturnedDecisions: []store.Decision{
+				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
+			},
+			storeReturnedPageToken: "user2##user1",
+			storeReturnedError:     nil,
+			wantErr:                nil,
 			want: &pb.ListLikedYouResponse{
 				Likers: []*pb.ListLikedYouResponse_Liker{
 					{ActorId: "user2", UnixTimestamp: 1},
-					{ActorId: "user3", UnixTimestamp: 2},
-					{ActorId: "user4", UnixTimestamp: 3},
 				},
-				NextPaginationToken: ref("user4##user1"),
+				NextPaginationToken: ref(testPageToken("user2##user1")),
 			},
 		},
 		"error listing decisions": {
 			in:                     &pb.ListLikedYouRequest{RecipientUserId: "user1"},
 			storeReturnedDecisions: nil,
 			storeReturnedError:     fmt.Errorf("some error"),
-			wantErr:                fmt.Errorf("failed to list decisions: some error"),
+			wantErr:                status.Error(codes.Internal, "failed to list decisions"),
 			want:                   nil,
 		},
 	}
@@ -62,25 +102,31 @@ func TestListLikedYou(t *testing.T) {
 			// GIVEN: A Server with some preconditions.
 			ctx := context.Background()
 			dsMock := mocks.NewDecisionStore(t)
-			inPaginationToken := tc.in.GetPaginationToken()
+			inPaginationToken, err := testPageTokens.Decode(tc.in.GetPaginationToken())
+			require.NoError(t, err)
 			dsMock.EXPECT().ListDecisions(
 				ctx,
 				store.DecisionFilter{
 					RecipientUserID: ref("user1"),
 					LikedRecipient:  ref(true),
+					ExcludeBlocked:  true,
 				},
 				inPaginationToken,
 			).Return(tc.storeReturnedDecisions, tc.storeReturnedPageToken, tc.storeReturnedError)
-			if tc.storeReturnedError == nil && tc.want.GetNextPaginationToken() != "" {
+			implicitAck := tc.in.GetAcknowledgementMode() != pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT
+			if tc.storeReturnedError == nil && len(tc.storeReturnedDecisions) > 0 && implicitAck {
+				// Exactly the returned decisions are marked as seen.
+				keys := []store.DecisionKey{}
+				for _, decision := range tc.storeReturnedDecisions {
+					keys = append(keys, decision.Key())
+				}
 				// As error is only logged, we don't care about the return value.
 				dsMock.EXPECT().MarkDecisionsAsSeen(
 					mock.Anything, // context internally created
-					"user1",
-					inPaginationToken,
-					tc.want.GetNextPaginationToken(),
+					keys,
 				).Return(nil)
 			}
-			s := NewServiceServer(dsMock)
+			s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens))
 
 			// WHEN: ListLikedYou is called.
 			got, err := s.ListLikedYou(ctx, tc.in)
@@ -93,12 +139,113 @@ func TestListLikedYou(t *testing.T) {
 	}
 }
 
+func TestListLikedYouPageSizeAndTotalCount(t *testing.T) {
+	testMap := map[string]struct {
+		in                       *pb.ListLikedYouRequest
+		decisionStoreMockFactory func(ctx context.Context, filter store.DecisionFilter) DecisionStore
+		wantErr                  error
+		want                     *pb.ListLikedYouResponse
+	}{
+		"page size under the maximum": {
+			in: &pb.ListLikedYouRequest{RecipientUserId: "user1", PageSize: ref(uint32(20))},
+			decisionStoreMockFactory: func(ctx context.Context, filter store.DecisionFilter) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				filter.PageSize = 20
+				dsMock.EXPECT().ListDecisions(ctx, filter, "").Return(nil, "", nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.ListLikedYouResponse{},
+		},
+		"page size over the maximum": {
+			in: &pb.ListLikedYouRequest{RecipientUserId: "user1", PageSize: ref(uint32(1000))},
+			decisionStoreMockFactory: func(ctx context.Context, filter store.DecisionFilter) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				filter.PageSize = 50
+				dsMock.EXPECT().ListDecisions(ctx, filter, "").Return(nil, "", nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.ListLikedYouResponse{},
+		},
+		"total count": {
+			in: &pb.ListLikedYouRequest{
+				RecipientUserId:     "user1",
+				AcknowledgementMode: pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT,
+				IncludeTotalCount:   true,
+			},
+			decisionStoreMockFactory: func(ctx context.Context, filter store.DecisionFilter) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().ListDecisions(ctx, filter, "").Return([]store.Decision{
+					{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
+				}, "2##user2", nil)
+				dsMock.EXPECT().CountDecisions(ctx, filter).Return(12, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want: &pb.ListLikedYouResponse{
+				Likers:              []*pb.ListLikedYouResponse_Liker{{ActorId: "user2", UnixTimestamp: 1}},
+				NextPaginationToken: ref(testPageToken("2##user2")),
+				TotalCount:          ref(uint64(12)),
+			},
+		},
+		"total count without likes": {
+			in: &pb.ListLikedYouRequest{RecipientUserId: "user1", IncludeTotalCount: true},
+			decisionStoreMockFactory: func(ctx context.Context, filter store.DecisionFilter) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().ListDecisions(ctx, filter, "").Return(nil, "", nil)
+				dsMock.EXPECT().CountDecisions(ctx, filter).Return(0, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.ListLikedYouResponse{TotalCount: ref(uint64(0))},
+		},
+		"error counting decisions": {
+			in: &pb.ListLikedYouRequest{RecipientUserId: "user1", IncludeTotalCount: true},
+			decisionStoreMockFactory: func(ctx context.Context, filter store.DecisionFilter) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().ListDecisions(ctx, filter, "").Return([]store.Decision{
+					{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
+				}, "2##user2", nil)
+				dsMock.EXPECT().CountDecisions(ctx, filter).Return(0, fmt.Errorf("some error: %w", store.ErrDeadline))
+				return dsMock
+			},
+			wantErr: status.Error(codes.DeadlineExceeded, "failed to count decisions"),
+			want:    nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with a maximum page size, and some preconditions.
+			ctx := context.Background()
+			filter := store.DecisionFilter{
+				RecipientUserID: ref("user1"),
+				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
+			}
+			s := NewServiceServer(
+				tc.decisionStoreMockFactory(ctx, filter),
+				WithPageTokenCodec(testPageTokens),
+				WithMaxPageSize(50),
+			)
+
+			// WHEN: ListLikedYou is called.
+			got, err := s.ListLikedYou(ctx, tc.in)
+
+			// THEN: The result should match the expectations.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestListNewLikedYou(t *testing.T) {
 	testMap := map[string]struct {
 		storeReturnedDecisions []store.Decision
 		storeReturnedPageToken string
 		storeReturnedError     error
 		in                     *pb.ListLikedYouRequest
+		wantFilter             *store.DecisionFilter // Unseen likes if not set.
 		wantErr                error
 		want                   *pb.ListLikedYouResponse
 	}{
@@ -112,27 +259,116 @@ func TestListNewLikedYou(t *testing.T) {
 		"multiple decisions": {
 			in: &pb.ListLikedYouRequest{RecipientUserId: "user1"},
 			storeReturnedDecisions: []store.Decision{
-				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
-				{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: false, LastModified: 2},
-				{ActorUserID: "user4", RecipientUserID: "user1", LikedRecipient: false, LastModified: 3},
+				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1, CreatedAt: 1},
+				{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: false, LastModified: 2, CreatedAt: 1},
+				{ActorUserID: "user4", RecipientUserID: "user1", LikedRecipient: false, LastModified: 3, CreatedAt: 2},
 			},
 			storeReturnedPageToken: "user4##user1",
 			storeReturnedError:     nil,
 			wantErr:                nil,
+			want: &pb.ListLikedYouResponse{
+				Likers: []*pb.ListLikedYouResponse_Liker{
+					{ActorId: "user2", UnixTimestamp: 1, CreatedUnixTimestamp: 1},
+					{ActorId: "user3", UnixTimestamp: 2, CreatedUnixTimestamp: 1},
+					{ActorId: "user4", UnixTimestamp: 3, CreatedUnixTimestamp: 2},
+				},
+				NextPaginationToken: ref(testPageToken("user4##user1")),
+			},
+		},
+		"explicit acknowledgement": {
+			in: &pb.ListLikedYouRequest{
+				RecipientUserId:     "user1",
+				AcknowledgementMode: pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT,
+			},
+			storeReturnedDecisions: []store.Decision{
+				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
+			},
+			storeReturnedPageToken: "user2##user1",
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want: &pb.ListLikedYouResponse{
+				Likers: []*pb.ListLikedYouResponse_Liker{
+					{ActorId: "user2", UnixTimestamp: 1},
+				},
+				NextPaginationToken: ref(testPageToken("user2##user1")),
+			},
+		},
+		"unreciprocated likes": {
+			in: &pb.ListLikedYouRequest{
+				RecipientUserId: "user1",
+				NewLikesFilter:  pb.NewLikesFilter_NEW_LIKES_FILTER_UNRECIPROCATED,
+			},
+			wantFilter: &store.DecisionFilter{
+				RecipientUserID:     ref("user1"),
+				LikedRecipient:      ref(true),
+				ExcludeReciprocated: true,
+				ExcludeBlocked:      true,
+			},
+			storeReturnedDecisions: nil,
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want:                   &pb.ListLikedYouResponse{Likers: nil},
+		},
+		"newest unseen likes within a time range": {
+			in: &pb.ListLikedYouRequest{
+				RecipientUserId:    "user1",
+				PaginationToken:    ref(testPageToken("newest##15##user3")),
+				SortOrder:          pb.SortOrder_SORT_ORDER_NEWEST,
+				SinceUnixTimestamp: 10,
+				UntilUnixTimestamp: 20,
+			},
+			wantFilter: &store.DecisionFilter{
+				RecipientUserID: ref("user1"),
+				LikedRecipient:  ref(true),
+				SeenByRecipient: ref(false),
+				ExcludeBlocked:  true,
+				Sort:            store.SortNewest,
+				ModifiedSince:   ref(int64(10)),
+				ModifiedUntil:   ref(int64(20)),
+			},
+			storeReturnedDecisions: []store.Decision{
+				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 12},
+			},
+			storeReturnedPageToken: "newest##12##user2",
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want: &pb.ListLikedYouResponse{
+				Likers: []*pb.ListLikedYouResponse_Liker{
+					{ActorId: "user2", UnixTimestamp: 12},
+				},
+				NextPaginationToken: ref(testPageToken("newest##12##user2")),
+			},
+		},
+		"unseen and unreciprocated likes": {
+			in: &pb.ListLikedYouRequest{
+				RecipientUserId: "user1",
+				NewLikesFilter:  pb.NewLikesFilter_NEW_LIKES_FILTER_UNSEEN_AND_UNRECIPROCATED,
+			},
+			wantFilter: &store.DecisionFilter{
+				RecipientUserID:     ref("user1"),
+				LikedRecipient:      ref(true),
+				SeenByRecipient:     ref(false),
+				ExcludeReciprocated: true,
+				ExcludeBlocked:      true,
+			},
+			storeReturnedDecisions: []store.Decision{
+				{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, LastModified: 1},
+			},
+			storeReturnedPageToken: "user2",
+			storeReturnedError:     nil,
+			wantErr:                nil,
 			want: &pb.ListLikedYouResponse{
 				Likers: []*pb.ListLikedYouResponse_Liker{
 					{ActorId: "user2", UnixTimestamp: 1},
-					{ActorId: "user3", UnixTimestamp: 2},
-					{ActorId: "user4", UnixTimestamp: 3},
 				},
-				NextPaginationToken: ref("user4##user1"),
+				NextPaginationToken: ref(testPageToken("user2")),
 			},
 		},
 		"error listing decisions": {
 			in:                     &pb.ListLikedYouRequest{RecipientUserId: "user1"},
 			storeReturnedDecisions: nil,
 			storeReturnedError:     fmt.Errorf("some error"),
-			wantErr:                fmt.Errorf("failed to list decisions: some error"),
+			wantErr:                status.Error(codes.Internal, "failed to list decisions"),
 			want:                   nil,
 		},
 	}
@@ -140,28 +376,34 @@ func TestListNewLikedYou(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			// GIVEN: A Server with some preconditions.
 			ctx := context.Background()
-			inPaginationToken := tc.in.GetPaginationToken()
+			inPaginationToken, err := testPageTokens.Decode(tc.in.GetPaginationToken())
+			require.NoError(t, err)
+			filter := store.DecisionFilter{
+				RecipientUserID: ref("user1"),
+				LikedRecipient:  ref(true),
+				SeenByRecipient: ref(false),
+				ExcludeBlocked:  true,
+			}
+			if tc.wantFilter != nil {
+				filter = *tc.wantFilter
+			}
 			dsMock := mocks.NewDecisionStore(t)
-			dsMock.EXPECT().ListDecisions(
-				ctx,
-				store.DecisionFilter{
-					RecipientUserID: ref("user1"),
-					LikedRecipient:  ref(true),
-					SeenByRecipient: ref(false),
-				},
-				inPaginationToken,
-			).Return(tc.storeReturnedDecisions, tc.storeReturnedPageToken, tc.storeReturnedError)
-			if tc.storeReturnedError == nil && tc.want.GetNextPaginationToken() != "" {
+			dsMock.EXPECT().ListDecisions(ctx, filter, inPaginationToken).Return(tc.storeReturnedDecisions, tc.storeReturnedPageToken, tc.storeReturnedError)
+			implicitAck := tc.in.GetAcknowledgementMode() != pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT
+			if tc.storeReturnedError == nil && len(tc.storeReturnedDecisions) > 0 && implicitAck {
+				// Exactly the returned decisions are marked as seen.
+				keys := []store.DecisionKey{}
+				for _, decision := range tc.storeReturnedDecisions {
+					keys = append(keys, decision.Key())
+				}
 				// As error is only logged, we don't care about the return value.
 				dsMock.EXPECT().MarkDecisionsAsSeen(
 					mock.Anything, // context internally created
-					"user1",
-					inPaginationToken,
-					tc.want.GetNextPaginationToken(),
+					keys,
 				).Return(nil)
 			}
 
-			s := NewServiceServer(dsMock)
+			s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens))
 
 			// WHEN: ListNewLikedYou is called.
 			got, err := s.ListNewLikedYou(ctx, tc.in)
@@ -174,6 +416,212 @@ func TestListNewLikedYou(t *testing.T) {
 	}
 }
 
+func TestListYouLiked(t *testing.T) {
+	testMap := map[string]struct {
+		storeReturnedDecisions []store.Decision
+		storeReturnedPageToken string
+		storeReturnedError     error
+		in                     *pb.ListYouLikedRequest
+		wantFilter             store.DecisionFilter
+		wantErr                error
+		want                   *pb.ListYouLikedResponse
+	}{
+		"no decisions": {
+			in:                     &pb.ListYouLikedRequest{ActorUserId: "user1"},
+			wantFilter:             store.DecisionFilter{ActorUserID: ref("user1"), ExcludeBlocked: true},
+			storeReturnedDecisions: nil,
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want:                   &pb.ListYouLikedResponse{Decisions: nil},
+		},
+		"multiple decisions": {
+			in:         &pb.ListYouLikedRequest{ActorUserId: "user1"},
+			wantFilter: store.DecisionFilter{ActorUserID: ref("user1"), ExcludeBlocked: true},
+			storeReturnedDecisions: []store.Decision{
+				{ActorUserID: "user1", RecipientUserID: "user2", LastModified: 3, CreatedAt: 1, Type: store.DecisionPass},
+				{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, LastModified: 2, CreatedAt: 2, Type: store.DecisionSuperLike},
+				{ActorUserID: "user1", RecipientUserID: "user4", LastModified: 1, CreatedAt: 1, Type: store.DecisionBlock},
+			},
+			storeReturnedPageToken: "1##user4",
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want: &pb.ListYouLikedResponse{
+				Decisions: []*pb.ListYouLikedResponse_Decision{
+					{RecipientId: "user2", DecisionType: pb.DecisionType_DECISION_TYPE_PASS, UnixTimestamp: 3, CreatedUnixTimestamp: 1},
+					{RecipientId: "user3", DecisionType: pb.DecisionType_DECISION_TYPE_SUPER_LIKE, UnixTimestamp: 2, CreatedUnixTimestamp: 2},
+					{RecipientId: "user4", DecisionType: pb.DecisionType_DECISION_TYPE_BLOCK, UnixTimestamp: 1, CreatedUnixTimestamp: 1},
+				},
+				NextPaginationToken: ref(testPageToken("1##user4")),
+			},
+		},
+		"pending likes of some types within a time range": {
+			in: &pb.ListYouLikedRequest{
+				ActorUserId:        "user1",
+				PaginationToken:    ref(testPageToken("15##user2")),
+				DecisionTypes:      []pb.DecisionType{pb.DecisionType_DECISION_TYPE_SUPER_LIKE},
+				PendingOnly:        true,
+				SinceUnixTimestamp: 10,
+				UntilUnixTimestamp: 20,
+			},
+			wantFilter: store.DecisionFilter{
+				ActorUserID:         ref("user1"),
+				LikedRecipient:      ref(true),
+				Types:               []store.DecisionType{store.DecisionSuperLike},
+				ModifiedSince:       ref(int64(10)),
+				ModifiedUntil:       ref(int64(20)),
+				ExcludeReciprocated: true,
+				ExcludeBlocked:      true,
+			},
+			storeReturnedDecisions: []store.Decision{
+				{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, LastModified: 12, CreatedAt: 12, Type: store.DecisionSuperLike},
+			},
+			storeReturnedPageToken: "12##user3",
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want: &pb.ListYouLikedResponse{
+				Decisions: []*pb.ListYouLikedResponse_Decision{
+					{RecipientId: "user3", DecisionType: pb.DecisionType_DECISION_TYPE_SUPER_LIKE, UnixTimestamp: 12, CreatedUnixTimestamp: 12},
+				},
+				NextPaginationToken: ref(testPageToken("12##user3")),
+			},
+		},
+		"error listing decisions": {
+			in:                     &pb.ListYouLikedRequest{ActorUserId: "user1"},
+			wantFilter:             store.DecisionFilter{ActorUserID: ref("user1"), ExcludeBlocked: true},
+			storeReturnedDecisions: nil,
+			storeReturnedError:     fmt.Errorf("some error: %w", store.ErrInvalidCursor),
+			wantErr:                status.Error(codes.InvalidArgument, "invalid pagination token"),
+			want:                   nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions.
+			ctx := context.Background()
+			inPaginationToken, err := testPageTokens.Decode(tc.in.GetPaginationToken())
+			require.NoError(t, err)
+			dsMock := mocks.NewDecisionStore(t)
+			dsMock.EXPECT().ListDecisions(ctx, tc.wantFilter, inPaginationToken).
+				Return(tc.storeReturnedDecisions, tc.storeReturnedPageToken, tc.storeReturnedError)
+			s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens))
+
+			// WHEN: ListYouLiked is called.
+			got, err := s.ListYouLiked(ctx, tc.in)
+
+			// THEN: The result should match the expectations, and nothing is marked as seen.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestListInvalidPaginationToken(t *testing.T) {
+	forger, err := pagetoken.NewCodec([]byte("fedcba9876543210fedcba9876543210"), false)
+	require.NoError(t, err)
+	forgedToken, err := forger.Encode("user4##user1")
+	require.NoError(t, err)
+
+	testMap := map[string]struct {
+		call func(s *ServiceServer, token string) (any, error)
+	}{
+		"ListLikedYou": {
+			call: func(s *ServiceServer, token string) (any, error) {
+				return s.ListLikedYou(context.Background(), &pb.ListLikedYouRequest{
+					RecipientUserId: "user1",
+					PaginationToken: &token,
+				})
+			},
+		},
+		"ListNewLikedYou": {
+			call: func(s *ServiceServer, token string) (any, error) {
+				return s.ListNewLikedYou(context.Background(), &pb.ListLikedYouRequest{
+					RecipientUserId: "user1",
+					PaginationToken: &token,
+				})
+			},
+		},
+		"ListMutualMatches": {
+			call: func(s *ServiceServer, token string) (any, error) {
+				return s.ListMutualMatches(context.Background(), &pb.ListMutualMatchesRequest{
+					UserId:          "user1",
+					PaginationToken: &token,
+				})
+			},
+		},
+	}
+	for name, tc := range testMap {
+		for tokenName, token := range map[string]string{
+			"raw cursor":   "user4##user1",
+			"malformed":    "user4",
+			"forged token": forgedToken,
+		} {
+			t.Run(name+" "+tokenName, func(t *testing.T) {
+				// GIVEN: A Server whose store must not be called.
+				s := NewServiceServer(mocks.NewDecisionStore(t), WithPageTokenCodec(testPageTokens))
+
+				// WHEN: The list method is called with an invalid pagination token.
+				got, err := tc.call(s, token)
+
+				// THEN: InvalidArgument is returned.
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				assert.Nil(t, got)
+			})
+		}
+	}
+}
+
+func TestAcknowledgeLikes(t *testing.T) {
+	testMap := map[string]struct {
+		in                 *pb.AcknowledgeLikesRequest
+		storeCalled        bool
+		storeReturnedError error
+		wantErr            error
+		want               *pb.AcknowledgeLikesResponse
+	}{
+		"no actors": {
+			in:          &pb.AcknowledgeLikesRequest{RecipientUserId: "user1"},
+			storeCalled: false,
+			wantErr:     nil,
+			want:        &pb.AcknowledgeLikesResponse{},
+		},
+		"multiple actors": {
+			in:                 &pb.AcknowledgeLikesRequest{RecipientUserId: "user1", ActorUserIds: []string{"user2", "user3"}},
+			storeCalled:        true,
+			storeReturnedError: nil,
+			wantErr:            nil,
+			want:               &pb.AcknowledgeLikesResponse{},
+		},
+		"error marking likes as seen": {
+			in:                 &pb.AcknowledgeLikesRequest{RecipientUserId: "user1", ActorUserIds: []string{"user2", "user3"}},
+			storeCalled:        true,
+			storeReturnedError: fmt.Errorf("some error"),
+			wantErr:            status.Error(codes.Internal, "failed to acknowledge likes"),
+			want:               nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions.
+			ctx := context.Background()
+			dsMock := mocks.NewDecisionStore(t)
+			if tc.storeCalled {
+				dsMock.EXPECT().MarkDecisionsAsSeen(ctx, []store.DecisionKey{
+					{ActorUserID: "user2", RecipientUserID: "user1"},
+					{ActorUserID: "user3", RecipientUserID: "user1"},
+				}).Return(tc.storeReturnedError)
+			}
+			s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens))
+
+			// WHEN: AcknowledgeLikes is called.
+			got, err := s.AcknowledgeLikes(ctx, tc.in)
+
+			// THEN: The result should match the expectations.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestCountLikedYou(t *testing.T) {
 	testMap := map[string]struct {
 		storeReturnedCount       uint64
@@ -201,7 +649,21 @@ func TestCountLikedYou(t *testing.T) {
 			in:                 &pb.CountLikedYouRequest{RecipientUserId: "user1"},
 			storeReturnedCount: 0,
 			storeReturnedError: fmt.Errorf("some error"),
-			wantErr:            fmt.Errorf("failed to count decisions: some error"),
+			wantErr:            status.Error(codes.Internal, "failed to count decisions"),
+			want:               nil,
+		},
+		"store unavailable": {
+			in:                 &pb.CountLikedYouRequest{RecipientUserId: "user1"},
+			storeReturnedCount: 0,
+			storeReturnedError: fmt.Errorf("failed to count decisions: %w", store.ErrUnavailable),
+			wantErr:            status.Error(codes.Unavailable, "failed to count decisions"),
+			want:               nil,
+		},
+		"store deadline exceeded": {
+			in:                 &pb.CountLikedYouRequest{RecipientUserId: "user1"},
+			storeReturnedCount: 0,
+			storeReturnedError: fmt.Errorf("failed to count decisions: %w", context.DeadlineExceeded),
+			wantErr:            status.Error(codes.DeadlineExceeded, "failed to count decisions"),
 			want:               nil,
 		},
 	}
@@ -213,8 +675,9 @@ func TestCountLikedYou(t *testing.T) {
 			dsMock.EXPECT().CountDecisions(ctx, store.DecisionFilter{
 				RecipientUserID: ref("user1"),
 				LikedRecipient:  ref(true),
+				ExcludeBlocked:  true,
 			}).Return(tc.storeReturnedCount, tc.storeReturnedError)
-			s := NewServiceServer(dsMock)
+			s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens))
 
 			// WHEN: CountLikedYou is called.
 			got, err := s.CountLikedYou(ctx, tc.in)
@@ -246,7 +709,9 @@ func TestPutDecision(t *testing.T) {
 					ActorUserID:     "user2",
 					LikedRecipient:  false,
 					LastModified:    lastModified,
-				}).Return(nil)
+					CreatedAt:       lastModified,
+					Type:            store.DecisionPass,
+				}).Return(0, nil)
 				return dsMock
 			},
 			wantErr: nil,
@@ -265,12 +730,11 @@ func TestPutDecision(t *testing.T) {
 					ActorUserID:     "user2",
 					LikedRecipient:  true,
 					LastModified:    lastModified,
-				}).Return(nil)
-				dsMock.EXPECT().ListDecisions(ctx, store.DecisionFilter{
-					ActorUserID:     ref("user1"),
-					RecipientUserID: ref("user2"),
-					LikedRecipient:  ref(true),
-				}, "").Return(nil, "", nil)
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, nil)
 				return dsMock
 			},
 			wantErr: nil,
@@ -289,14 +753,13 @@ func TestPutDecision(t *testing.T) {
 					ActorUserID:     "user2",
 					LikedRecipient:  true,
 					LastModified:    lastModified,
-				}).Return(nil)
-				dsMock.EXPECT().ListDecisions(ctx, store.DecisionFilter{
-					ActorUserID:     ref("user1"),
-					RecipientUserID: ref("user2"),
-					LikedRecipient:  ref(true),
-				}, "").Return([]store.Decision{
-					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: 1},
-				}, "user2##user1", nil)
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return([]store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: 1, Type: store.DecisionLike},
+				}, nil)
 				return dsMock
 			},
 			wantErr: nil,
@@ -315,37 +778,42 @@ func TestPutDecision(t *testing.T) {
 					ActorUserID:     "user2",
 					LikedRecipient:  true,
 					LastModified:    lastModified,
-				}).Return(nil)
-				dsMock.EXPECT().ListDecisions(ctx, store.DecisionFilter{
-					ActorUserID:     ref("user1"),
-					RecipientUserID: ref("user2"),
-					LikedRecipient:  ref(true),
-				}, "").Return(nil, "", nil)
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, nil)
 				return dsMock
 			},
 			wantErr: nil,
 			want:    &pb.PutDecisionResponse{MutualLikes: false},
 		},
-		"error putting decision": {
+		"super-like decision, it's a match": {
 			in: &pb.PutDecisionRequest{
 				RecipientUserId: "user1",
 				ActorUserId:     "user2",
-				LikedRecipient:  false,
+				DecisionType:    pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
 			},
 			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
 				dsMock := mocks.NewDecisionStore(t)
 				dsMock.EXPECT().UpsertDecision(ctx, store.Decision{
 					RecipientUserID: "user1",
 					ActorUserID:     "user2",
-					LikedRecipient:  false,
+					LikedRecipient:  true,
 					LastModified:    lastModified,
-				}).Return(fmt.Errorf("some error"))
+					CreatedAt:       lastModified,
+					Type:            store.DecisionSuperLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return([]store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: 1, Type: store.DecisionLike},
+				}, nil)
 				return dsMock
 			},
-			wantErr: fmt.Errorf("failed to upsert decision: some error"),
-			want:    nil,
+			wantErr: nil,
+			want:    &pb.PutDecisionResponse{MutualLikes: true},
 		},
-		"error checking it's a match": {
+		"like decision, blocked by the recipient": {
 			in: &pb.PutDecisionRequest{
 				RecipientUserId: "user1",
 				ActorUserId:     "user2",
@@ -358,32 +826,1041 @@ func TestPutDecision(t *testing.T) {
 					ActorUserID:     "user2",
 					LikedRecipient:  true,
 					LastModified:    lastModified,
-				}).Return(nil)
-				dsMock.EXPECT().ListDecisions(ctx, store.DecisionFilter{
-					ActorUserID:     ref("user1"),
-					RecipientUserID: ref("user2"),
-					LikedRecipient:  ref(true),
-				}, "").Return(nil, "", fmt.Errorf("some error"))
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return([]store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: false, LastModified: 1, Type: store.DecisionBlock},
+				}, nil)
 				return dsMock
 			},
-			wantErr: fmt.Errorf("failed to check if it's mutual: some error"),
+			wantErr: nil,
 			want:    &pb.PutDecisionResponse{MutualLikes: false},
 		},
-	}
-	for name, tc := range testMap {
-		t.Run(name, func(t *testing.T) {
-			// GIVEN: A Server with some preconditions.
-			ctx := context.Background()
-			now := time.Now()
-			s := NewServiceServer(tc.decisionStoreMockFactory(ctx, now.Unix()))
-			s.nowFn = func() time.Time { return now }
-
-			// WHEN: ListLikedYou is called.
-			got, err := s.PutDecision(ctx, tc.in)
-
-			// THEN: The result should match the expectations.
-			require.Equal(t, tc.wantErr, err)
-			assert.Equal(t, tc.want, got)
+		"block decision": {
+			in: &pb.PutDecisionRequest{
+				RecipientUserId: "user1",
+				ActorUserId:     "user2",
+				DecisionType:    pb.DecisionType_DECISION_TYPE_BLOCK,
+			},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecision(ctx, store.Decision{
+					RecipientUserID: "user1",
+					ActorUserID:     "user2",
+					LikedRecipient:  false,
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionBlock,
+				}).Return(0, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.PutDecisionResponse{MutualLikes: false},
+		},
+		"error putting decision": {
+			in: &pb.PutDecisionRequest{
+				RecipientUserId: "user1",
+				ActorUserId:     "user2",
+				LikedRecipient:  false,
+			},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecision(ctx, store.Decision{
+					RecipientUserID: "user1",
+					ActorUserID:     "user2",
+					LikedRecipient:  false,
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionPass,
+				}).Return(0, fmt.Errorf("some error"))
+				return dsMock
+			},
+			wantErr: status.Error(codes.Internal, "failed to upsert decision"),
+			want:    nil,
+		},
+		"error checking it's a match": {
+			in: &pb.PutDecisionRequest{
+				RecipientUserId: "user1",
+				ActorUserId:     "user2",
+				LikedRecipient:  true,
+			},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecision(ctx, store.Decision{
+					RecipientUserID: "user1",
+					ActorUserID:     "user2",
+					LikedRecipient:  true,
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, fmt.Errorf("some error"))
+				return dsMock
+			},
+			wantErr: status.Error(codes.Internal, "failed to check if it's mutual"),
+			want:    &pb.PutDecisionResponse{MutualLikes: false},
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions.
+			ctx := context.Background()
+			now := time.Now()
+			s := NewServiceServer(tc.decisionStoreMockFactory(ctx, now.Unix()))
+			s.nowFn = func() time.Time { return now }
+
+			// WHEN: ListLikedYou is called.
+			got, err := s.PutDecision(ctx, tc.in)
+
+			// THEN: The result should match the expectations.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPutDecisionEvents(t *testing.T) {
+	testMap := map[string]struct {
+		decisionType pb.DecisionType
+		previous     store.DecisionType
+		reverseType  store.DecisionType
+		want         []LikeEventKind
+	}{
+		"new like": {
+			decisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+			want:         []LikeEventKind{LikeEventLike},
+		},
+		"pass turned into a like": {
+			decisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+			previous:     store.DecisionPass,
+			want:         []LikeEventKind{LikeEventLike},
+		},
+		"like re-submitted": {
+			decisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+			previous:     store.DecisionLike,
+			want:         nil,
+		},
+		"like upgraded to a super-like": {
+			decisionType: pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+			previous:     store.DecisionLike,
+			want:         []LikeEventKind{LikeEventLike},
+		},
+		"super-like downgraded to a like": {
+			decisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+			previous:     store.DecisionSuperLike,
+			want:         nil,
+		},
+		"new match": {
+			decisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+			previous:     store.DecisionPass,
+			reverseType:  store.DecisionLike,
+			want:         []LikeEventKind{LikeEventMatch},
+		},
+		"like of a match re-submitted": {
+			decisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+			previous:     store.DecisionLike,
+			reverseType:  store.DecisionLike,
+			want:         nil,
+		},
+		"like of a match upgraded to a super-like": {
+			decisionType: pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+			previous:     store.DecisionLike,
+			reverseType:  store.DecisionLike,
+			want:         nil,
+		},
+		"like of a match turned into a pass": {
+			decisionType: pb.DecisionType_DECISION_TYPE_PASS,
+			previous:     store.DecisionSuperLike,
+			reverseType:  store.DecisionLike,
+			want:         []LikeEventKind{LikeEventUnmatch},
+		},
+		"like of a match turned into a block": {
+			decisionType: pb.DecisionType_DECISION_TYPE_BLOCK,
+			previous:     store.DecisionLike,
+			reverseType:  store.DecisionLike,
+			want:         []LikeEventKind{LikeEventUnmatch},
+		},
+		"like not liked back turned into a pass": {
+			decisionType: pb.DecisionType_DECISION_TYPE_PASS,
+			previous:     store.DecisionLike,
+			reverseType:  store.DecisionPass,
+			want:         nil,
+		},
+		"pass re-submitted": {
+			decisionType: pb.DecisionType_DECISION_TYPE_PASS,
+			previous:     store.DecisionPass,
+			reverseType:  store.DecisionLike,
+			want:         nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server replacing a decision of some type, and the recipient watching their likes.
+			ctx := context.Background()
+			dsMock := mocks.NewDecisionStore(t)
+			dsMock.EXPECT().UpsertDecision(ctx, mock.Anything).Return(tc.previous, nil)
+			var reverseDecisions []store.Decision
+			if tc.reverseType != 0 {
+				reverseDecisions = []store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: tc.reverseType.Liked(), Type: tc.reverseType},
+				}
+			}
+			// The reverse decision is only looked up for likes, or likes turned into something else.
+			dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).
+				Return(reverseDecisions, nil).Maybe()
+			hub := NewMemoryHub(10, 10)
+			s := NewServiceServer(dsMock, WithLikesHub(hub))
+			sub, err := hub.Subscribe(ctx, "user1", "")
+			require.NoError(t, err)
+
+			// WHEN: PutDecision is called.
+			_, err = s.PutDecision(ctx, &pb.PutDecisionRequest{
+				ActorUserId:     "user2",
+				RecipientUserId: "user1",
+				DecisionType:    tc.decisionType,
+			})
+
+			// THEN: The recipient is only told about the likes and matches that are new, and the matches
+			// dissolved.
+			require.NoError(t, err)
+			var got []LikeEventKind
+			for _, event := range receive(sub) {
+				got = append(got, event.Kind)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPutDecisionsUnmatch(t *testing.T) {
+	// GIVEN: A Server with user2 matched with user1 and user3, and user1 watching their likes.
+	ctx := context.Background()
+	now := time.Now()
+	dsMock := mocks.NewDecisionStore(t)
+	dsMock.EXPECT().UpsertDecisions(ctx, []store.Decision{
+		{ActorUserID: "user2", RecipientUserID: "user1", LastModified: now.Unix(), CreatedAt: now.Unix(), Type: store.DecisionPass},
+		{ActorUserID: "user2", RecipientUserID: "user3", LastModified: now.Unix(), CreatedAt: now.Unix(), Type: store.DecisionBlock},
+		{ActorUserID: "user2", RecipientUserID: "user4", LastModified: now.Unix(), CreatedAt: now.Unix(), Type: store.DecisionPass},
+	}).Return([]store.DecisionType{store.DecisionLike, store.DecisionSuperLike, store.DecisionPass}, nil)
+	dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{
+		{ActorUserID: "user1", RecipientUserID: "user2"},
+		{ActorUserID: "user3", RecipientUserID: "user2"},
+	}).Return([]store.Decision{
+		{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, Type: store.DecisionLike},
+		{ActorUserID: "user3", RecipientUserID: "user2", LikedRecipient: true, Type: store.DecisionLike},
+	}, nil)
+	hub := NewMemoryHub(10, 10)
+	s := NewServiceServer(dsMock, WithLikesHub(hub))
+	s.nowFn = func() time.Time { return now }
+	sub1, err := hub.Subscribe(ctx, "user1", "")
+	require.NoError(t, err)
+	sub3, err := hub.Subscribe(ctx, "user3", "")
+	require.NoError(t, err)
+
+	// WHEN: user2 passes on user1, blocks user3, and passes on user4 again.
+	_, err = s.PutDecisions(ctx, &pb.PutDecisionsRequest{Decisions: []*pb.PutDecisionRequest{
+		{ActorUserId: "user2", RecipientUserId: "user1", DecisionType: pb.DecisionType_DECISION_TYPE_PASS},
+		{ActorUserId: "user2", RecipientUserId: "user3", DecisionType: pb.DecisionType_DECISION_TYPE_BLOCK},
+		{ActorUserId: "user2", RecipientUserId: "user4", DecisionType: pb.DecisionType_DECISION_TYPE_PASS},
+	}})
+
+	// THEN: Both matches are dissolved, and their users told about it.
+	require.NoError(t, err)
+	for _, sub := range []Subscription{sub1, sub3} {
+		events := receive(sub)
+		require.Len(t, events, 1)
+		assert.Equal(t, LikeEventUnmatch, events[0].Kind)
+		assert.Equal(t, "user2", events[0].ActorUserID)
+	}
+}
+
+func TestPutDecisions(t *testing.T) {
+	testMap := map[string]struct {
+		in                       *pb.PutDecisionsRequest
+		decisionStoreMockFactory func(ctx context.Context, lastModified int64) DecisionStore
+		wantErr                  error
+		want                     *pb.PutDecisionsResponse
+	}{
+		"empty batch": {
+			in: &pb.PutDecisionsRequest{},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				return mocks.NewDecisionStore(t)
+			},
+			wantErr: nil,
+			want:    &pb.PutDecisionsResponse{Results: []*pb.PutDecisionsResponse_Result{}},
+		},
+		"passes only": {
+			in: &pb.PutDecisionsRequest{Decisions: []*pb.PutDecisionRequest{
+				{ActorUserId: "user1", RecipientUserId: "user2", LikedRecipient: false},
+				{ActorUserId: "user1", RecipientUserId: "user3", LikedRecipient: false},
+			}},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecisions(ctx, []store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionPass},
+					{ActorUserID: "user1", RecipientUserID: "user3", LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionPass},
+				}).Return([]store.DecisionType{0, 0}, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want: &pb.PutDecisionsResponse{Results: []*pb.PutDecisionsResponse_Result{
+				{MutualLikes: false},
+				{MutualLikes: false},
+			}},
+		},
+		"likes checked in bulk, with an invalid decision": {
+			in: &pb.PutDecisionsRequest{Decisions: []*pb.PutDecisionRequest{
+				{ActorUserId: "user1", RecipientUserId: "user2", DecisionType: pb.DecisionType_DECISION_TYPE_SUPER_LIKE},
+				{ActorUserId: "user1", RecipientUserId: "user1", LikedRecipient: true},
+				{ActorUserId: "user1", RecipientUserId: "user3", LikedRecipient: true},
+				{ActorUserId: "user1", RecipientUserId: "user4", LikedRecipient: true},
+				{ActorUserId: "user1", RecipientUserId: "user5", LikedRecipient: true},
+			}},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecisions(ctx, []store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionSuperLike, LikedAt: lastModified},
+					{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionLike, LikedAt: lastModified},
+					{ActorUserID: "user1", RecipientUserID: "user4", LikedRecipient: true, LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionLike, LikedAt: lastModified},
+					{ActorUserID: "user1", RecipientUserID: "user5", LikedRecipient: true, LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionLike, LikedAt: lastModified},
+				}).Return([]store.DecisionType{0, 0, 0, 0}, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{
+					{ActorUserID: "user2", RecipientUserID: "user1"},
+					{ActorUserID: "user3", RecipientUserID: "user1"},
+					{ActorUserID: "user4", RecipientUserID: "user1"},
+					{ActorUserID: "user5", RecipientUserID: "user1"},
+				}).Return([]store.Decision{
+					{ActorUserID: "user4", RecipientUserID: "user1", LikedRecipient: true, Type: store.DecisionSuperLike},
+					{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: false, Type: store.DecisionPass},
+					{ActorUserID: "user5", RecipientUserID: "user1", LikedRecipient: false, Type: store.DecisionBlock},
+				}, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want: &pb.PutDecisionsResponse{Results: []*pb.PutDecisionsResponse_Result{
+				{MutualLikes: false},
+				{Error: &pb.PutDecisionsResponse_Result_Error{
+					Code:    int32(codes.InvalidArgument),
+					Message: "invalid request; recipient_user_id must be different from actor_user_id",
+				}},
+				{MutualLikes: false},
+				{MutualLikes: true},
+				{MutualLikes: false},
+			}},
+		},
+		"too many decisions": {
+			in: &pb.PutDecisionsRequest{
+				Decisions: make([]*pb.PutDecisionRequest, MaxBatchDecisions+1),
+			},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				return mocks.NewDecisionStore(t)
+			},
+			wantErr: func() error {
+				st, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(&errdetails.BadRequest{
+					FieldViolations: []*errdetails.BadRequest_FieldViolation{
+						{Field: "decisions", Description: "must have at most 100 decisions"},
+					},
+				})
+				require.NoError(t, err)
+				return st.Err()
+			}(),
+			want: nil,
+		},
+		"error upserting decisions": {
+			in: &pb.PutDecisionsRequest{Decisions: []*pb.PutDecisionRequest{
+				{ActorUserId: "user1", RecipientUserId: "user2", LikedRecipient: true},
+			}},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecisions(ctx, []store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionLike, LikedAt: lastModified},
+				}).Return(nil, fmt.Errorf("some error: %w", store.ErrConflict))
+				return dsMock
+			},
+			wantErr: status.Error(codes.Aborted, "failed to upsert decisions"),
+			want:    nil,
+		},
+		"error checking if they are mutual": {
+			in: &pb.PutDecisionsRequest{Decisions: []*pb.PutDecisionRequest{
+				{ActorUserId: "user1", RecipientUserId: "user2", LikedRecipient: true},
+				{ActorUserId: "user1", RecipientUserId: "user3", LikedRecipient: false},
+			}},
+			decisionStoreMockFactory: func(ctx context.Context, lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecisions(ctx, []store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionLike, LikedAt: lastModified},
+					{ActorUserID: "user1", RecipientUserID: "user3", LastModified: lastModified, CreatedAt: lastModified, Type: store.DecisionPass},
+				}).Return([]store.DecisionType{0, 0}, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{
+					{ActorUserID: "user2", RecipientUserID: "user1"},
+				}).Return(nil, fmt.Errorf("some error"))
+				return dsMock
+			},
+			want: &pb.PutDecisionsResponse{Results: []*pb.PutDecisionsResponse_Result{
+				{MutualCheckError: &pb.PutDecisionsResponse_Result_Error{
+					Code:    int32(codes.Internal),
+					Message: "failed to check if they are mutual",
+				}},
+				{},
+			}},
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions.
+			ctx := context.Background()
+			now := time.Now()
+			s := NewServiceServer(tc.decisionStoreMockFactory(ctx, now.Unix()))
+			s.nowFn = func() time.Time { return now }
+
+			// WHEN: PutDecisions is called.
+			got, err := s.PutDecisions(ctx, tc.in)
+
+			// THEN: The result should match the expectations.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestUndoDecision(t *testing.T) {
+	like := store.Decision{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, Type: store.DecisionLike}
+	pass := store.Decision{ActorUserID: "user2", RecipientUserID: "user1", Type: store.DecisionPass}
+	testMap := map[string]struct {
+		decisionStoreMockFactory func(ctx context.Context, notBefore int64) DecisionStore
+		wantErr                  error
+		want                     *pb.UndoDecisionResponse
+		wantEvent                *LikeEventKind // Sent to both users, if any.
+	}{
+		"like undone, previous pass restored": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).Return(like, &pass, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.UndoDecisionResponse{DecisionType: pb.DecisionType_DECISION_TYPE_PASS},
+		},
+		"pass undone, previous like restored": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).Return(pass, &like, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.UndoDecisionResponse{DecisionType: pb.DecisionType_DECISION_TYPE_LIKE},
+		},
+		"match restored": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).Return(
+					store.Decision{ActorUserID: "user2", RecipientUserID: "user1", Type: store.DecisionBlock},
+					&like,
+					nil,
+				)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return([]store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, Type: store.DecisionLike},
+				}, nil)
+				return dsMock
+			},
+			wantErr:   nil,
+			want:      &pb.UndoDecisionResponse{DecisionType: pb.DecisionType_DECISION_TYPE_LIKE},
+			wantEvent: ref(LikeEventMatch),
+		},
+		"first decision undone": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).Return(pass, nil, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.UndoDecisionResponse{DecisionType: pb.DecisionType_DECISION_TYPE_UNSPECIFIED},
+		},
+		"match dissolved": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).Return(like, nil, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return([]store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, Type: store.DecisionSuperLike},
+				}, nil)
+				return dsMock
+			},
+			wantErr:   nil,
+			want:      &pb.UndoDecisionResponse{DecisionType: pb.DecisionType_DECISION_TYPE_UNSPECIFIED},
+			wantEvent: ref(LikeEventUnmatch),
+		},
+		"error checking the match doesn't fail the undo": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).Return(like, nil, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, fmt.Errorf("some error"))
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.UndoDecisionResponse{DecisionType: pb.DecisionType_DECISION_TYPE_UNSPECIFIED},
+		},
+		"decision out of the undo window": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).
+					Return(store.Decision{}, nil, fmt.Errorf("some error: %w", store.ErrFailedPrecondition))
+				return dsMock
+			},
+			wantErr: status.Error(codes.FailedPrecondition, "failed to undo decision"),
+			want:    nil,
+		},
+		"no decision to undo": {
+			decisionStoreMockFactory: func(ctx context.Context, notBefore int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UndoDecision(ctx, like.Key(), notBefore).
+					Return(store.Decision{}, nil, fmt.Errorf("some error: %w", store.ErrNotFound))
+				return dsMock
+			},
+			wantErr: status.Error(codes.NotFound, "failed to undo decision"),
+			want:    nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions, and both users watching their likes.
+			ctx := context.Background()
+			now := time.Now()
+			hub := NewMemoryHub(10, 10)
+			s := NewServiceServer(
+				tc.decisionStoreMockFactory(ctx, now.Add(-30*time.Second).Unix()),
+				WithUndoWindow(30*time.Second),
+				WithLikesHub(hub),
+			)
+			s.nowFn = func() time.Time { return now }
+			sub1, err := hub.Subscribe(ctx, "user1", "")
+			require.NoError(t, err)
+			sub2, err := hub.Subscribe(ctx, "user2", "")
+			require.NoError(t, err)
+
+			// WHEN: UndoDecision is called.
+			got, err := s.UndoDecision(ctx, &pb.UndoDecisionRequest{ActorUserId: "user2", RecipientUserId: "user1"})
+
+			// THEN: The result should match the expectations, and both users are told about the match
+			// restored or dissolved.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+			events1, events2 := receive(sub1), receive(sub2)
+			if tc.wantEvent != nil {
+				require.Len(t, events1, 1)
+				assert.Equal(t, *tc.wantEvent, events1[0].Kind)
+				assert.Equal(t, "user2", events1[0].ActorUserID)
+				require.Len(t, events2, 1)
+				assert.Equal(t, *tc.wantEvent, events2[0].Kind)
+				assert.Equal(t, "user1", events2[0].ActorUserID)
+			} else {
+				assert.Empty(t, events1)
+				assert.Empty(t, events2)
+			}
+		})
+	}
+}
+
+func TestDeleteDecision(t *testing.T) {
+	key := store.DecisionKey{ActorUserID: "user2", RecipientUserID: "user1"}
+	testMap := map[string]struct {
+		decisionStoreMockFactory func(ctx context.Context) DecisionStore
+		wantErr                  error
+		want                     *pb.DeleteDecisionResponse
+		wantUnmatch              bool
+	}{
+		"pass deleted": {
+			decisionStoreMockFactory: func(ctx context.Context) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().DeleteDecision(ctx, key).Return(store.Decision{
+					ActorUserID:     "user2",
+					RecipientUserID: "user1",
+					Type:            store.DecisionPass,
+				}, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want:    &pb.DeleteDecisionResponse{},
+		},
+		"like of a match deleted": {
+			decisionStoreMockFactory: func(ctx context.Context) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().DeleteDecision(ctx, key).Return(store.Decision{
+					ActorUserID:     "user2",
+					RecipientUserID: "user1",
+					LikedRecipient:  true,
+					Type:            store.DecisionLike,
+				}, nil)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return([]store.Decision{
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, Type: store.DecisionLike},
+				}, nil)
+				return dsMock
+			},
+			wantErr:     nil,
+			want:        &pb.DeleteDecisionResponse{},
+			wantUnmatch: true,
+		},
+		"no decision": {
+			decisionStoreMockFactory: func(ctx context.Context) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().DeleteDecision(ctx, key).
+					Return(store.Decision{}, fmt.Errorf("some error: %w", store.ErrNotFound))
+				return dsMock
+			},
+			wantErr: status.Error(codes.NotFound, "failed to delete decision"),
+			want:    nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions, and the recipient watching their likes.
+			ctx := context.Background()
+			hub := NewMemoryHub(10, 10)
+			s := NewServiceServer(tc.decisionStoreMockFactory(ctx), WithLikesHub(hub))
+			sub, err := hub.Subscribe(ctx, "user1", "")
+			require.NoError(t, err)
+
+			// WHEN: DeleteDecision is called.
+			got, err := s.DeleteDecision(ctx, &pb.DeleteDecisionRequest{ActorUserId: "user2", RecipientUserId: "user1"})
+
+			// THEN: The result should match the expectations, and the recipient is told about the unmatch.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+			events := receive(sub)
+			if tc.wantUnmatch {
+				require.Len(t, events, 1)
+				assert.Equal(t, LikeEventUnmatch, events[0].Kind)
+			} else {
+				assert.Empty(t, events)
+			}
+		})
+	}
+}
+
+func TestUnmatch(t *testing.T) {
+	testMap := map[string]struct {
+		storeReturnedError error
+		wantErr            error
+		want               *pb.UnmatchResponse
+	}{
+		"matched": {
+			storeReturnedError: nil,
+			wantErr:            nil,
+			want:               &pb.UnmatchResponse{},
+		},
+		"not matched": {
+			storeReturnedError: fmt.Errorf("some error: %w", store.ErrNotFound),
+			wantErr:            status.Error(codes.NotFound, "failed to unmatch"),
+			want:               nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions, and both users watching their likes.
+			ctx := context.Background()
+			dsMock := mocks.NewDecisionStore(t)
+			dsMock.EXPECT().Unmatch(ctx, "user1", "user2").Return(tc.storeReturnedError)
+			hub := NewMemoryHub(10, 10)
+			s := NewServiceServer(dsMock, WithLikesHub(hub))
+			sub1, err := hub.Subscribe(ctx, "user1", "")
+			require.NoError(t, err)
+			sub2, err := hub.Subscribe(ctx, "user2", "")
+			require.NoError(t, err)
+
+			// WHEN: Unmatch is called.
+			got, err := s.Unmatch(ctx, &pb.UnmatchRequest{UserId: "user1", PartnerUserId: "user2"})
+
+			// THEN: The result should match the expectations, and both users are told if they unmatched.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+			if tc.wantErr == nil {
+				assert.Equal(t, []string{"user2"}, actors(receive(sub1)))
+				assert.Equal(t, []string{"user1"}, actors(receive(sub2)))
+			} else {
+				assert.Empty(t, receive(sub1))
+				assert.Empty(t, receive(sub2))
+			}
+		})
+	}
+}
+
+func TestGetRelationship(t *testing.T) {
+	testMap := map[string]struct {
+		in                       *pb.GetRelationshipRequest
+		decisionStoreMockFactory func(ctx context.Context) DecisionStore
+		wantErr                  error
+		want                     *pb.GetRelationshipResponse
+	}{
+		"no other users": {
+			in: &pb.GetRelationshipRequest{ViewerUserId: "user1"},
+			decisionStoreMockFactory: func(ctx context.Context) DecisionStore {
+				return mocks.NewDecisionStore(t)
+			},
+			wantErr: nil,
+			want:    &pb.GetRelationshipResponse{},
+		},
+		"both directions looked up at once": {
+			in: &pb.GetRelationshipRequest{ViewerUserId: "user1", OtherUserIds: []string{"user2", "user3", "user4", "user5"}},
+			decisionStoreMockFactory: func(ctx context.Context) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{
+					{ActorUserID: "user1", RecipientUserID: "user2"},
+					{ActorUserID: "user2", RecipientUserID: "user1"},
+					{ActorUserID: "user1", RecipientUserID: "user3"},
+					{ActorUserID: "user3", RecipientUserID: "user1"},
+					{ActorUserID: "user1", RecipientUserID: "user4"},
+					{ActorUserID: "user4", RecipientUserID: "user1"},
+					{ActorUserID: "user1", RecipientUserID: "user5"},
+					{ActorUserID: "user5", RecipientUserID: "user1"},
+				}).Return([]store.Decision{
+					{ActorUserID: "user2", RecipientUserID: "user1", LikedRecipient: true, Type: store.DecisionSuperLike},
+					{ActorUserID: "user1", RecipientUserID: "user2", LikedRecipient: true, Type: store.DecisionLike},
+					{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, Type: store.DecisionLike},
+					{ActorUserID: "user4", RecipientUserID: "user1", Type: store.DecisionPass},
+					{ActorUserID: "user1", RecipientUserID: "user5", LikedRecipient: true, Type: store.DecisionLike},
+					{ActorUserID: "user5", RecipientUserID: "user1", Type: store.DecisionBlock},
+				}, nil)
+				return dsMock
+			},
+			wantErr: nil,
+			want: &pb.GetRelationshipResponse{Relationships: []*pb.GetRelationshipResponse_Relationship{
+				{
+					OtherUserId:        "user2",
+					ViewerDecisionType: pb.DecisionType_DECISION_TYPE_LIKE,
+					OtherDecisionType:  pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+					Matched:            true,
+				},
+				{OtherUserId: "user3", ViewerDecisionType: pb.DecisionType_DECISION_TYPE_LIKE},
+				{OtherUserId: "user4", OtherDecisionType: pb.DecisionType_DECISION_TYPE_PASS},
+				{OtherUserId: "user5", ViewerDecisionType: pb.DecisionType_DECISION_TYPE_LIKE},
+			}},
+		},
+		"error getting decisions": {
+			in: &pb.GetRelationshipRequest{ViewerUserId: "user1", OtherUserIds: []string{"user2"}},
+			decisionStoreMockFactory: func(ctx context.Context) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().GetDecisions(ctx, []store.DecisionKey{
+					{ActorUserID: "user1", RecipientUserID: "user2"},
+					{ActorUserID: "user2", RecipientUserID: "user1"},
+				}).Return(nil, fmt.Errorf("some error: %w", store.ErrUnavailable))
+				return dsMock
+			},
+			wantErr: status.Error(codes.Unavailable, "failed to get decisions"),
+			want:    nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions.
+			ctx := context.Background()
+			s := NewServiceServer(tc.decisionStoreMockFactory(ctx))
+
+			// WHEN: GetRelationship is called.
+			got, err := s.GetRelationship(ctx, tc.in)
+
+			// THEN: The result should match the expectations.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestListMutualMatches(t *testing.T) {
+	testMap := map[string]struct {
+		storeReturnedMatches   []store.Match
+		storeReturnedPageToken string
+		storeReturnedError     error
+		in                     *pb.ListMutualMatchesRequest
+		wantErr                error
+		want                   *pb.ListMutualMatchesResponse
+	}{
+		"no matches": {
+			in:                   &pb.ListMutualMatchesRequest{UserId: "user1"},
+			storeReturnedMatches: nil,
+			storeReturnedError:   nil,
+			wantErr:              nil,
+			want:                 &pb.ListMutualMatchesResponse{Matches: nil},
+		},
+		"multiple matches": {
+			in: &pb.ListMutualMatchesRequest{UserId: "user1", PaginationToken: ref(testPageToken("user1"))},
+			storeReturnedMatches: []store.Match{
+				{UserID: "user1", PartnerUserID: "user2", MatchedAt: 1},
+				{UserID: "user1", PartnerUserID: "user3", MatchedAt: 2},
+			},
+			storeReturnedPageToken: "user3",
+			storeReturnedError:     nil,
+			wantErr:                nil,
+			want: &pb.ListMutualMatchesResponse{
+				Matches: []*pb.ListMutualMatchesResponse_Match{
+					{PartnerId: "user2", UnixTimestamp: 1},
+					{PartnerId: "user3", UnixTimestamp: 2},
+				},
+				NextPaginationToken: ref(testPageToken("user3")),
+			},
+		},
+		"error listing matches": {
+			in:                   &pb.ListMutualMatchesRequest{UserId: "user1"},
+			storeReturnedMatches: nil,
+			storeReturnedError:   fmt.Errorf("some error"),
+			wantErr:              status.Error(codes.Internal, "failed to list mutual matches"),
+			want:                 nil,
+		},
+		"invalid store cursor": {
+			in:                   &pb.ListMutualMatchesRequest{UserId: "user1", PaginationToken: ref(testPageToken("a##b"))},
+			storeReturnedMatches: nil,
+			storeReturnedError:   fmt.Errorf("failed to list mutual matches: %w", store.ErrInvalidCursor),
+			wantErr:              status.Error(codes.InvalidArgument, "invalid pagination token"),
+			want:                 nil,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server with some preconditions.
+			ctx := context.Background()
+			dsMock := mocks.NewDecisionStore(t)
+			inPaginationToken, err := testPageTokens.Decode(tc.in.GetPaginationToken())
+			require.NoError(t, err)
+			dsMock.EXPECT().ListMutualMatches(ctx, "user1", inPaginationToken).
+				Return(tc.storeReturnedMatches, tc.storeReturnedPageToken, tc.storeReturnedError)
+			s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens))
+
+			// WHEN: ListMutualMatches is called.
+			got, err := s.ListMutualMatches(ctx, tc.in)
+
+			// THEN: The result should match the expectations.
+			require.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	tooLong := "12345678901"
+	testMap := map[string]struct {
+		rules          *UserIDRules
+		call           func(context.Context, *ServiceServer) error
+		wantViolations map[string]string
+	}{
+		"list likes without recipient": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.ListLikedYou(ctx, &pb.ListLikedYouRequest{})
+				return err
+			},
+			wantViolations: map[string]string{"recipient_user_id": "must be set"},
+		},
+		"list new likes with unknown acknowledgement mode": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.ListNewLikedYou(ctx, &pb.ListLikedYouRequest{
+					RecipientUserId:     "user1",
+					AcknowledgementMode: pb.AcknowledgementMode(42),
+				})
+				return err
+			},
+			wantViolations: map[string]string{"acknowledgement_mode": "unknown acknowledgement mode"},
+		},
+		"list new likes with unknown filter": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.ListNewLikedYou(ctx, &pb.ListLikedYouRequest{
+					RecipientUserId: "user1",
+					NewLikesFilter:  pb.NewLikesFilter(42),
+				})
+				return err
+			},
+			wantViolations: map[string]string{"new_likes_filter": "unknown new likes filter"},
+		},
+		"count likes with too long recipient": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.CountLikedYou(ctx, &pb.CountLikedYouRequest{RecipientUserId: tooLong})
+				return err
+			},
+			wantViolations: map[string]string{"recipient_user_id": "must be at most 10 characters long"},
+		},
+		"put decision on oneself": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user1"})
+				return err
+			},
+			wantViolations: map[string]string{"recipient_user_id": "must be different from actor_user_id"},
+		},
+		"put decision without users": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.PutDecision(ctx, &pb.PutDecisionRequest{})
+				return err
+			},
+			wantViolations: map[string]string{
+				"actor_user_id":     "must be set",
+				"recipient_user_id": "must be set",
+			},
+		},
+		"put decision with invalid UTF-8": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "user\xff", RecipientUserId: "user2"})
+				return err
+			},
+			wantViolations: map[string]string{"actor_user_id": "must be valid UTF-8"},
+		},
+		"put decision with an unknown type": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user2", DecisionType: 42})
+				return err
+			},
+			wantViolations: map[string]string{"decision_type": "unknown decision type"},
+		},
+		"put decision liked but blocking": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.PutDecision(ctx, &pb.PutDecisionRequest{
+					ActorUserId:     "user1",
+					RecipientUserId: "user2",
+					LikedRecipient:  true,
+					DecisionType:    pb.DecisionType_DECISION_TYPE_BLOCK,
+				})
+				return err
+			},
+			wantViolations: map[string]string{"decision_type": "must be a like when liked_recipient is set"},
+		},
+		"undo decision without users": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.UndoDecision(ctx, &pb.UndoDecisionRequest{})
+				return err
+			},
+			wantViolations: map[string]string{
+				"actor_user_id":     "must be set",
+				"recipient_user_id": "must be set",
+			},
+		},
+		"unmatch without partner": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.Unmatch(ctx, &pb.UnmatchRequest{UserId: "user1"})
+				return err
+			},
+			wantViolations: map[string]string{"partner_user_id": "must be set"},
+		},
+		"unmatch oneself": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.Unmatch(ctx, &pb.UnmatchRequest{UserId: "user1", PartnerUserId: "user1"})
+				return err
+			},
+			wantViolations: map[string]string{"partner_user_id": "must be different from user_id"},
+		},
+		"relationships of too many users": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.GetRelationship(ctx, &pb.GetRelationshipRequest{
+					ViewerUserId: "user1",
+					OtherUserIds: make([]string, MaxRelationshipUsers+1),
+				})
+				return err
+			},
+			wantViolations: map[string]string{"other_user_ids": "must have at most 500 users"},
+		},
+		"relationship with the viewer": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.GetRelationship(ctx, &pb.GetRelationshipRequest{
+					ViewerUserId: "user1",
+					OtherUserIds: []string{"user2", "user1", ""},
+				})
+				return err
+			},
+			wantViolations: map[string]string{
+				"other_user_ids[1]": "must be different from viewer_user_id",
+				"other_user_ids[2]": "must be set",
+			},
+		},
+		"list likes with unknown sort order and an empty time range": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.ListLikedYou(ctx, &pb.ListLikedYouRequest{
+					RecipientUserId:    "user1",
+					SortOrder:          9,
+					SinceUnixTimestamp: 20,
+					UntilUnixTimestamp: 10,
+				})
+				return err
+			},
+			wantViolations: map[string]string{
+				"sort_order":           "unknown sort order",
+				"until_unix_timestamp": "must be after since_unix_timestamp",
+			},
+		},
+		"list you liked with unknown types and an empty time range": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.ListYouLiked(ctx, &pb.ListYouLikedRequest{
+					ActorUserId:        "user1",
+					DecisionTypes:      []pb.DecisionType{pb.DecisionType_DECISION_TYPE_LIKE, pb.DecisionType_DECISION_TYPE_UNSPECIFIED, 9},
+					SinceUnixTimestamp: 20,
+					UntilUnixTimestamp: 20,
+				})
+				return err
+			},
+			wantViolations: map[string]string{
+				"decision_types[1]":    "unknown decision type",
+				"decision_types[2]":    "unknown decision type",
+				"until_unix_timestamp": "must be after since_unix_timestamp",
+			},
+		},
+		"list matches without user": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.ListMutualMatches(ctx, &pb.ListMutualMatchesRequest{})
+				return err
+			},
+			wantViolations: map[string]string{"user_id": "must be set"},
+		},
+		"acknowledge likes of too many actors": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.AcknowledgeLikes(ctx, &pb.AcknowledgeLikesRequest{
+					RecipientUserId: "user1",
+					ActorUserIds:    make([]string, MaxAcknowledgedActors+1),
+				})
+				return err
+			},
+			wantViolations: map[string]string{"actor_user_ids": "must have at most 500 users"},
+		},
+		"acknowledge likes with an invalid actor": {
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.AcknowledgeLikes(ctx, &pb.AcknowledgeLikesRequest{
+					RecipientUserId: "user1",
+					ActorUserIds:    []string{"user2", tooLong},
+				})
+				return err
+			},
+			wantViolations: map[string]string{"actor_user_ids[1]": "must be at most 10 characters long"},
+		},
+		"custom rules": {
+			rules: &UserIDRules{MaxLength: 20, Pattern: regexp.MustCompile(`^[0-9]+$`)},
+			call: func(ctx context.Context, s *ServiceServer) error {
+				_, err := s.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: tooLong, RecipientUserId: "user2"})
+				return err
+			},
+			wantViolations: map[string]string{"recipient_user_id": `must match "^[0-9]+$"`},
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server whose store must not be called.
+			ctx := context.Background()
+			opts := []Option{WithPageTokenCodec(testPageTokens)}
+			if tc.rules != nil {
+				opts = append(opts, WithUserIDRules(*tc.rules))
+			}
+			s := NewServiceServer(mocks.NewDecisionStore(t), opts...)
+
+			// WHEN: The RPC is called with an invalid request.
+			err := tc.call(ctx, s)
+
+			// THEN: An InvalidArgument error with the field violations is returned.
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, codes.InvalidArgument, st.Code())
+			require.Len(t, st.Details(), 1)
+			badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+			require.True(t, ok)
+			gotViolations := map[string]string{}
+			for _, violation := range badRequest.GetFieldViolations() {
+				gotViolations[violation.GetField()] = violation.GetDescription()
+			}
+			assert.Equal(t, tc.wantViolations, gotViolations)
 		})
 	}
 }
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	pb "muzz-explore/internal/api"
+)
+
+// SwipeSession records the decisions streamed by a client as they are made, acknowledging each of
+// them and notifying the new matches they complete on the same stream.
+//
+// Decisions are handled one at a time, in order: the next one isn't received until the events of the
+// previous one are sent. So a client sending faster than decisions are recorded, or not reading its
+// events, is slowed down by the flow control of the stream instead of piling them up in memory. A
+// failed decision is reported in its ack without ending the session, which only ends when the client
+// closes its side or the stream is cancelled.
+func (s *ServiceServer) SwipeSession(stream pb.ExploreService_SwipeSessionServer) error {
+	ctx := stream.Context()
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// The stream is broken or cancelled, and its status is already set by gRPC.
+			return err
+		}
+		for _, event := range s.swipe(ctx, in) {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// swipe records a decision of a session, returning the events to send back for it.
+func (s *ServiceServer) swipe(ctx context.Context, in *pb.SwipeSessionRequest) []*pb.SwipeSessionResponse {
+	ack := &pb.SwipeSessionResponse_Ack{RequestId: in.GetRequestId()}
+	events := []*pb.SwipeSessionResponse{{Event: &pb.SwipeSessionResponse_Ack_{Ack: ack}}}
+	decision := in.GetDecision()
+	if err := s.validatePutDecisionRequest(decision); err != nil {
+		ack.Error = toResultError(err)
+		return events
+	}
+	_, matched, err := s.putDecision(ctx, decision)
+	if err != nil {
+		ack.Error = toResultError(err)
+		return events
+	}
+	if matched {
+		events = append(events, &pb.SwipeSessionResponse{Event: &pb.SwipeSessionResponse_Match_{
+			Match: &pb.SwipeSessionResponse_Match{
+				RequestId: in.GetRequestId(),
+				PartnerId: decision.GetRecipientUserId(),
+			},
+		}})
+	}
+	return events
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	pb "muzz-explore/internal/api"
+	"muzz-explore/internal/store"
+	"muzz-explore/server/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newBufconnClient serves s in process, and returns a client connected to it.
+func newBufconnClient(t *testing.T, s pb.ExploreServiceServer) pb.ExploreServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	pb.RegisterExploreServiceServer(grpcServer, s)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewExploreServiceClient(conn)
+}
+
+func TestSwipeSession(t *testing.T) {
+	testMap := map[string]struct {
+		in                       []*pb.SwipeSessionRequest
+		decisionStoreMockFactory func(lastModified int64) DecisionStore
+		want                     []*pb.SwipeSessionResponse
+	}{
+		"no decisions": {
+			in: nil,
+			decisionStoreMockFactory: func(lastModified int64) DecisionStore {
+				return mocks.NewDecisionStore(t)
+			},
+			want: nil,
+		},
+		"pass, match, and invalid decision": {
+			in: []*pb.SwipeSessionRequest{
+				{RequestId: "1", Decision: &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user2"}},
+				{RequestId: "2", Decision: &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user3", LikedRecipient: true}},
+				{RequestId: "3", Decision: &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user1", LikedRecipient: true}},
+			},
+			decisionStoreMockFactory: func(lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecision(mock.Anything, store.Decision{
+					ActorUserID:     "user1",
+					RecipientUserID: "user2",
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionPass,
+				}).Return(0, nil)
+				dsMock.EXPECT().UpsertDecision(mock.Anything, store.Decision{
+					ActorUserID:     "user1",
+					RecipientUserID: "user3",
+					LikedRecipient:  true,
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(0, nil)
+				dsMock.EXPECT().GetDecisions(mock.Anything, []store.DecisionKey{{ActorUserID: "user3", RecipientUserID: "user1"}}).Return([]store.Decision{
+					{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: true, Type: store.DecisionLike},
+				}, nil)
+				return dsMock
+			},
+			want: []*pb.SwipeSessionResponse{
+				{Event: &pb.SwipeSessionResponse_Ack_{Ack: &pb.SwipeSessionResponse_Ack{RequestId: "1"}}},
+				{Event: &pb.SwipeSessionResponse_Ack_{Ack: &pb.SwipeSessionResponse_Ack{RequestId: "2"}}},
+				{Event: &pb.SwipeSessionResponse_Match_{Match: &pb.SwipeSessionResponse_Match{
+					RequestId: "2",
+					PartnerId: "user3",
+				}}},
+				{Event: &pb.SwipeSessionResponse_Ack_{Ack: &pb.SwipeSessionResponse_Ack{
+					RequestId: "3",
+					Error: &pb.PutDecisionsResponse_Result_Error{
+						Code:    int32(codes.InvalidArgument),
+						Message: "invalid request; recipient_user_id must be different from actor_user_id",
+					},
+				}}},
+			},
+		},
+		"like of a match re-submitted": {
+			in: []*pb.SwipeSessionRequest{
+				{RequestId: "1", Decision: &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user3", LikedRecipient: true}},
+			},
+			decisionStoreMockFactory: func(lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecision(mock.Anything, store.Decision{
+					ActorUserID:     "user1",
+					RecipientUserID: "user3",
+					LikedRecipient:  true,
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionLike,
+					LikedAt:         lastModified,
+				}).Return(store.DecisionLike, nil)
+				dsMock.EXPECT().GetDecisions(mock.Anything, []store.DecisionKey{{ActorUserID: "user3", RecipientUserID: "user1"}}).Return([]store.Decision{
+					{ActorUserID: "user3", RecipientUserID: "user1", LikedRecipient: true, Type: store.DecisionLike},
+				}, nil)
+				return dsMock
+			},
+			want: []*pb.SwipeSessionResponse{
+				{Event: &pb.SwipeSessionResponse_Ack_{Ack: &pb.SwipeSessionResponse_Ack{RequestId: "1"}}},
+			},
+		},
+		"store error doesn't end the session": {
+			in: []*pb.SwipeSessionRequest{
+				{RequestId: "1", Decision: &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user2"}},
+				{RequestId: "2", Decision: &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user3"}},
+			},
+			decisionStoreMockFactory: func(lastModified int64) DecisionStore {
+				dsMock := mocks.NewDecisionStore(t)
+				dsMock.EXPECT().UpsertDecision(mock.Anything, store.Decision{
+					ActorUserID:     "user1",
+					RecipientUserID: "user2",
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionPass,
+				}).Return(0, fmt.Errorf("some error: %w", store.ErrUnavailable))
+				dsMock.EXPECT().UpsertDecision(mock.Anything, store.Decision{
+					ActorUserID:     "user1",
+					RecipientUserID: "user3",
+					LastModified:    lastModified,
+					CreatedAt:       lastModified,
+					Type:            store.DecisionPass,
+				}).Return(0, nil)
+				return dsMock
+			},
+			want: []*pb.SwipeSessionResponse{
+				{Event: &pb.SwipeSessionResponse_Ack_{Ack: &pb.SwipeSessionResponse_Ack{
+					RequestId: "1",
+					Error: &pb.PutDecisionsResponse_Result_Error{
+						Code:    int32(codes.Unavailable),
+						Message: "failed to upsert decision",
+					},
+				}}},
+				{Event: &pb.SwipeSessionResponse_Ack_{Ack: &pb.SwipeSessionResponse_Ack{RequestId: "2"}}},
+			},
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server served in process, with a session open.
+			ctx := context.Background()
+			now := time.Now()
+			s := NewServiceServer(tc.decisionStoreMockFactory(now.Unix()))
+			s.nowFn = func() time.Time { return now }
+			session, err := newBufconnClient(t, s).SwipeSession(ctx)
+			require.NoError(t, err)
+
+			// WHEN: The decisions are sent, and the session closed by the client.
+			for _, in := range tc.in {
+				require.NoError(t, session.Send(in))
+			}
+			require.NoError(t, session.CloseSend())
+
+			// THEN: The events should match the expectations, and the session end cleanly.
+			var got []*pb.SwipeSessionResponse
+			for {
+				event, err := session.Recv()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, event)
+			}
+			require.Len(t, got, len(tc.want))
+			for i := range tc.want {
+				assert.Equal(t, tc.want[i].String(), got[i].String())
+			}
+		})
+	}
+}
+
+func TestSwipeSessionCancelled(t *testing.T) {
+	// GIVEN: A Server served in process, with a session open whose decision is being recorded.
+	recording := make(chan struct{})
+	dsMock := mocks.NewDecisionStore(t)
+	dsMock.EXPECT().UpsertDecision(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, _ store.Decision) (store.DecisionType, error) {
+			close(recording)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	ctx, cancel := context.WithCancel(context.Background())
+	session, err := newBufconnClient(t, NewServiceServer(dsMock)).SwipeSession(ctx)
+	require.NoError(t, err)
+	require.NoError(t, session.Send(&pb.SwipeSessionRequest{
+		RequestId: "1",
+		Decision:  &pb.PutDecisionRequest{ActorUserId: "user1", RecipientUserId: "user2"},
+	}))
+	<-recording
+
+	// WHEN: The client cancels the session.
+	cancel()
+
+	// THEN: The session ends as cancelled, and the decision in progress is cancelled too.
+	_, err = session.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	pb "muzz-explore/internal/api"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UserIDRules are the rules the user IDs received in requests must follow.
+type UserIDRules struct {
+	// MaxLength is the maximum length of an ID, in characters. Zero means no limit.
+	MaxLength int
+	// Pattern, if set, must match the whole ID.
+	Pattern *regexp.Regexp
+}
+
+// MaxBatchDecisions is the maximum number of decisions accepted by PutDecisions.
+const MaxBatchDecisions = 100
+
+// MaxRelationshipUsers is the maximum number of other users accepted by GetRelationship.
+const MaxRelationshipUsers = 500
+
+// MaxAcknowledgedActors is the maximum number of actors accepted by AcknowledgeLikes.
+const MaxAcknowledgedActors = 500
+
+// DefaultUserIDRules only limit the length of IDs to the size of the user ID columns of the database.
+var DefaultUserIDRules = UserIDRules{MaxLength: 10}
+
+// WithUserIDRules sets the rules user IDs are validated with. By default, DefaultUserIDRules are used.
+func WithUserIDRules(r UserIDRules) Option {
+	return func(s *ServiceServer) { s.userIDRules = r }
+}
+
+// violations collects the fields of a request that are not valid.
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) add(field, description string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+// userID checks that a user ID is set and follows the rules.
+func (v *violations) userID(rules UserIDRules, field, id string) {
+	switch {
+	case id == "":
+		v.add(field, "must be set")
+	case !utf8.ValidString(id):
+		v.add(field, "must be valid UTF-8")
+	case rules.MaxLength > 0 && utf8.RuneCountInString(id) > rules.MaxLength:
+		v.add(field, fmt.Sprintf("must be at most %d characters long", rules.MaxLength))
+	case rules.Pattern != nil && !rules.Pattern.MatchString(id):
+		v.add(field, fmt.Sprintf("must match %q", rules.Pattern.String()))
+	}
+}
+
+// err returns an InvalidArgument status carrying the violations, or nil if there are none.
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	st, err := status.New(codes.InvalidArgument, "invalid request").
+		WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		// Details can't fail to marshal, but the client still has to know the request is not valid.
+		return status.Error(codes.InvalidArgument, "invalid request")
+	}
+	return st.Err()
+}
+
+// toResultError turns the error of a single item of a batch into its result error, flattening the
+// field violations into the message as there's no room for details.
+func toResultError(err error) *pb.PutDecisionsResponse_Result_Error {
+	st := status.Convert(err)
+	message := st.Message()
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.GetFieldViolations() {
+			message += fmt.Sprintf("; %s %s", violation.GetField(), violation.GetDescription())
+		}
+	}
+	return &pb.PutDecisionsResponse_Result_Error{Code: int32(st.Code()), Message: message}
+}
+
+func (s *ServiceServer) validateListLikedYouRequest(in *pb.ListLikedYouRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	if _, ok := pb.AcknowledgementMode_name[int32(in.GetAcknowledgementMode())]; !ok {
+		v.add("acknowledgement_mode", "unknown acknowledgement mode")
+	}
+	if _, ok := pb.NewLikesFilter_name[int32(in.GetNewLikesFilter())]; !ok {
+		v.add("new_likes_filter", "unknown new likes filter")
+	}
+	if _, ok := pb.SortOrder_name[int32(in.GetSortOrder())]; !ok {
+		v.add("sort_order", "unknown sort order")
+	}
+	if in.GetUntilUnixTimestamp() != 0 && in.GetUntilUnixTimestamp() <= in.GetSinceUnixTimestamp() {
+		v.add("until_unix_timestamp", "must be after since_unix_timestamp")
+	}
+	return v.err()
+}
+
+func (s *ServiceServer) validateListYouLikedRequest(in *pb.ListYouLikedRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "actor_user_id", in.GetActorUserId())
+	for i, decisionType := range in.GetDecisionTypes() {
+		if _, ok := pb.DecisionType_name[int32(decisionType)]; !ok || decisionType == pb.DecisionType_DECISION_TYPE_UNSPECIFIED {
+			v.add(fmt.Sprintf("decision_types[%d]", i), "unknown decision type")
+		}
+	}
+	if in.GetUntilUnixTimestamp() != 0 && in.GetUntilUnixTimestamp() <= in.GetSinceUnixTimestamp() {
+		v.add("until_unix_timestamp", "must be after since_unix_timestamp")
+	}
+	return v.err()
+}
+
+func (s *ServiceServer) validateCountLikedYouRequest(in *pb.CountLikedYouRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	return v.err()
+}
+
+func (s *ServiceServer) validatePutDecisionRequest(in *pb.PutDecisionRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "actor_user_id", in.GetActorUserId())
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	if in.GetActorUserId() != "" && in.GetActorUserId() == in.GetRecipientUserId() {
+		v.add("recipient_user_id", "must be different from actor_user_id")
+	}
+	if _, ok := pb.DecisionType_name[int32(in.GetDecisionType())]; !ok {
+		v.add("decision_type", "unknown decision type")
+	} else if in.GetLikedRecipient() && in.GetDecisionType() != pb.DecisionType_DECISION_TYPE_UNSPECIFIED &&
+		!decisionType(in).Liked() {
+		v.add("decision_type", "must be a like when liked_recipient is set")
+	}
+	return v.err()
+}
+
+func (s *ServiceServer) validateUndoDecisionRequest(in *pb.UndoDecisionRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "actor_user_id", in.GetActorUserId())
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	return v.err()
+}
+
+func (s *ServiceServer) validateDeleteDecisionRequest(in *pb.DeleteDecisionRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "actor_user_id", in.GetActorUserId())
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	return v.err()
+}
+
+func (s *ServiceServer) validateUnmatchRequest(in *pb.UnmatchRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "user_id", in.GetUserId())
+	v.userID(s.userIDRules, "partner_user_id", in.GetPartnerUserId())
+	if in.GetUserId() != "" && in.GetUserId() == in.GetPartnerUserId() {
+		v.add("partner_user_id", "must be different from user_id")
+	}
+	return v.err()
+}
+
+// validatePutDecisionsRequest only checks the size of the batch, as each decision is validated on its
+// own so the valid ones can still be recorded.
+func (s *ServiceServer) validatePutDecisionsRequest(in *pb.PutDecisionsRequest) error {
+	var v violations
+	if len(in.GetDecisions()) > MaxBatchDecisions {
+		v.add("decisions", fmt.Sprintf("must have at most %d decisions", MaxBatchDecisions))
+	}
+	return v.err()
+}
+
+func (s *ServiceServer) validateGetRelationshipRequest(in *pb.GetRelationshipRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "viewer_user_id", in.GetViewerUserId())
+	if len(in.GetOtherUserIds()) > MaxRelationshipUsers {
+		v.add("other_user_ids", fmt.Sprintf("must have at most %d users", MaxRelationshipUsers))
+		return v.err()
+	}
+	for i, other := range in.GetOtherUserIds() {
+		field := fmt.Sprintf("other_user_ids[%d]", i)
+		v.userID(s.userIDRules, field, other)
+		if other != "" && other == in.GetViewerUserId() {
+			v.add(field, "must be different from viewer_user_id")
+		}
+	}
+	return v.err()
+}
+
+func (s *ServiceServer) validateWatchLikesRequest(in *pb.WatchLikesRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	return v.err()
+}
+
+func (s *ServiceServer) validateListMutualMatchesRequest(in *pb.ListMutualMatchesRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "user_id", in.GetUserId())
+	return v.err()
+}
+
+func (s *ServiceServer) validateAcknowledgeLikesRequest(in *pb.AcknowledgeLikesRequest) error {
+	var v violations
+	v.userID(s.userIDRules, "recipient_user_id", in.GetRecipientUserId())
+	if len(in.GetActorUserIds()) > MaxAcknowledgedActors {
+		v.add("actor_user_ids", fmt.Sprintf("must have at most %d users", MaxAcknowledgedActors))
+		return v.err()
+	}
+	for i, actor := range in.GetActorUserIds() {
+		v.userID(s.userIDRules, fmt.Sprintf("actor_user_ids[%d]", i), actor)
+	}
+	return v.err()
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+
+	pb "muzz-explore/internal/api"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchLikes pushes the likes received by a user, and the matches completed with them or dissolved,
+// as they are recorded. Clients resuming a stream pass the cursor of the last event they got, so they don't miss
+// the ones published meanwhile. If those are gone, or the client doesn't keep up with its events, the
+// stream fails, and the client has to list the new likes before watching again.
+func (s *ServiceServer) WatchLikes(in *pb.WatchLikesRequest, stream pb.ExploreService_WatchLikesServer) error {
+	if err := s.validateWatchLikesRequest(in); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	after, err := s.pageTokens.Decode(in.GetCursor())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid cursor")
+	}
+	sub, err := s.likesHub.Subscribe(ctx, in.GetRecipientUserId(), after)
+	if errors.Is(err, ErrCursorExpired) {
+		return status.Error(codes.OutOfRange, "cursor expired, list the new likes before watching again")
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to subscribe to like events")
+		return status.Error(codes.Unavailable, "failed to watch likes")
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), ErrSlowSubscriber) {
+					return status.Error(codes.ResourceExhausted, "too many events pending, resume from the last cursor")
+				}
+				log.Error().Err(sub.Err()).Msg("like events subscription ended")
+				return status.Error(codes.Unavailable, "failed to watch likes")
+			}
+			resp, err := s.toWatchLikesResponse(event)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *ServiceServer) toWatchLikesResponse(event LikeEvent) (*pb.WatchLikesResponse, error) {
+	cursor, err := s.pageTokens.Encode(event.Cursor)
+	if err != nil {
+		log.Err(err).Msg("failed to encode cursor")
+		return nil, status.Error(codes.Internal, "failed to encode cursor")
+	}
+	resp := &pb.WatchLikesResponse{Cursor: cursor}
+	switch event.Kind {
+	case LikeEventMatch:
+		resp.Event = &pb.WatchLikesResponse_Match_{Match: &pb.WatchLikesResponse_Match{
+			PartnerId:     event.ActorUserID,
+			UnixTimestamp: uint64(event.Timestamp),
+		}}
+	case LikeEventUnmatch:
+		resp.Event = &pb.WatchLikesResponse_Unmatch_{Unmatch: &pb.WatchLikesResponse_Unmatch{
+			PartnerId:     event.ActorUserID,
+			UnixTimestamp: uint64(event.Timestamp),
+		}}
+	default:
+		resp.Event = &pb.WatchLikesResponse_Like_{Like: &pb.WatchLikesResponse_Like{
+			ActorId:       event.ActorUserID,
+			UnixTimestamp: uint64(event.Timestamp),
+			SuperLike:     event.SuperLike,
+		}}
+	}
+	return resp, nil
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "muzz-explore/internal/api"
+	"muzz-explore/internal/store"
+	"muzz-explore/server/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// waitForWatchers waits until a recipient has the given number of streams watching their likes.
+func waitForWatchers(t *testing.T, hub *MemoryHub, recipient string, n int) {
+	require.Eventually(t, func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return len(hub.subscriptions[recipient]) == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestWatchLikes(t *testing.T) {
+	// GIVEN: A Server served in process, with user1 watching their likes.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Now()
+	dsMock := mocks.NewDecisionStore(t)
+	dsMock.EXPECT().UpsertDecision(mock.Anything, mock.Anything).Return(0, nil)
+	dsMock.EXPECT().GetDecisions(mock.Anything, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user2"}}).Return(nil, nil)
+	dsMock.EXPECT().GetDecisions(mock.Anything, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user3"}}).Return([]store.Decision{
+		{ActorUserID: "user1", RecipientUserID: "user3", LikedRecipient: true, Type: store.DecisionLike},
+	}, nil)
+	dsMock.EXPECT().GetDecisions(mock.Anything, []store.DecisionKey{{ActorUserID: "user1", RecipientUserID: "user5"}}).Return([]store.Decision{
+		{ActorUserID: "user1", RecipientUserID: "user5", Type: store.DecisionBlock},
+	}, nil)
+	hub := NewMemoryHub(10, 10)
+	s := NewServiceServer(dsMock, WithPageTokenCodec(testPageTokens), WithLikesHub(hub))
+	s.nowFn = func() time.Time { return now }
+	client := newBufconnClient(t, s)
+	watch, err := client.WatchLikes(ctx, &pb.WatchLikesRequest{RecipientUserId: "user1"})
+	require.NoError(t, err)
+	waitForWatchers(t, hub, "user1", 1)
+
+	// WHEN: Some decisions on user1 are recorded.
+	_, err = client.PutDecision(ctx, &pb.PutDecisionRequest{
+		ActorUserId:     "user2",
+		RecipientUserId: "user1",
+		DecisionType:    pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+	})
+	require.NoError(t, err)
+	_, err = client.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "user4", RecipientUserId: "user1", LikedRecipient: false})
+	require.NoError(t, err)
+	_, err = client.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "user5", RecipientUserId: "user1", LikedRecipient: true})
+	require.NoError(t, err)
+	_, err = client.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "user3", RecipientUserId: "user1", LikedRecipient: true})
+	require.NoError(t, err)
+
+	// THEN: The super-like and the match are pushed, but not the pass nor the like of a blocked user.
+	like, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "user2", like.GetLike().GetActorId())
+	assert.True(t, like.GetLike().GetSuperLike())
+	assert.Equal(t, uint64(now.Unix()), like.GetLike().GetUnixTimestamp())
+	match, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "user3", match.GetMatch().GetPartnerId())
+
+	// WHEN: user1 watches again from the cursor of the like.
+	cancel()
+	waitForWatchers(t, hub, "user1", 0)
+	resumed, err := client.WatchLikes(context.Background(), &pb.WatchLikesRequest{
+		RecipientUserId: "user1",
+		Cursor:          ref(like.GetCursor()),
+	})
+	require.NoError(t, err)
+
+	// THEN: The match is pushed again.
+	got, err := resumed.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, match.GetCursor(), got.GetCursor())
+	assert.Equal(t, "user3", got.GetMatch().GetPartnerId())
+}
+
+func TestWatchLikesInvalidCursor(t *testing.T) {
+	otherHub := NewMemoryHub(10, 10)
+	require.NoError(t, otherHub.Publish(context.Background(), LikeEvent{RecipientUserID: "user1", ActorUserID: "user2"}))
+	otherSub, err := otherHub.Subscribe(context.Background(), "user1", "")
+	require.NoError(t, err)
+	require.NoError(t, otherHub.Publish(context.Background(), LikeEvent{RecipientUserID: "user1", ActorUserID: "user2"}))
+	otherCursor := (<-otherSub.Events()).Cursor
+
+	testMap := map[string]struct {
+		cursor   string
+		wantCode codes.Code
+	}{
+		"forged cursor": {
+			cursor:   "forged",
+			wantCode: codes.InvalidArgument,
+		},
+		"cursor of another run": {
+			cursor:   testPageToken(otherCursor),
+			wantCode: codes.OutOfRange,
+		},
+	}
+	for name, tc := range testMap {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN: A Server served in process.
+			client := newBufconnClient(t, NewServiceServer(mocks.NewDecisionStore(t), WithPageTokenCodec(testPageTokens)))
+
+			// WHEN: Likes are watched from a cursor that can't be used.
+			watch, err := client.WatchLikes(context.Background(), &pb.WatchLikesRequest{
+				RecipientUserId: "user1",
+				Cursor:          ref(tc.cursor),
+			})
+			require.NoError(t, err)
+			_, err = watch.Recv()
+
+			// THEN: The stream fails with the expected code.
+			assert.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+}
@@ -3,12 +3,23 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	pb "muzz-explore/internal/api"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	implicitAck = pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_IMPLICIT
+	explicitAck = pb.AcknowledgementMode_ACKNOWLEDGEMENT_MODE_EXPLICIT
 )
 
 // This will be the function in charge of running the tests.
@@ -21,6 +32,23 @@ import (
 // 5. Put new decision.
 // 6. List new likes.
 // 7. List all likes.
+// 8. List mutual matches.
+// 9. List likes across multiple pages.
+// 10. List new likes acknowledging them explicitly.
+// 11. Receive a like while a page of new likes is being marked as seen.
+// 12. Re-submit decisions, which only become new again when flipping from pass to like.
+// 13. Send invalid requests, which are rejected before reaching the database.
+// 14. List new likes excluding the ones liked in return.
+// 15. Put a batch of decisions, with an invalid one.
+// 16. Swipe through a session, getting acks and matches on the same stream.
+// 17. Watch likes as they are recorded.
+// 18. Super-like and block.
+// 19. Undo decisions.
+// 20. Unmatch and delete decisions.
+// 21. Get the relationships of a user with several others at once.
+// 22. List the decisions of a user on others.
+// 23. List likes across multiple pages sorted by time.
+// 24. List likes with a page size over the maximum, along with their total count.
 func main() {
 	ctx := context.Background()
 	con, err := grpc.NewClient("localhost:8080", grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -56,15 +84,15 @@ func main() {
 	time.Sleep(time.Second)
 
 	// 3. List new likes.
-	if err := listNewLikes(ctx, pbcl, "1", []string{"3"}); err != nil {
+	if err := listNewLikes(ctx, pbcl, "1", implicitAck, []string{"3"}); err != nil {
 		log.Fatal().Msgf("failed to list new likes for user 1: %v", err)
 	}
-	if err := listNewLikes(ctx, pbcl, "3", []string{"1"}); err != nil {
+	if err := listNewLikes(ctx, pbcl, "3", implicitAck, []string{"1"}); err != nil {
 		log.Fatal().Msgf("failed to list new likes for user 3: %v", err)
 	}
 
 	// 4. List new likes again (responses should be empty).
-	if err := listNewLikes(ctx, pbcl, "1", nil); err != nil {
+	if err := listNewLikes(ctx, pbcl, "1", implicitAck, nil); err != nil {
 		log.Fatal().Msgf("failed to list new likes for user 1: %v", err)
 	}
 	fmt.Println("New likes listed")
@@ -75,7 +103,7 @@ func main() {
 	}
 
 	// 6. List new likes.
-	if err := listNewLikes(ctx, pbcl, "1", []string{"2"}); err != nil {
+	if err := listNewLikes(ctx, pbcl, "1", implicitAck, []string{"2"}); err != nil {
 		log.Fatal().Msgf("failed to list new likes for user 1: %v", err)
 	}
 
@@ -85,9 +113,475 @@ func main() {
 	}
 
 	fmt.Println("All likes listed")
+
+	// 8. List mutual matches.
+	if err := listMutualMatches(ctx, pbcl, "1", []string{"3"}); err != nil {
+		log.Fatal().Msgf("failed to list mutual matches for user 1: %v", err)
+	}
+	if err := listMutualMatches(ctx, pbcl, "3", []string{"1"}); err != nil {
+		log.Fatal().Msgf("failed to list mutual matches for user 3: %v", err)
+	}
+	// User 2 likes user 1, but user 1 passed on user 2.
+	if err := listMutualMatches(ctx, pbcl, "2", nil); err != nil {
+		log.Fatal().Msgf("failed to list mutual matches for user 2: %v", err)
+	}
+
+	fmt.Println("Mutual matches listed")
+
+	// 9. List likes across multiple pages.
+	// Actors sort lower than the recipient, which made the old pagination skip every later page.
+	wantLikers := []string{}
+	for i := 0; i < 25; i++ {
+		actor := fmt.Sprintf("5%02d", i)
+		if err := putDecision(ctx, pbcl, actor, "900", true, false); err != nil {
+			log.Fatal().Msgf("failed to put decision of user %s: %v", actor, err)
+		}
+		wantLikers = append(wantLikers, actor)
+	}
+	if err := listAllLikes(ctx, pbcl, "900", pb.SortOrder_SORT_ORDER_UNSPECIFIED, wantLikers); err != nil {
+		log.Fatal().Msgf("failed to list all likes for user 900: %v", err)
+	}
+
+	fmt.Println("Likes listed across pages")
+
+	// 10. List new likes acknowledging them explicitly.
+	for _, actor := range []string{"601", "602"} {
+		if err := putDecision(ctx, pbcl, actor, "901", true, false); err != nil {
+			log.Fatal().Msgf("failed to put decision of user %s: %v", actor, err)
+		}
+	}
+	// Likes are not marked as seen by listing them.
+	for i := 0; i < 2; i++ {
+		if err := listNewLikes(ctx, pbcl, "901", explicitAck, []string{"601", "602"}); err != nil {
+			log.Fatal().Msgf("failed to list new likes for user 901: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	// Only the acknowledged likes are marked as seen.
+	if _, err := pbcl.AcknowledgeLikes(ctx, &pb.AcknowledgeLikesRequest{
+		RecipientUserId: "901",
+		ActorUserIds:    []string{"601"},
+	}); err != nil {
+		log.Fatal().Msgf("failed to acknowledge likes for user 901: %v", err)
+	}
+	if err := listNewLikes(ctx, pbcl, "901", explicitAck, []string{"602"}); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 901: %v", err)
+	}
+
+	fmt.Println("New likes acknowledged")
+
+	// 11. Receive a like while a page of new likes is being marked as seen.
+	for _, actor := range []string{"701", "703"} {
+		if err := putDecision(ctx, pbcl, actor, "902", true, false); err != nil {
+			log.Fatal().Msgf("failed to put decision of user %s: %v", actor, err)
+		}
+	}
+	if err := listNewLikes(ctx, pbcl, "902", implicitAck, []string{"701", "703"}); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 902: %v", err)
+	}
+	// This like sorts in between the ones listed, but wasn't returned, so it must stay new.
+	if err := putDecision(ctx, pbcl, "702", "902", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 702: %v", err)
+	}
+	time.Sleep(time.Second)
+	if err := listNewLikes(ctx, pbcl, "902", implicitAck, []string{"702"}); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 902: %v", err)
+	}
+
+	fmt.Println("Concurrent like kept as new")
+
+	// 12. Re-submit decisions, which only become new again when flipping from pass to like.
+	if err := putDecision(ctx, pbcl, "801", "903", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 801: %v", err)
+	}
+	if err := listNewLikes(ctx, pbcl, "903", implicitAck, []string{"801"}); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 903: %v", err)
+	}
+	time.Sleep(time.Second)
+	if err := putDecision(ctx, pbcl, "801", "903", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 801: %v", err)
+	}
+	if err := listNewLikes(ctx, pbcl, "903", implicitAck, nil); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 903 after re-like: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "801", "903", false, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 801: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "801", "903", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 801: %v", err)
+	}
+	if err := listNewLikes(ctx, pbcl, "903", implicitAck, []string{"801"}); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 903 after pass and like: %v", err)
+	}
+	resp, err := pbcl.ListLikedYou(ctx, &pb.ListLikedYouRequest{
+		RecipientUserId:     "903",
+		AcknowledgementMode: explicitAck,
+	})
+	if err != nil {
+		log.Fatal().Msgf("failed to list likes for user 903: %v", err)
+	}
+	if liker := resp.GetLikers()[0]; liker.GetCreatedUnixTimestamp() >= liker.GetUnixTimestamp() {
+		log.Fatal().Msgf(
+			"first decision time was not kept: created at %d, last modified at %d",
+			liker.GetCreatedUnixTimestamp(),
+			liker.GetUnixTimestamp(),
+		)
+	}
+
+	fmt.Println("Re-submitted decisions upserted")
+
+	// 13. Send invalid requests, which are rejected before reaching the database.
+	_, err = pbcl.PutDecision(ctx, &pb.PutDecisionRequest{ActorUserId: "1", RecipientUserId: "1"})
+	if status.Code(err) != codes.InvalidArgument {
+		log.Fatal().Msgf("unexpected error putting a decision on oneself: %v", err)
+	}
+	_, err = pbcl.CountLikedYou(ctx, &pb.CountLikedYouRequest{RecipientUserId: "12345678901"})
+	if status.Code(err) != codes.InvalidArgument {
+		log.Fatal().Msgf("unexpected error counting likes of a too long user ID: %v", err)
+	}
+
+	fmt.Println("Invalid requests rejected")
+
+	// 14. List new likes excluding the ones liked in return.
+	// Users 1001 and 1002 like user 904, who only likes 1001 back.
+	for _, actor := range []string{"1001", "1002"} {
+		if err := putDecision(ctx, pbcl, actor, "904", true, false); err != nil {
+			log.Fatal().Msgf("failed to put decision of user %s: %v", actor, err)
+		}
+	}
+	if err := putDecision(ctx, pbcl, "904", "1001", true, true); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 904: %v", err)
+	}
+	err = checkNewLikes(ctx, pbcl, &pb.ListLikedYouRequest{
+		RecipientUserId:     "904",
+		AcknowledgementMode: explicitAck,
+		NewLikesFilter:      pb.NewLikesFilter_NEW_LIKES_FILTER_UNRECIPROCATED,
+	}, []string{"1002"})
+	if err != nil {
+		log.Fatal().Msgf("failed to list unreciprocated likes for user 904: %v", err)
+	}
+	// Without the filter, both likes are still unseen.
+	if err := listNewLikes(ctx, pbcl, "904", explicitAck, []string{"1001", "1002"}); err != nil {
+		log.Fatal().Msgf("failed to list new likes for user 904: %v", err)
+	}
+
+	fmt.Println("Reciprocated likes excluded")
+
+	// 15. Put a batch of decisions, with an invalid one.
+	// User 905 likes user 1101, who then flushes a batch liking 905 back and passing 906.
+	if err := putDecision(ctx, pbcl, "905", "1101", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 905: %v", err)
+	}
+	batchResp, err := pbcl.PutDecisions(ctx, &pb.PutDecisionsRequest{Decisions: []*pb.PutDecisionRequest{
+		{ActorUserId: "1101", RecipientUserId: "905", LikedRecipient: true},
+		{ActorUserId: "1101", RecipientUserId: "1101", LikedRecipient: true},
+		{ActorUserId: "1101", RecipientUserId: "906", LikedRecipient: false},
+	}})
+	if err != nil {
+		log.Fatal().Msgf("failed to put batch of decisions: %v", err)
+	}
+	results := batchResp.GetResults()
+	if len(results) != 3 ||
+		!results[0].GetMutualLikes() || results[0].GetError() != nil ||
+		codes.Code(results[1].GetError().GetCode()) != codes.InvalidArgument ||
+		results[2].GetMutualLikes() || results[2].GetError() != nil {
+		log.Fatal().Msgf("unexpected results of the batch of decisions: %v", results)
+	}
+	if err := listLikes(ctx, pbcl, "1101", []string{"905"}); err != nil {
+		log.Fatal().Msgf("failed to list likes for user 1101: %v", err)
+	}
+
+	fmt.Println("Batch of decisions put")
+
+	// 16. Swipe through a session, getting acks and matches on the same stream.
+	// User 907 likes user 1201, who then likes 907 back and passes 908 in a session.
+	if err := putDecision(ctx, pbcl, "907", "1201", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 907: %v", err)
+	}
+	if err := swipeSession(ctx, pbcl, []*pb.SwipeSessionRequest{
+		{RequestId: "a", Decision: &pb.PutDecisionRequest{ActorUserId: "1201", RecipientUserId: "907", LikedRecipient: true}},
+		{RequestId: "b", Decision: &pb.PutDecisionRequest{ActorUserId: "1201", RecipientUserId: "908"}},
+	}, []string{"ack a", "match a 907", "ack b"}); err != nil {
+		log.Fatal().Msgf("failed to swipe in a session: %v", err)
+	}
+
+	fmt.Println("Swipe session done")
+
+	// 17. Watch likes as they are recorded.
+	// User 909 watches their likes while user 1301 likes them.
+	watchCtx, cancelWatch := context.WithTimeout(ctx, 10*time.Second)
+	watch, err := pbcl.WatchLikes(watchCtx, &pb.WatchLikesRequest{RecipientUserId: "909"})
+	if err != nil {
+		log.Fatal().Msgf("failed to watch likes of user 909: %v", err)
+	}
+	time.Sleep(time.Second) // Give the stream time to subscribe.
+	if err := putDecision(ctx, pbcl, "1301", "909", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1301: %v", err)
+	}
+	event, err := watch.Recv()
+	if err != nil {
+		log.Fatal().Msgf("failed to receive like of user 909: %v", err)
+	}
+	if event.GetLike().GetActorId() != "1301" {
+		log.Fatal().Msgf("unexpected like event: %v", event)
+	}
+	cancelWatch()
+
+	fmt.Println("Likes watched")
+
+	// 18. Super-like and block.
+	// Users 1401 and 1403 like user 910, and 1402 super-likes them, so it's listed first. Then 910
+	// blocks 1403, whose like is hidden, and can't match with them anymore.
+	if err := putDecision(ctx, pbcl, "1401", "910", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1401: %v", err)
+	}
+	if _, err := pbcl.PutDecision(ctx, &pb.PutDecisionRequest{
+		ActorUserId:     "1402",
+		RecipientUserId: "910",
+		DecisionType:    pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+	}); err != nil {
+		log.Fatal().Msgf("failed to put super-like of user 1402: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "1403", "910", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1403: %v", err)
+	}
+	if err := listLikes(ctx, pbcl, "910", []string{"1402", "1401", "1403"}); err != nil {
+		log.Fatal().Msgf("failed to list likes of user 910: %v", err)
+	}
+	if _, err := pbcl.PutDecision(ctx, &pb.PutDecisionRequest{
+		ActorUserId:     "910",
+		RecipientUserId: "1403",
+		DecisionType:    pb.DecisionType_DECISION_TYPE_BLOCK,
+	}); err != nil {
+		log.Fatal().Msgf("failed to put block of user 910: %v", err)
+	}
+	if err := listLikes(ctx, pbcl, "910", []string{"1402", "1401"}); err != nil {
+		log.Fatal().Msgf("failed to list likes of user 910 after blocking: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "1403", "910", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of blocked user 1403: %v", err)
+	}
+
+	fmt.Println("Super-likes and blocks checked")
+
+	// 19. Undo decisions.
+	// User 1501 likes user 911, who passes them and then likes them, matching. User 911 undoes the
+	// like, which restores the pass and dissolves the match, and then the pass, which was the first
+	// decision, so there's nothing left to undo.
+	if err := putDecision(ctx, pbcl, "1501", "911", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1501: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "911", "1501", false, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 911: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "911", "1501", true, true); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 911: %v", err)
+	}
+	for _, want := range []pb.DecisionType{pb.DecisionType_DECISION_TYPE_PASS, pb.DecisionType_DECISION_TYPE_UNSPECIFIED} {
+		resp, err := pbcl.UndoDecision(ctx, &pb.UndoDecisionRequest{ActorUserId: "911", RecipientUserId: "1501"})
+		if err != nil {
+			log.Fatal().Msgf("failed to undo decision of user 911: %v", err)
+		}
+		if resp.GetDecisionType() != want {
+			log.Fatal().Msgf("unexpected decision restored: got %v, want %v", resp.GetDecisionType(), want)
+		}
+		if err := listMutualMatches(ctx, pbcl, "1501", nil); err != nil {
+			log.Fatal().Msgf("failed to list mutual matches of user 1501: %v", err)
+		}
+	}
+	_, err = pbcl.UndoDecision(ctx, &pb.UndoDecisionRequest{ActorUserId: "911", RecipientUserId: "1501"})
+	if status.Code(err) != codes.NotFound {
+		log.Fatal().Msgf("unexpected error undoing without decision: %v", err)
+	}
+
+	fmt.Println("Decisions undone")
+
+	// 20. Unmatch and delete decisions.
+	// Users 912 and 1601 match, and 912 unmatches 1601, so neither is listed by the other. When 1601
+	// likes 912 again it's not a match, until 912 likes them anew. Then 1601 deletes their like.
+	if err := putDecision(ctx, pbcl, "912", "1601", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 912: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "1601", "912", true, true); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1601: %v", err)
+	}
+	if _, err := pbcl.Unmatch(ctx, &pb.UnmatchRequest{UserId: "912", PartnerUserId: "1601"}); err != nil {
+		log.Fatal().Msgf("failed to unmatch user 912: %v", err)
+	}
+	for _, user := range []string{"912", "1601"} {
+		if err := listMutualMatches(ctx, pbcl, user, nil); err != nil {
+			log.Fatal().Msgf("failed to list mutual matches of user %s: %v", user, err)
+		}
+		if err := listLikes(ctx, pbcl, user, nil); err != nil {
+			log.Fatal().Msgf("failed to list likes of user %s: %v", user, err)
+		}
+	}
+	if err := putDecision(ctx, pbcl, "1601", "912", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1601: %v", err)
+	}
+	if _, err := pbcl.DeleteDecision(ctx, &pb.DeleteDecisionRequest{ActorUserId: "1601", RecipientUserId: "912"}); err != nil {
+		log.Fatal().Msgf("failed to delete decision of user 1601: %v", err)
+	}
+	if err := listLikes(ctx, pbcl, "912", nil); err != nil {
+		log.Fatal().Msgf("failed to list likes of user 912: %v", err)
+	}
+	_, err = pbcl.Unmatch(ctx, &pb.UnmatchRequest{UserId: "912", PartnerUserId: "1601"})
+	if status.Code(err) != codes.NotFound {
+		log.Fatal().Msgf("unexpected error unmatching users not matched: %v", err)
+	}
+
+	fmt.Println("Unmatched and deleted decisions")
+
+	// 21. Get the relationships of a user with several others at once.
+	// User 913 matches 1701, and super-likes 1702, who passes them. 1703 has no decision with 913.
+	if err := putDecision(ctx, pbcl, "913", "1701", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 913: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "1701", "913", true, true); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1701: %v", err)
+	}
+	if _, err := pbcl.PutDecision(ctx, &pb.PutDecisionRequest{
+		ActorUserId:     "913",
+		RecipientUserId: "1702",
+		DecisionType:    pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+	}); err != nil {
+		log.Fatal().Msgf("failed to put super-like of user 913: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "1702", "913", false, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1702: %v", err)
+	}
+	if err := getRelationship(ctx, pbcl, "913", []string{
+		"1701 like like matched",
+		"1702 super-like pass",
+		"1703 unspecified unspecified",
+	}); err != nil {
+		log.Fatal().Msgf("failed to get relationships of user 913: %v", err)
+	}
+
+	fmt.Println("Relationships got")
+
+	// 22. List the decisions of a user on others.
+	// User 914 likes 1801, who likes them back, passes 1802 and super-likes 1803. The decisions are
+	// made within the same second, so they are sorted by recipient.
+	if err := putDecision(ctx, pbcl, "914", "1801", true, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 914: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "1801", "914", true, true); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 1801: %v", err)
+	}
+	if err := putDecision(ctx, pbcl, "914", "1802", false, false); err != nil {
+		log.Fatal().Msgf("failed to put decision of user 914: %v", err)
+	}
+	if _, err := pbcl.PutDecision(ctx, &pb.PutDecisionRequest{
+		ActorUserId:     "914",
+		RecipientUserId: "1803",
+		DecisionType:    pb.DecisionType_DECISION_TYPE_SUPER_LIKE,
+	}); err != nil {
+		log.Fatal().Msgf("failed to put super-like of user 914: %v", err)
+	}
+	for _, tc := range []struct {
+		in       *pb.ListYouLikedRequest
+		expected []string
+	}{
+		{in: &pb.ListYouLikedRequest{ActorUserId: "914"}, expected: []string{"1801", "1802", "1803"}},
+		{in: &pb.ListYouLikedRequest{ActorUserId: "914", PendingOnly: true}, expected: []string{"1803"}},
+		{
+			in:       &pb.ListYouLikedRequest{ActorUserId: "914", DecisionTypes: []pb.DecisionType{pb.DecisionType_DECISION_TYPE_PASS}},
+			expected: []string{"1802"},
+		},
+		{
+			in:       &pb.ListYouLikedRequest{ActorUserId: "914", UntilUnixTimestamp: uint64(time.Now().Add(-time.Hour).Unix())},
+			expected: nil,
+		},
+	} {
+		if err := listYouLiked(ctx, pbcl, tc.in, tc.expected); err != nil {
+			log.Fatal().Msgf("failed to list decisions of user 914: %v", err)
+		}
+	}
+
+	fmt.Println("Decisions of a user listed")
+
+	// 23. List likes across multiple pages sorted by time.
+	// The likes of step 9 were put in the order of their actors, so the oldest ones sort the same way,
+	// and the likes put within the same second are sorted by actor too.
+	newestLikers := slices.Clone(wantLikers)
+	slices.Reverse(newestLikers)
+	if err := listAllLikes(ctx, pbcl, "900", pb.SortOrder_SORT_ORDER_OLDEST, wantLikers); err != nil {
+		log.Fatal().Msgf("failed to list the oldest likes for user 900 first: %v", err)
+	}
+	if err := listAllLikes(ctx, pbcl, "900", pb.SortOrder_SORT_ORDER_NEWEST, newestLikers); err != nil {
+		log.Fatal().Msgf("failed to list the newest likes for user 900 first: %v", err)
+	}
+
+	fmt.Println("Likes listed across pages by time")
+
+	// 24. List likes with a page size over the maximum, along with their total count.
+	// The service is configured with a maximum page size of 20, under the 25 likes of user 900.
+	likesResp, err := pbcl.ListLikedYou(ctx, &pb.ListLikedYouRequest{
+		RecipientUserId:   "900",
+		PageSize:          proto.Uint32(50),
+		IncludeTotalCount: true,
+	})
+	if err != nil {
+		log.Fatal().Msgf("failed to list likes for user 900: %v", err)
+	}
+	if len(likesResp.GetLikers()) != 20 || likesResp.GetTotalCount() != uint64(len(wantLikers)) {
+		log.Fatal().Msgf(
+			"unexpected page: got %d likes of %d, want 20 of %d",
+			len(likesResp.GetLikers()),
+			likesResp.GetTotalCount(),
+			len(wantLikers),
+		)
+	}
+
+	fmt.Println("Likes listed with page size and total count")
 	fmt.Println("All the checks passed!")
 }
 
+// swipeSession sends decisions through a session, and checks the events received back, written as
+// "ack <request ID>" or "match <request ID> <partner ID>".
+func swipeSession(
+	ctx context.Context,
+	pbcl pb.ExploreServiceClient,
+	decisions []*pb.SwipeSessionRequest,
+	wantEvents []string,
+) error {
+	session, err := pbcl.SwipeSession(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	for _, decision := range decisions {
+		if err := session.Send(decision); err != nil {
+			return fmt.Errorf("failed to send decision: %w", err)
+		}
+	}
+	if err := session.CloseSend(); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+	var events []string
+	for {
+		resp, err := session.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to receive event: %w", err)
+		}
+		switch {
+		case resp.GetAck() != nil && resp.GetAck().GetError() != nil:
+			return fmt.Errorf("decision %s failed: %s", resp.GetAck().GetRequestId(), resp.GetAck().GetError().GetMessage())
+		case resp.GetAck() != nil:
+			events = append(events, "ack "+resp.GetAck().GetRequestId())
+		case resp.GetMatch() != nil:
+			events = append(events, "match "+resp.GetMatch().GetRequestId()+" "+resp.GetMatch().GetPartnerId())
+		}
+	}
+	if fmt.Sprint(events) != fmt.Sprint(wantEvents) {
+		return fmt.Errorf("unexpected events: got %v, want %v", events, wantEvents)
+	}
+	return nil
+}
+
 func putDecision(
 	ctx context.Context,
 	pbcl pb.ExploreServiceClient,
@@ -112,9 +606,23 @@ func listNewLikes(
 	ctx context.Context,
 	pbcl pb.ExploreServiceClient,
 	recipientUser string,
+	ackMode pb.AcknowledgementMode,
+	wantDecisions []string,
+) error {
+	return checkNewLikes(ctx, pbcl, &pb.ListLikedYouRequest{
+		RecipientUserId:     recipientUser,
+		AcknowledgementMode: ackMode,
+	}, wantDecisions)
+}
+
+// checkNewLikes lists the new likes of a request, and checks they are the wanted ones.
+func checkNewLikes(
+	ctx context.Context,
+	pbcl pb.ExploreServiceClient,
+	req *pb.ListLikedYouRequest,
 	wantDecisions []string,
 ) error {
-	resp, err := pbcl.ListNewLikedYou(ctx, &pb.ListLikedYouRequest{RecipientUserId: recipientUser})
+	resp, err := pbcl.ListNewLikedYou(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to list new likes: %w", err)
 	}
@@ -166,6 +674,125 @@ func listLikes(
 	return nil
 }
 
+// listAllLikes follows the pagination tokens of ListLikedYou in the given sort order until the end,
+// checking no like is missed nor repeated.
+func listAllLikes(
+	ctx context.Context,
+	pbcl pb.ExploreServiceClient,
+	recipientUser string,
+	sortOrder pb.SortOrder,
+	wantDecisions []string,
+) error {
+	var gotDecisions []string
+	var pageToken *string
+	for pages := 0; ; pages++ {
+		if pages > len(wantDecisions) {
+			return fmt.Errorf("pagination did not end after %d pages", pages)
+		}
+		resp, err := pbcl.ListLikedYou(ctx, &pb.ListLikedYouRequest{
+			RecipientUserId: recipientUser,
+			PaginationToken: pageToken,
+			SortOrder:       sortOrder,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list likes: %w", err)
+		}
+		if len(resp.GetLikers()) == 0 {
+			break
+		}
+		for _, liker := range resp.GetLikers() {
+			gotDecisions = append(gotDecisions, liker.GetActorId())
+		}
+		pageToken = resp.NextPaginationToken
+	}
+	if len(gotDecisions) != len(wantDecisions) {
+		return fmt.Errorf("unexpected number of likes: got %d, want %d", len(gotDecisions), len(wantDecisions))
+	}
+	errMsg := ""
+	for i := range gotDecisions {
+		if gotDecisions[i] != wantDecisions[i] {
+			errMsg += fmt.Sprintf("unexpected like: got %s, want %s\n", gotDecisions[i], wantDecisions[i])
+		}
+	}
+	if errMsg != "" {
+		return fmt.Errorf("error matching likes: %s", errMsg)
+	}
+	return nil
+}
+
+func listMutualMatches(
+	ctx context.Context,
+	pbcl pb.ExploreServiceClient,
+	user string,
+	wantPartners []string,
+) error {
+	resp, err := pbcl.ListMutualMatches(ctx, &pb.ListMutualMatchesRequest{UserId: user})
+	if err != nil {
+		return fmt.Errorf("failed to list mutual matches: %w", err)
+	}
+	if len(resp.GetMatches()) != len(wantPartners) {
+		return fmt.Errorf("unexpected number of matches: got %d, want %d", len(resp.GetMatches()), len(wantPartners))
+	}
+	errMsg := ""
+	for i := range resp.GetMatches() {
+		if resp.GetMatches()[i].GetPartnerId() != wantPartners[i] {
+			errMsg += fmt.Sprintf(
+				"unexpected match: got %s, want %s\n",
+				resp.GetMatches()[i].GetPartnerId(),
+				wantPartners[i],
+			)
+		}
+	}
+	if errMsg != "" {
+		return fmt.Errorf("error matching mutual matches: %s", errMsg)
+	}
+	return nil
+}
+
+// listYouLiked lists the decisions of a user, and checks their recipients against the expected ones.
+func listYouLiked(ctx context.Context, pbcl pb.ExploreServiceClient, in *pb.ListYouLikedRequest, expected []string) error {
+	resp, err := pbcl.ListYouLiked(ctx, in)
+	if err != nil {
+		return err
+	}
+	var got []string
+	for _, decision := range resp.GetDecisions() {
+		got = append(got, decision.GetRecipientId())
+	}
+	if !slices.Equal(got, expected) {
+		return fmt.Errorf("unexpected decisions: got %v, want %v", got, expected)
+	}
+	return nil
+}
+
+// getRelationship gets the relationships of a user, and checks them against the expected ones,
+// written as "<other user ID> <viewer decision> <other decision> [matched]".
+func getRelationship(ctx context.Context, pbcl pb.ExploreServiceClient, viewerID string, expected []string) error {
+	otherIDs := make([]string, 0, len(expected))
+	for _, e := range expected {
+		otherIDs = append(otherIDs, strings.Fields(e)[0])
+	}
+	resp, err := pbcl.GetRelationship(ctx, &pb.GetRelationshipRequest{ViewerUserId: viewerID, OtherUserIds: otherIDs})
+	if err != nil {
+		return err
+	}
+	name := func(t pb.DecisionType) string {
+		return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(t.String(), "DECISION_TYPE_")), "_", "-")
+	}
+	got := make([]string, 0, len(resp.GetRelationships()))
+	for _, r := range resp.GetRelationships() {
+		g := fmt.Sprintf("%s %s %s", r.GetOtherUserId(), name(r.GetViewerDecisionType()), name(r.GetOtherDecisionType()))
+		if r.GetMatched() {
+			g += " matched"
+		}
+		got = append(got, g)
+	}
+	if !slices.Equal(got, expected) {
+		return fmt.Errorf("unexpected relationships: got %v, want %v", got, expected)
+	}
+	return nil
+}
+
 func countDecisions(ctx context.Context, pbcl pb.ExploreServiceClient) error {
 	// Count likes for user 1.
 	count, err := pbcl.CountLikedYou(ctx, &pb.CountLikedYouRequest{RecipientUserId: "1"})